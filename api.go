@@ -5,7 +5,9 @@
 // A more complex protocol might have many different meanings for the same operation.
 // A natural pattern to ensure parseability is to precede each operation with a comment in the
 // transcript that disambiguates it. Such information is usually provided anyway through protocol
-// an operation called framing.
+// an operation called framing. SendMessage and RecvMessage frame messages this way.
+//
+// SendStream and RecvStream adapt streaming SendENC and RecvENC to cipher.Stream.
 //
 package strobe
 
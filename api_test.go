@@ -410,3 +410,116 @@ func mustReadFile(t *testing.T, filename string) []byte {
 
 	return raw
 }
+
+func TestStrobe_RoundTrip(t *testing.T) {
+	alice := mustNewStrobe(t, "round trip", strobe.Bit128)
+	bob := mustNewStrobe(t, "round trip", strobe.Bit128)
+	opts := &strobe.Options{}
+
+	// alice -> bob
+	msg := []byte("hello")
+	if err := alice.SendCLR(msg, opts); err != nil {
+		t.Fatalf("alice fail to SendCLR: %v", err)
+	}
+	if err := bob.RecvCLR(msg, opts); err != nil {
+		t.Fatalf("bob fail to RecvCLR: %v", err)
+	}
+
+	// bob -> alice
+	var mac [16]byte
+	if err := bob.SendMAC(mac[:], opts); err != nil {
+		t.Fatalf("bob fail to SendMAC: %v", err)
+	}
+	if err := alice.RecvMAC(mac[:], opts); err != nil {
+		t.Fatalf("alice fail to RecvMAC: %v", err)
+	}
+}
+
+// TestStrobe_Reply checks a responder replying to the initiator against the reference
+// implementation, which pins down the I flag of operations sent by the responder.
+func TestStrobe_Reply(t *testing.T) {
+	type TestCase struct {
+		Key           []byte
+		Request       []byte
+		RequestCipher []byte
+		RequestMAC    []byte
+		Reply         []byte
+		ReplyCipher   []byte
+		ReplyMAC      []byte
+		PRF           []byte
+	}
+
+	type TestVector struct {
+		Proto         string
+		SecurityLevel int
+		Cases         []TestCase
+	}
+
+	raw := mustReadFile(t, "testdata/send_then_reply.json")
+	var testVector []TestVector
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	opts := &strobe.Options{}
+	for i, v := range testVector {
+		securityLevel := strobe.SecurityLevel(v.SecurityLevel)
+		for j, c := range v.Cases {
+			initiator := mustNewStrobe(t, v.Proto, securityLevel)
+			_ = initiator.KEY(append([]byte{}, c.Key...), false)
+			responder := mustNewStrobe(t, v.Proto, securityLevel)
+			_ = responder.KEY(append([]byte{}, c.Key...), false)
+
+			if got, err := initiator.SendENC(append([]byte{}, c.Request...), opts); err != nil {
+				t.Fatalf("#%d-%d initiator fail to SendENC: %v", i, j, err)
+			} else if !bytes.Equal(c.RequestCipher, got) {
+				t.Fatalf("#%d-%d invalid request: expect %x, got %x", i, j, c.RequestCipher, got)
+			}
+
+			mac := make([]byte, len(c.RequestMAC))
+			if err := initiator.SendMAC(mac, opts); err != nil {
+				t.Fatalf("#%d-%d initiator fail to SendMAC: %v", i, j, err)
+			} else if !bytes.Equal(c.RequestMAC, mac) {
+				t.Fatalf("#%d-%d invalid request MAC: expect %x, got %x", i, j, c.RequestMAC, mac)
+			}
+
+			if _, err := responder.RecvENC(append([]byte{}, c.RequestCipher...), opts); err != nil {
+				t.Fatalf("#%d-%d responder fail to RecvENC: %v", i, j, err)
+			}
+			if err := responder.RecvMAC(mac, opts); err != nil {
+				t.Fatalf("#%d-%d responder fail to RecvMAC: %v", i, j, err)
+			}
+
+			if got, err := responder.SendENC(append([]byte{}, c.Reply...), opts); err != nil {
+				t.Fatalf("#%d-%d responder fail to SendENC: %v", i, j, err)
+			} else if !bytes.Equal(c.ReplyCipher, got) {
+				t.Fatalf("#%d-%d invalid reply: expect %x, got %x", i, j, c.ReplyCipher, got)
+			}
+
+			mac = make([]byte, len(c.ReplyMAC))
+			if err := responder.SendMAC(mac, opts); err != nil {
+				t.Fatalf("#%d-%d responder fail to SendMAC: %v", i, j, err)
+			} else if !bytes.Equal(c.ReplyMAC, mac) {
+				t.Fatalf("#%d-%d invalid reply MAC: expect %x, got %x", i, j, c.ReplyMAC, mac)
+			}
+
+			if got, err := initiator.RecvENC(append([]byte{}, c.ReplyCipher...), opts); err != nil {
+				t.Fatalf("#%d-%d initiator fail to RecvENC: %v", i, j, err)
+			} else if !bytes.Equal(c.Reply, got) {
+				t.Fatalf("#%d-%d invalid reply plaintext: expect %x, got %x", i, j, c.Reply, got)
+			}
+			if err := initiator.RecvMAC(mac, opts); err != nil {
+				t.Fatalf("#%d-%d initiator fail to RecvMAC: %v", i, j, err)
+			}
+
+			for k, s := range []*strobe.Strobe{initiator, responder} {
+				prf := make([]byte, len(c.PRF))
+				if err := s.PRF(prf, false); err != nil {
+					t.Fatalf("#%d-%d-%d fail to PRF: %v", i, j, k, err)
+				} else if !bytes.Equal(c.PRF, prf) {
+					t.Fatalf("#%d-%d-%d invalid PRF: expect %x, got %x", i, j, k, c.PRF, prf)
+				}
+			}
+		}
+	}
+}
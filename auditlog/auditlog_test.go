@@ -0,0 +1,178 @@
+package auditlog_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sammyne/strobe/auditlog"
+)
+
+// all entries are entrySize bytes, so that records are recordSize bytes
+const (
+	entrySize  = 8
+	recordSize = 13 + entrySize + auditlog.TagSize
+)
+
+var key = bytes.Repeat([]byte{0x42}, auditlog.MinKeySize)
+
+func TestCreate(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.log")
+
+	w, err := auditlog.Create(name, key)
+	if err != nil {
+		t.Fatalf("fail to create log: %v", err)
+	}
+
+	entries := mustEntries(5)
+	for i, v := range entries {
+		if err := w.Append(v); err != nil {
+			t.Fatalf("#%d fail to append: %v", i, err)
+		}
+	}
+	if n := w.Len(); n != uint64(len(entries)) {
+		t.Fatalf("invalid length: expect %d, got %d", len(entries), n)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+	if err := w.Append(entries[0]); err != auditlog.ErrClosed {
+		t.Fatalf("invalid error for closed log: expect %v, got %v", auditlog.ErrClosed, err)
+	}
+
+	if _, err := auditlog.Create(name, key); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("invalid error for existing log: expect %v, got %v", os.ErrExist, err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("fail to open log: %v", err)
+	}
+	defer f.Close()
+
+	r, err := auditlog.NewReader(f, key)
+	if err != nil {
+		t.Fatalf("fail to new reader: %v", err)
+	}
+
+	for i, v := range entries {
+		if got, err := r.Next(); err != nil {
+			t.Fatalf("#%d fail to read: %v", i, err)
+		} else if !bytes.Equal(v, got) {
+			t.Fatalf("#%d invalid entry: expect %q, got %q", i, v, got)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("invalid error after seal: expect %v, got %v", io.EOF, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	log := mustLog(t, 5, true)
+	unsealed := mustLog(t, 5, false)
+
+	record := func(i int) []byte {
+		return log[i*recordSize : (i+1)*recordSize]
+	}
+	flip := func(i int) []byte {
+		out := append([]byte{}, log...)
+		out[i] ^= 1
+		return out
+	}
+	concat := func(parts ...[]byte) []byte {
+		var out []byte
+		for _, v := range parts {
+			out = append(out, v...)
+		}
+		return out
+	}
+
+	anotherKey := append([]byte{}, key...)
+	anotherKey[0] ^= 1
+
+	testVector := []struct {
+		key    []byte
+		log    []byte
+		n      uint64
+		expect error
+	}{
+		{key, log, 5, nil},
+		{key, unsealed, 5, auditlog.ErrUnsealed},
+		{anotherKey, log, 0, auditlog.ErrTampered},
+		// entry, tag and type of record #2
+		{key, flip(2*recordSize + 13), 2, auditlog.ErrTampered},
+		{key, flip(3*recordSize - 1), 2, auditlog.ErrTampered},
+		{key, flip(2 * recordSize), 2, auditlog.ErrInvalidRecord},
+		// reordered and removed records
+		{key, concat(record(0), record(2), record(1), log[3*recordSize:]), 1, auditlog.ErrOutOfOrder},
+		{key, concat(record(0), log[2*recordSize:]), 1, auditlog.ErrOutOfOrder},
+		// truncated in the middle of a record, and at a record boundary
+		{key, log[:3*recordSize+20], 3, auditlog.ErrTruncated},
+		{key, log[:3*recordSize+5], 3, auditlog.ErrTruncated},
+		{key, log[:3*recordSize], 3, auditlog.ErrUnsealed},
+		{key, log[:len(log)-1], 5, auditlog.ErrTruncated},
+		{key, append(append([]byte{}, log...), 0), 5, auditlog.ErrTrailingData},
+	}
+
+	for i, c := range testVector {
+		n, err := auditlog.Verify(bytes.NewReader(c.log), c.key)
+		if !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		} else if n != c.n {
+			t.Fatalf("#%d invalid #(verified entries): expect %d, got %d", i, c.n, n)
+		}
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	if _, err := auditlog.NewWriter(io.Discard, key[1:]); err != auditlog.ErrInvalidKey {
+		t.Fatalf("invalid error for short key: expect %v, got %v", auditlog.ErrInvalidKey, err)
+	}
+
+	w, err := auditlog.NewWriter(io.Discard, key)
+	if err != nil {
+		t.Fatalf("fail to new writer: %v", err)
+	}
+
+	if err := w.Append(make([]byte, auditlog.MaxEntrySize+1)); err != auditlog.ErrEntryTooLong {
+		t.Fatalf("invalid error for long entry: expect %v, got %v", auditlog.ErrEntryTooLong, err)
+	}
+}
+
+func mustEntries(n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = []byte(fmt.Sprintf("entry-%02d", i))
+	}
+
+	return out
+}
+
+// mustLog returns the log of n entries, which is sealed if sealed is true.
+func mustLog(t *testing.T, n int, sealed bool) []byte {
+	var buf bytes.Buffer
+
+	w, err := auditlog.NewWriter(&buf, key)
+	if err != nil {
+		t.Fatalf("fail to new writer: %v", err)
+	}
+
+	for i, v := range mustEntries(n) {
+		if err := w.Append(v); err != nil {
+			t.Fatalf("#%d fail to append: %v", i, err)
+		}
+	}
+
+	if sealed {
+		if err := w.Close(); err != nil {
+			t.Fatalf("fail to close: %v", err)
+		}
+	}
+
+	return buf.Bytes()
+}
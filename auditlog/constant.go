@@ -0,0 +1,28 @@
+package auditlog
+
+// Proto is the STROBE proto of audit logs.
+const Proto = "github.com/sammyne/strobe/auditlog/v1"
+
+const (
+	// MaxEntrySize is the maximum size of entries in bytes.
+	MaxEntrySize = 16 << 20
+	// MinKeySize is the minimum size of initial keys in bytes.
+	MinKeySize = 32
+	// RatchetLen is the number of bytes ratcheted after each record.
+	RatchetLen = 32
+	// TagSize is the size of the MAC of each record in bytes.
+	TagSize = 32
+)
+
+// recordHeaderLen is the length of the record header, i.e. the 1-byte type followed by the 8-byte
+// little-endian sequence number and the 4-byte little-endian length of the entry.
+const recordHeaderLen = 13
+
+// Types of records.
+const (
+	recordEntry = 0
+	recordSeal  = 1
+)
+
+// labelKey frames the initial key.
+const labelKey = "key"
@@ -0,0 +1,26 @@
+package auditlog
+
+import "errors"
+
+var (
+	// ErrClosed is the error returned when appending to a closed Writer.
+	ErrClosed = errors.New("log closed")
+	// ErrEntryTooLong is the error returned by Append when the entry exceeds MaxEntrySize.
+	ErrEntryTooLong = errors.New("entry too long")
+	// ErrInvalidKey is the error returned when the initial key is shorter than MinKeySize.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrInvalidRecord is the error returned when a record header is malformed.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrOutOfOrder is the error returned when a record isn't the next one in sequence, i.e.
+	// records are reordered or removed.
+	ErrOutOfOrder = errors.New("record out of order")
+	// ErrTampered is the error returned when the MAC of a record is invalid.
+	ErrTampered = errors.New("record tampered")
+	// ErrTrailingData is the error returned when data follows the seal.
+	ErrTrailingData = errors.New("trailing data after seal")
+	// ErrTruncated is the error returned when the log ends in the middle of a record.
+	ErrTruncated = errors.New("log truncated")
+	// ErrUnsealed is the error returned when the log ends without the seal written by Close, i.e.
+	// it is still being written, or truncated at a record boundary.
+	ErrUnsealed = errors.New("log unsealed")
+)
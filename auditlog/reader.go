@@ -0,0 +1,127 @@
+package auditlog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Reader replays the chain of a log produced by Writer. Entries are returned only after their
+// MACs are verified, and io.EOF is returned only after the seal.
+//
+// Errors locate the first invalid record by its index and byte offset, wrapping ErrInvalidRecord,
+// ErrOutOfOrder, ErrTampered, ErrTrailingData, ErrTruncated or ErrUnsealed.
+type Reader struct {
+	r      io.Reader
+	s      *strobe.Strobe
+	seq    uint64
+	offset int64
+	err    error
+}
+
+// NewReader returns a Reader verifying the log from r with the initial key.
+func NewReader(r io.Reader, key []byte) (*Reader, error) {
+	s, err := newStrobe(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{r: r, s: s}, nil
+}
+
+// Next returns the next entry of the log.
+func (r *Reader) Next() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	entry, err := r.readRecord()
+	if err == io.EOF {
+		r.err = io.EOF
+	} else if err != nil {
+		r.err = fmt.Errorf("%w: record #%d at offset %d", err, r.seq, r.offset)
+	}
+
+	return entry, r.err
+}
+
+// Verify replays the whole log from r with the initial key, and returns the number of entries
+// verified, which is also the index of the first invalid record on error.
+func Verify(r io.Reader, key []byte) (uint64, error) {
+	rr, err := NewReader(r, key)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		if _, err := rr.Next(); err == io.EOF {
+			return rr.seq, nil
+		} else if err != nil {
+			return rr.seq, err
+		}
+	}
+}
+
+func (r *Reader) readRecord() ([]byte, error) {
+	hdr := make([]byte, recordHeaderLen)
+	if _, err := io.ReadFull(r.r, hdr); err == io.EOF {
+		return nil, ErrUnsealed
+	} else if err == io.ErrUnexpectedEOF {
+		return nil, ErrTruncated
+	} else if err != nil {
+		return nil, err
+	}
+
+	typ, seq := hdr[0], binary.LittleEndian.Uint64(hdr[1:9])
+	n := binary.LittleEndian.Uint32(hdr[9:recordHeaderLen])
+	if (typ != recordEntry && typ != recordSeal) || n > MaxEntrySize || (typ == recordSeal && n != 0) {
+		return nil, ErrInvalidRecord
+	} else if seq != r.seq {
+		return nil, ErrOutOfOrder
+	}
+
+	if err := r.s.RecvCLR(hdr, &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, int(n)+TagSize)
+	if _, err := io.ReadFull(r.r, body); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrTruncated
+	} else if err != nil {
+		return nil, err
+	}
+
+	entry := body[:n]
+	if err := r.s.RecvCLR(entry, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	if err := r.s.RecvMAC(body[n:], &strobe.Options{}); err == strobe.ErrAuthenticationFailed {
+		return nil, ErrTampered
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := r.s.RATCHET(RatchetLen); err != nil {
+		return nil, err
+	}
+
+	if typ == recordSeal {
+		// a single Read may return no data without an error, so read until EOF
+		var trailing [1]byte
+		if _, err := io.ReadFull(r.r, trailing[:]); err == nil {
+			return nil, ErrTrailingData
+		} else if err != io.EOF {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	r.seq++
+	r.offset += int64(recordHeaderLen) + int64(len(body))
+
+	return entry, nil
+}
@@ -0,0 +1,181 @@
+// Package auditlog implements a forward-secure append-only audit log, which stays tamper-evident
+// even if the logging host is compromised later on.
+//
+// The log is a sequence of records, each laid out as
+//
+//	type (1) || sequence number (8) || length (4) || entry || tag (TagSize)
+//
+// The record header goes through a meta-CLR and the entry through CLR of a STROBE instance keyed
+// with the initial key, followed by a SendMAC tag and a RATCHET. Since the state is ratcheted after
+// every record and the initial key is never kept, an attacker seizing the writer learns nothing
+// about the tags of earlier records, so can't rewrite them without detection. The verifier, given
+// the initial key, replays the chain and pinpoints the first tampered, reordered or truncated
+// record. Close appends a seal record, so that truncating a closed log is detected too.
+//
+// Entries are authenticated but not encrypted.
+package auditlog
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sammyne/strobe"
+)
+
+// Writer appends entries to a log, which is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	s      *strobe.Strobe
+	seq    uint64
+	err    error
+}
+
+// Create creates the named file, which must not exist, and returns a Writer appending to it.
+// Closing the Writer closes the file.
+func Create(name string, key []byte) (*Writer, error) {
+	if len(key) < MinKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := NewWriter(f, key)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.closer = f
+
+	return w, nil
+}
+
+// NewWriter returns a Writer appending records to w, which MACs records under the initial key of
+// at least MinKeySize bytes. The key should be erased by the caller once the Writer is created.
+func NewWriter(w io.Writer, key []byte) (*Writer, error) {
+	s, err := newStrobe(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, s: s}, nil
+}
+
+// Append appends the entry to the log.
+func (w *Writer) Append(entry []byte) error {
+	if len(entry) > MaxEntrySize {
+		return ErrEntryTooLong
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.writeRecord(recordEntry, entry)
+	return w.err
+}
+
+// Close appends the seal record, marking the end of the log, and closes the file opened by Create.
+// It doesn't close the writer passed to NewWriter.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	err := w.writeRecord(recordSeal, nil)
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.err = ErrClosed
+
+	return nil
+}
+
+// Len returns the number of entries appended so far.
+func (w *Writer) Len() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.seq
+}
+
+func (w *Writer) writeRecord(typ byte, entry []byte) error {
+	out := make([]byte, recordHeaderLen+len(entry)+TagSize)
+	out[0] = typ
+	binary.LittleEndian.PutUint64(out[1:9], w.seq)
+	binary.LittleEndian.PutUint32(out[9:recordHeaderLen], uint32(len(entry)))
+
+	if err := w.s.SendCLR(out[:recordHeaderLen], &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	n := recordHeaderLen + len(entry)
+	copy(out[recordHeaderLen:], entry)
+	if err := w.s.SendCLR(out[recordHeaderLen:n], &strobe.Options{}); err != nil {
+		return err
+	}
+
+	if err := w.s.SendMAC(out[n:], &strobe.Options{}); err != nil {
+		return err
+	}
+
+	// erases the state tagging this record, for forward security
+	if err := w.s.RATCHET(RatchetLen); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(out); err != nil {
+		return err
+	}
+	w.seq++
+
+	return nil
+}
+
+func newStrobe(key []byte) (*strobe.Strobe, error) {
+	if len(key) < MinKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelKey), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	k := append([]byte{}, key...)
+	defer zero(k)
+	if err := s.KEY(k, false); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,110 @@
+// Package balloon implements the Balloon memory-hard password hashing function, using STROBE as the
+// underlying compression function.
+//
+// The construction follows "Balloon Hashing: A Memory-Hard Function Providing Provable Protection
+// Against Sequential Attacks" by Boneh, Corrigan-Gibbs and Schechter, in its Balloon-M flavor when
+// the parallelism is greater than 1.
+//
+// See also https://eprint.iacr.org/2016/027.
+package balloon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+)
+
+// Params specifies the cost parameters of the hashing.
+type Params struct {
+	// SpaceCost is the number of BlockSize blocks filled per lane, which determines the memory usage.
+	SpaceCost uint32
+	// TimeCost is the number of mixing rounds over the buffer.
+	TimeCost uint32
+	// Parallelism is the number of independent lanes computed concurrently, whose outputs are
+	// combined into the final hash.
+	Parallelism uint32
+}
+
+// DefaultParams is a reasonable default as for interactive logins, which costs 1 MiB memory.
+var DefaultParams = Params{SpaceCost: 1 << 14, TimeCost: 3, Parallelism: 1}
+
+// Validate checks the cost parameters, which must be positive and bounded by MaxSpaceCost,
+// MaxTimeCost and MaxParallelism. It returns ErrInvalidParams or ErrParamsTooLarge otherwise.
+func (p *Params) Validate() error {
+	if p.SpaceCost == 0 || p.TimeCost == 0 || p.Parallelism == 0 {
+		return ErrInvalidParams
+	}
+
+	if uint64(p.SpaceCost)*uint64(p.Parallelism) > MaxSpaceCost || p.TimeCost > MaxTimeCost ||
+		p.Parallelism > MaxParallelism {
+		return ErrParamsTooLarge
+	}
+
+	return nil
+}
+
+// Key derives a keyLen-byte key from the password and salt with the given cost parameters. It
+// fails with ErrInvalidKeyLen if keyLen isn't positive.
+func Key(password, salt []byte, params *Params, keyLen int) ([]byte, error) {
+	if keyLen <= 0 {
+		return nil, ErrInvalidKeyLen
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return key(password, salt, params, keyLen)
+}
+
+// Hash hashes the password with a random salt and the given parameters, and returns the hash
+// encoded as
+//	$strobe-balloon$v=1$s=<SpaceCost>,t=<TimeCost>,p=<Parallelism>$<salt>$<hash>
+// where salt and hash are encoded with the standard base64 encoding without padding.
+func Hash(password []byte, params *Params) (string, error) {
+	if err := params.Validate(); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	h, err := key(password, salt, params, HashLen)
+	if err != nil {
+		return "", err
+	}
+
+	out := &encoded{params: *params, salt: salt, hash: h}
+	return out.String(), nil
+}
+
+// NeedsRehash reports whether the encoded hash was computed with parameters different from the
+// given ones, in which case the password should be rehashed on the next successful login.
+func NeedsRehash(encodedHash string, params *Params) (bool, error) {
+	e, err := decode(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	return e.params != *params, nil
+}
+
+// Verify checks the password against the encoded hash produced by Hash. It returns nil on success,
+// or ErrMismatchedHashAndPassword if the password doesn't match.
+func Verify(password []byte, encodedHash string) error {
+	e, err := decode(encodedHash)
+	if err != nil {
+		return err
+	}
+
+	h, err := key(password, e.salt, &e.params, len(e.hash))
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(h, e.hash) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package balloon_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sammyne/strobe/balloon"
+)
+
+var testParams = balloon.Params{SpaceCost: 64, TimeCost: 2, Parallelism: 2}
+
+func TestHash(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	h, err := balloon.Hash(password, &testParams)
+	if err != nil {
+		t.Fatalf("fail to hash: %v", err)
+	}
+
+	if !strings.HasPrefix(h, "$strobe-balloon$v=1$s=64,t=2,p=2$") {
+		t.Fatalf("invalid encoded hash: %s", h)
+	}
+
+	if err := balloon.Verify(password, h); err != nil {
+		t.Fatalf("fail to verify: %v", err)
+	}
+
+	if err := balloon.Verify([]byte("wrong password"), h); err != balloon.ErrMismatchedHashAndPassword {
+		t.Fatalf("invalid error for wrong password: expect %v, got %v",
+			balloon.ErrMismatchedHashAndPassword, err)
+	}
+
+	h2, err := balloon.Hash(password, &testParams)
+	if err != nil {
+		t.Fatalf("fail to hash again: %v", err)
+	} else if h == h2 {
+		t.Fatal("salt isn't randomized")
+	}
+}
+
+func TestKey(t *testing.T) {
+	testVector := []struct {
+		password string
+		salt     string
+		params   balloon.Params
+	}{
+		{"hello", "world", balloon.Params{SpaceCost: 1, TimeCost: 1, Parallelism: 1}},
+		{"hello", "world", balloon.Params{SpaceCost: 16, TimeCost: 3, Parallelism: 1}},
+		{"hello", "world", balloon.Params{SpaceCost: 16, TimeCost: 3, Parallelism: 4}},
+	}
+
+	var outs [][]byte
+	for i, c := range testVector {
+		k1, err := balloon.Key([]byte(c.password), []byte(c.salt), &c.params, 32)
+		if err != nil {
+			t.Fatalf("#%d fail to derive key: %v", i, err)
+		}
+
+		k2, err := balloon.Key([]byte(c.password), []byte(c.salt), &c.params, 32)
+		if err != nil {
+			t.Fatalf("#%d fail to derive key again: %v", i, err)
+		} else if !bytes.Equal(k1, k2) {
+			t.Fatalf("#%d non-deterministic key: %x != %x", i, k1, k2)
+		}
+
+		for j, v := range outs {
+			if bytes.Equal(v, k1) {
+				t.Fatalf("#%d key collides with #%d", i, j)
+			}
+		}
+		outs = append(outs, k1)
+	}
+
+	if _, err := balloon.Key(nil, nil, &balloon.Params{}, 32); err != balloon.ErrInvalidParams {
+		t.Fatalf("invalid error for zero params: expect %v, got %v", balloon.ErrInvalidParams, err)
+	}
+
+	tooLarge := balloon.Params{SpaceCost: balloon.MaxSpaceCost + 1, TimeCost: 1, Parallelism: 1}
+	if _, err := balloon.Key(nil, nil, &tooLarge, 32); err != balloon.ErrParamsTooLarge {
+		t.Fatalf("invalid error for too large params: expect %v, got %v", balloon.ErrParamsTooLarge, err)
+	}
+
+	for _, keyLen := range []int{0, -1} {
+		if _, err := balloon.Key(nil, nil, &testParams, keyLen); err != balloon.ErrInvalidKeyLen {
+			t.Fatalf("invalid error for key length %d: expect %v, got %v", keyLen, balloon.ErrInvalidKeyLen, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h, err := balloon.Hash([]byte("password"), &testParams)
+	if err != nil {
+		t.Fatalf("fail to hash: %v", err)
+	}
+
+	if yes, err := balloon.NeedsRehash(h, &testParams); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if yes {
+		t.Fatal("rehash is required by the same params")
+	}
+
+	upgraded := testParams
+	upgraded.TimeCost++
+	if yes, err := balloon.NeedsRehash(h, &upgraded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !yes {
+		t.Fatal("rehash isn't required by upgraded params")
+	}
+}
+
+func TestVerify_InvalidHash(t *testing.T) {
+	testVector := []struct {
+		encoded string
+		expect  error
+	}{
+		{"", balloon.ErrInvalidHash},
+		{"$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=2$s=1,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrUnsupportedVersion},
+		{"$strobe-balloon$v=1$s=1,t=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1x$s=1,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=01$s=1,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=+1$s=1,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$s=1,t=1,p=1x$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$s=1,t=1,p=1,x=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$s=1,t= 1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$t=1,s=1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$s=0,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrInvalidParams},
+		{"$strobe-balloon$v=1$s=4294967295,t=1,p=1$c2FsdA$aGFzaA", balloon.ErrParamsTooLarge},
+		{"$strobe-balloon$v=1$s=16777216,t=1,p=2$c2FsdA$aGFzaA", balloon.ErrParamsTooLarge},
+		{"$strobe-balloon$v=1$s=1,t=4294967295,p=1$c2FsdA$aGFzaA", balloon.ErrParamsTooLarge},
+		{"$strobe-balloon$v=1$s=1,t=1,p=65536$c2FsdA$aGFzaA", balloon.ErrParamsTooLarge},
+		{"$strobe-balloon$v=1$s=1,t=1,p=1$c2FsdA==$aGFzaA", balloon.ErrInvalidHash},
+		{"$strobe-balloon$v=1$s=1,t=1,p=1$c2FsdA$", balloon.ErrInvalidHash},
+	}
+
+	for i, c := range testVector {
+		if err := balloon.Verify([]byte("password"), c.encoded); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
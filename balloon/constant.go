@@ -0,0 +1,37 @@
+package balloon
+
+// BlockSize is the size of each block in the buffer in bytes.
+const BlockSize = 64
+
+const (
+	// HashLen is the length of the hash produced by Hash in bytes.
+	HashLen = 32
+	// SaltLen is the length of the random salt generated by Hash in bytes.
+	SaltLen = 16
+)
+
+// Bounds of the cost parameters, which keep a forged encoded hash from exhausting the memory and
+// CPU of the verifier.
+const (
+	// MaxSpaceCost bounds SpaceCost times Parallelism, i.e. the blocks of all lanes, which is 1 GiB
+	// memory.
+	MaxSpaceCost = 1 << 24
+	// MaxTimeCost bounds TimeCost.
+	MaxTimeCost = 1 << 8
+	// MaxParallelism bounds Parallelism.
+	MaxParallelism = 1 << 8
+)
+
+// Version is the version of the encoded hash format.
+const Version = 1
+
+// delta is the number of dependencies per block, as recommended by the paper.
+const delta = 3
+
+// Protos for domain separation of the different usages of the compression function.
+const (
+	protoExpand = "strobe-balloon/v1/expand"
+	protoMix    = "strobe-balloon/v1/mix"
+	protoIndex  = "strobe-balloon/v1/index"
+	protoFinal  = "strobe-balloon/v1/final"
+)
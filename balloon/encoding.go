@@ -0,0 +1,83 @@
+package balloon
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// algorithmID identifies the hashing in the PHC string format.
+const algorithmID = "strobe-balloon"
+
+// encoded is the decoded form of a hash in the PHC string format.
+type encoded struct {
+	params Params
+	salt   []byte
+	hash   []byte
+}
+
+func (e *encoded) String() string {
+	return fmt.Sprintf("$%s$v=%d$s=%d,t=%d,p=%d$%s$%s", algorithmID, Version, e.params.SpaceCost,
+		e.params.TimeCost, e.params.Parallelism, base64.RawStdEncoding.EncodeToString(e.salt),
+		base64.RawStdEncoding.EncodeToString(e.hash))
+}
+
+func decode(s string) (*encoded, error) {
+	// the leading '$' produces an empty first field
+	fields := strings.Split(s, "$")
+	if len(fields) != 6 || fields[0] != "" || fields[1] != algorithmID {
+		return nil, ErrInvalidHash
+	}
+
+	if version, ok := parseUint32(fields[2], "v"); !ok {
+		return nil, ErrInvalidHash
+	} else if version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var out encoded
+	p := &out.params
+	costs := strings.Split(fields[3], ",")
+	if len(costs) != 3 {
+		return nil, ErrInvalidHash
+	}
+	for i, v := range []struct {
+		key string
+		out *uint32
+	}{{"s", &p.SpaceCost}, {"t", &p.TimeCost}, {"p", &p.Parallelism}} {
+		var ok bool
+		if *v.out, ok = parseUint32(costs[i], v.key); !ok {
+			return nil, ErrInvalidHash
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if out.salt, err = base64.RawStdEncoding.DecodeString(fields[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if out.hash, err = base64.RawStdEncoding.DecodeString(fields[5]); err != nil || len(out.hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	return &out, nil
+}
+
+// parseUint32 parses the pair key=value, where value must be a canonical decimal uint32 without
+// sign or leading zeros.
+func parseUint32(pair, key string) (uint32, bool) {
+	k, value, ok := strings.Cut(pair, "=")
+	if !ok || k != key {
+		return 0, false
+	}
+
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || strconv.FormatUint(v, 10) != value {
+		return 0, false
+	}
+
+	return uint32(v), true
+}
@@ -0,0 +1,19 @@
+package balloon
+
+import "errors"
+
+var (
+	// ErrInvalidHash is the error returned when the encoded hash is malformed.
+	ErrInvalidHash = errors.New("invalid encoded hash")
+	// ErrInvalidKeyLen is the error returned by Key when the key length isn't positive.
+	ErrInvalidKeyLen = errors.New("key length must be positive")
+	// ErrInvalidParams is the error returned when any of the cost parameters is zero.
+	ErrInvalidParams = errors.New("cost parameters must be positive")
+	// ErrParamsTooLarge is the error returned when any of the cost parameters exceeds its bound.
+	ErrParamsTooLarge = errors.New("cost parameters too large")
+	// ErrMismatchedHashAndPassword is the error returned by Verify when the password doesn't match
+	// the hash.
+	ErrMismatchedHashAndPassword = errors.New("hash and password mismatched")
+	// ErrUnsupportedVersion is the error returned when the encoded hash is of an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+)
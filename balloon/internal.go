@@ -0,0 +1,171 @@
+package balloon
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/sammyne/strobe"
+)
+
+// hasher is the compression function H of the paper. Every invocation runs on a clone of a
+// pre-initialized STROBE instance, so the per-proto initialization is paid only once.
+type hasher struct {
+	proto *strobe.Strobe
+}
+
+// sum absorbs the counter and each of the inputs as separate AD operations, and squeezes
+// len(dst) bytes into dst.
+func (h *hasher) sum(dst []byte, cnt uint64, inputs ...[]byte) error {
+	s := h.proto.Clone()
+
+	var c [8]byte
+	binary.LittleEndian.PutUint64(c[:], cnt)
+	if err := s.AD(c[:], &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	opts := &strobe.Options{}
+	for _, v := range inputs {
+		if err := s.AD(v, opts); err != nil {
+			return err
+		}
+	}
+
+	return s.PRF(dst, false)
+}
+
+func key(password, salt []byte, params *Params, keyLen int) ([]byte, error) {
+	hashers, err := newHashers()
+	if err != nil {
+		return nil, err
+	}
+
+	lanes := make([][]byte, params.Parallelism)
+	errs := make([]error, params.Parallelism)
+
+	var wg sync.WaitGroup
+	for i := range lanes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			laneSalt := make([]byte, len(salt)+4)
+			copy(laneSalt, salt)
+			binary.LittleEndian.PutUint32(laneSalt[len(salt):], uint32(i))
+
+			lanes[i], errs[i] = hashers.balloon(password, laneSalt, params)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	acc := lanes[0]
+	for _, v := range lanes[1:] {
+		for i := range acc {
+			acc[i] ^= v[i]
+		}
+	}
+
+	var p [12]byte
+	binary.LittleEndian.PutUint32(p[0:], params.SpaceCost)
+	binary.LittleEndian.PutUint32(p[4:], params.TimeCost)
+	binary.LittleEndian.PutUint32(p[8:], params.Parallelism)
+
+	out := make([]byte, keyLen)
+	if err := hashers.final.sum(out, 0, p[:], password, salt, acc); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+type hashers struct {
+	expand, mix, index, final hasher
+}
+
+func newHashers() (*hashers, error) {
+	var out hashers
+
+	protos := []struct {
+		h     *hasher
+		proto string
+	}{
+		{&out.expand, protoExpand},
+		{&out.mix, protoMix},
+		{&out.index, protoIndex},
+		{&out.final, protoFinal},
+	}
+
+	for _, v := range protos {
+		s, err := strobe.New(v.proto, strobe.Bit128)
+		if err != nil {
+			return nil, err
+		}
+		v.h.proto = s
+	}
+
+	return &out, nil
+}
+
+// balloon runs the single-lane Balloon hashing, returning the last block of the buffer.
+func (h *hashers) balloon(password, salt []byte, params *Params) ([]byte, error) {
+	sCost := uint64(params.SpaceCost)
+
+	buf := make([]byte, sCost*BlockSize)
+	block := func(i uint64) []byte {
+		return buf[i*BlockSize : (i+1)*BlockSize]
+	}
+
+	var cnt uint64
+
+	// Step 1. Expand input into buffer.
+	if err := h.expand.sum(block(0), cnt, password, salt); err != nil {
+		return nil, err
+	}
+	cnt++
+	for m := uint64(1); m < sCost; m++ {
+		if err := h.expand.sum(block(m), cnt, block(m-1)); err != nil {
+			return nil, err
+		}
+		cnt++
+	}
+
+	// Step 2. Mix buffer contents.
+	var idx [24]byte
+	var other [8]byte
+	for t := uint64(0); t < uint64(params.TimeCost); t++ {
+		for m := uint64(0); m < sCost; m++ {
+			// Step 2a. Hash last and current blocks.
+			prev := block((m + sCost - 1) % sCost)
+			if err := h.mix.sum(block(m), cnt, prev, block(m)); err != nil {
+				return nil, err
+			}
+			cnt++
+
+			// Step 2b. Hash in pseudorandomly chosen blocks.
+			for i := uint64(0); i < delta; i++ {
+				binary.LittleEndian.PutUint64(idx[0:], t)
+				binary.LittleEndian.PutUint64(idx[8:], m)
+				binary.LittleEndian.PutUint64(idx[16:], i)
+				if err := h.index.sum(other[:], cnt, salt, idx[:]); err != nil {
+					return nil, err
+				}
+				cnt++
+
+				j := binary.LittleEndian.Uint64(other[:]) % sCost
+				if err := h.mix.sum(block(m), cnt, block(m), block(j)); err != nil {
+					return nil, err
+				}
+				cnt++
+			}
+		}
+	}
+
+	// Step 3. Extract output from buffer.
+	return append([]byte{}, block(sCost-1)...), nil
+}
@@ -0,0 +1,174 @@
+// Package channel implements a full-duplex secure channel over a net.Conn.
+//
+// A STROBE transcript is half-duplex, because both parties must apply the same operations in the
+// same order. So the channel keeps one STROBE instance per direction, as produced by the Split of
+// a Disco handshake, and both parties can write concurrently.
+//
+// Each record is framed as a meta-CLR of its header, i.e. the type and little-endian length,
+// followed by the SendENC of the payload and a SendMAC tag. Closing the channel sends an
+// authenticated close-notify record, so that the reader can tell a graceful close from a
+// truncation.
+package channel
+
+import (
+	"crypto/ecdh"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sammyne/strobe"
+)
+
+// Conn is a secure channel implementing net.Conn.
+type Conn struct {
+	conn       net.Conn
+	peerStatic *ecdh.PublicKey
+
+	in  halfConn
+	out halfConn
+
+	// raw buffers the bytes read from conn but not yet processed, which makes read timeouts
+	// recoverable.
+	raw []byte
+	// plaintext buffers the decrypted payload not yet consumed by Read.
+	plaintext []byte
+}
+
+// halfConn is the state of one direction of the channel.
+type halfConn struct {
+	sync.Mutex
+
+	s *strobe.Strobe
+	// err is the permanent error, after which the direction is unusable.
+	err error
+}
+
+// Close sends the close-notify record and closes the underlying connection.
+func (c *Conn) Close() error {
+	// unblock pending writes holding the lock
+	_ = c.conn.SetWriteDeadline(time.Now().Add(closeNotifyTimeout))
+
+	err := c.CloseWrite()
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+
+	if cerr := c.conn.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// CloseWrite sends the close-notify record without closing the underlying connection, after
+// which the remote party reads io.EOF and Write fails.
+func (c *Conn) CloseWrite() error {
+	c.out.Lock()
+	defer c.out.Unlock()
+
+	if c.out.err != nil {
+		return c.out.err
+	}
+
+	err := c.writeRecord(recordTypeCloseNotify, nil)
+	if err == nil {
+		c.out.err = net.ErrClosed
+	}
+
+	return err
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// PeerStatic returns the static public key of the remote party if the handshake authenticates
+// one, or nil otherwise.
+func (c *Conn) PeerStatic() *ecdh.PublicKey {
+	return c.peerStatic
+}
+
+// Read reads the decrypted data. It returns io.EOF after the remote party closes gracefully, and
+// io.ErrUnexpectedEOF if the connection ends without the close-notify record.
+//
+// Failures other than timeouts are permanent.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.in.Lock()
+	defer c.in.Unlock()
+
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	for len(c.plaintext) == 0 {
+		if c.in.err != nil {
+			return 0, c.in.err
+		}
+
+		if err := c.readRecord(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, c.plaintext)
+	c.plaintext = c.plaintext[n:]
+
+	return n, nil
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection. A write timeout is
+// permanent, since the record may have been partially written.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Write encrypts and writes the data as records of at most MaxPayloadLen bytes. Failures are
+// permanent.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.out.Lock()
+	defer c.out.Unlock()
+
+	if c.out.err != nil {
+		return 0, c.out.err
+	}
+
+	var n int
+	for len(b) > 0 {
+		m := len(b)
+		if m > MaxPayloadLen {
+			m = MaxPayloadLen
+		}
+
+		if err := c.writeRecord(recordTypeData, b[:m]); err != nil {
+			c.out.err = err
+			return n, err
+		}
+
+		n, b = n+m, b[m:]
+	}
+
+	return n, nil
+}
+
+// NewConn wraps conn into a secure channel with STROBE instances for sending and receiving, which
+// must be the receiving and sending instances of the remote party respectively.
+func NewConn(conn net.Conn, send, recv *strobe.Strobe) *Conn {
+	return &Conn{conn: conn, in: halfConn{s: recv}, out: halfConn{s: send}}
+}
@@ -0,0 +1,267 @@
+package channel_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/channel"
+	"github.com/sammyne/strobe/disco"
+)
+
+func TestConn_FullDuplex(t *testing.T) {
+	client, server := mustPipe(t, "XX")
+	defer closePipe(client, server)
+
+	clientData := mustRandBytes(t, 3*channel.MaxPayloadLen+123)
+	serverData := mustRandBytes(t, 2*channel.MaxPayloadLen+45)
+
+	errs := make(chan error, 4)
+	received := make([][]byte, 2)
+
+	// both parties write and read concurrently
+	for i, v := range []struct {
+		conn *channel.Conn
+		data []byte
+		recv int
+	}{
+		{client, clientData, len(serverData)},
+		{server, serverData, len(clientData)},
+	} {
+		i, v := i, v
+		go func() {
+			_, err := v.conn.Write(v.data)
+			errs <- err
+		}()
+		go func() {
+			received[i] = make([]byte, v.recv)
+			_, err := io.ReadFull(v.conn, received[i])
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if !bytes.Equal(serverData, received[0]) {
+		t.Fatal("client receives corrupted data")
+	}
+	if !bytes.Equal(clientData, received[1]) {
+		t.Fatal("server receives corrupted data")
+	}
+}
+
+func TestConn_Close(t *testing.T) {
+	client, server := mustPipe(t, "NN")
+
+	go func() {
+		client.Write([]byte("bye"))
+		client.Close()
+	}()
+
+	got, err := io.ReadAll(server)
+	if err != nil {
+		t.Fatalf("unexpected error on graceful close: %v", err)
+	} else if string(got) != "bye" {
+		t.Fatalf("invalid data: expect %q, got %q", "bye", got)
+	}
+
+	if _, err := client.Write([]byte("more")); err == nil {
+		t.Fatal("write after close should fail")
+	}
+}
+
+func TestConn_PeerStatic(t *testing.T) {
+	client, server := mustPipe(t, "XX")
+	defer closePipe(client, server)
+
+	if !client.PeerStatic().Equal(mustStaticKey(t, false).PublicKey()) {
+		t.Fatal("client learns invalid server static key")
+	}
+	if !server.PeerStatic().Equal(mustStaticKey(t, true).PublicKey()) {
+		t.Fatal("server learns invalid client static key")
+	}
+}
+
+func TestConn_ReadDeadline(t *testing.T) {
+	client, server := mustPipe(t, "NN")
+	defer closePipe(client, server)
+
+	if err := server.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatalf("fail to set deadline: %v", err)
+	}
+
+	var buf [8]byte
+	if _, err := server.Read(buf[:]); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("invalid error: expect %v, got %v", os.ErrDeadlineExceeded, err)
+	}
+
+	// timeouts are recoverable
+	if err := server.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatalf("fail to reset deadline: %v", err)
+	}
+	go client.Write([]byte("hello"))
+
+	if n, err := server.Read(buf[:]); err != nil {
+		t.Fatalf("fail to read after timeout: %v", err)
+	} else if string(buf[:n]) != "hello" {
+		t.Fatalf("invalid data: expect %q, got %q", "hello", buf[:n])
+	}
+}
+
+func TestConn_Tampered(t *testing.T) {
+	client, server := mustPipeWithRelay(t, func(b []byte) []byte {
+		b[len(b)-1] ^= 1
+		return b
+	})
+	defer closePipe(client, server)
+
+	go client.Write([]byte("hello"))
+
+	var buf [8]byte
+	if _, err := server.Read(buf[:]); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+
+	// the failure is permanent
+	if _, err := server.Read(buf[:]); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+}
+
+func TestConn_Truncated(t *testing.T) {
+	var relay net.Conn
+	client, server := mustPipeWithRelay(t, func(b []byte) []byte {
+		return b
+	}, &relay)
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte("hello"))
+		// drop the connection without close-notify
+		relay.Close()
+	}()
+
+	if _, err := io.ReadAll(server); err != io.ErrUnexpectedEOF {
+		t.Fatalf("invalid error: expect %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+// closePipe closes both ends of the channel, draining server so that the close-notify record
+// written into the unbuffered pipe doesn't block.
+func closePipe(client, server *channel.Conn) {
+	go io.Copy(io.Discard, server)
+	client.Close()
+	server.Close()
+}
+
+func mustNewConfig(t *testing.T, pattern string, initiator bool) *disco.Config {
+	return &disco.Config{Pattern: pattern, StaticKey: mustStaticKey(t, initiator)}
+}
+
+// mustPipe establishes a channel over net.Pipe.
+func mustPipe(t *testing.T, pattern string) (*channel.Conn, *channel.Conn) {
+	c, s := net.Pipe()
+	return mustHandshake(t, c, s, pattern)
+}
+
+// mustPipeWithRelay establishes a channel over net.Pipe with a relay in the middle, which passes
+// through the handshake and then applies the mutate function to client-to-server data. The relay
+// towards the server is exposed via the optional relay parameter.
+func mustPipeWithRelay(t *testing.T, mutate func([]byte) []byte,
+	relay ...*net.Conn) (*channel.Conn, *channel.Conn) {
+	c, m1 := net.Pipe()
+	m2, s := net.Pipe()
+	if len(relay) > 0 {
+		*relay[0] = m2
+	}
+
+	handshakeDone := make(chan struct{})
+	go func() {
+		io.Copy(m1, m2)
+	}()
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := m1.Read(buf)
+			if err != nil {
+				m2.Close()
+				return
+			}
+
+			data := buf[:n]
+			select {
+			case <-handshakeDone:
+				data = mutate(data)
+			default:
+			}
+
+			if _, err := m2.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	client, server := mustHandshake(t, c, s, "NN")
+	close(handshakeDone)
+
+	return client, server
+}
+
+func mustHandshake(t *testing.T, c, s net.Conn, pattern string) (*channel.Conn, *channel.Conn) {
+	type result struct {
+		conn *channel.Conn
+		err  error
+	}
+
+	done := make(chan result)
+	go func() {
+		conn, err := channel.Server(s, mustNewConfig(t, pattern, false))
+		done <- result{conn, err}
+	}()
+
+	client, err := channel.Client(c, mustNewConfig(t, pattern, true))
+	if err != nil {
+		t.Fatalf("client fail to handshake: %v", err)
+	}
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("server fail to handshake: %v", r.err)
+	}
+
+	return client, r.conn
+}
+
+func mustRandBytes(t *testing.T, n int) []byte {
+	out := make([]byte, n)
+	if _, err := rand.Read(out); err != nil {
+		t.Fatalf("fail to read random bytes: %v", err)
+	}
+
+	return out
+}
+
+func mustStaticKey(t *testing.T, initiator bool) *ecdh.PrivateKey {
+	seed := byte(0x11)
+	if !initiator {
+		seed = 0x22
+	}
+
+	out, err := ecdh.X25519().NewPrivateKey(bytes.Repeat([]byte{seed}, 32))
+	if err != nil {
+		t.Fatalf("fail to load X25519 key: %v", err)
+	}
+
+	return out
+}
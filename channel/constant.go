@@ -0,0 +1,24 @@
+package channel
+
+import "time"
+
+// MaxPayloadLen is the maximum length of the payload carried by a record in bytes.
+const MaxPayloadLen = 1 << 14
+
+// TagLen is the length of the MAC authenticating each record in bytes.
+const TagLen = 16
+
+// headerLen is the length of the record header, i.e. 1-byte type followed by 2-byte little-endian
+// payload length.
+const headerLen = 3
+
+// closeNotifyTimeout bounds the time Close spends on sending the close-notify record.
+const closeNotifyTimeout = 5 * time.Second
+
+// recordType tells how to interpret the payload of a record.
+type recordType uint8
+
+const (
+	recordTypeData        recordType = 0
+	recordTypeCloseNotify recordType = 1
+)
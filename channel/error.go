@@ -0,0 +1,11 @@
+package channel
+
+import "errors"
+
+var (
+	// ErrInvalidRecord is the error returned when a record with an unknown type or malformed
+	// content is received.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrRecordOverflow is the error returned when a record exceeding MaxPayloadLen is received.
+	ErrRecordOverflow = errors.New("record overflow")
+)
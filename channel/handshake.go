@@ -0,0 +1,74 @@
+package channel
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/sammyne/strobe/disco"
+)
+
+// Client runs the Disco handshake described by config over conn as the initiator, and returns the
+// established channel. The Initiator field of config is ignored.
+func Client(conn net.Conn, config *disco.Config) (*Conn, error) {
+	return handshake(conn, config, true)
+}
+
+// Server runs the Disco handshake described by config over conn as the responder, and returns the
+// established channel. The Initiator field of config is ignored.
+func Server(conn net.Conn, config *disco.Config) (*Conn, error) {
+	return handshake(conn, config, false)
+}
+
+// handshake exchanges handshake messages with empty payloads, each prefixed by its little-endian
+// 2-byte length.
+func handshake(conn net.Conn, config *disco.Config, initiator bool) (*Conn, error) {
+	c := *config
+	c.Initiator = initiator
+
+	h, err := disco.NewHandshakeState(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; !h.Finished(); i++ {
+		if (i%2 == 0) == initiator {
+			msg, err := h.WriteMessage(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			frame := make([]byte, 2+len(msg))
+			binary.LittleEndian.PutUint16(frame, uint16(len(msg)))
+			copy(frame[2:], msg)
+			if _, err := conn.Write(frame); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var n [2]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return nil, err
+		}
+
+		msg := make([]byte, binary.LittleEndian.Uint16(n[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return nil, err
+		}
+
+		if _, err := h.ReadMessage(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	send, recv, err := h.Split()
+	if err != nil {
+		return nil, err
+	}
+
+	out := NewConn(conn, send, recv)
+	out.peerStatic = h.PeerStatic()
+
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package channel
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/sammyne/strobe"
+)
+
+// readFromUntil reads from the underlying connection until at least n raw bytes are buffered.
+func (c *Conn) readFromUntil(n int) error {
+	var buf [4096]byte
+	for len(c.raw) < n {
+		m, err := c.conn.Read(buf[:])
+		c.raw = append(c.raw, buf[:m]...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readRecord reads and verifies the next record, of which the payload is placed in c.plaintext.
+// It must be called with c.in locked.
+func (c *Conn) readRecord() error {
+	if err := c.readFromUntil(headerLen); err != nil {
+		return c.inError(err)
+	}
+
+	typ := recordType(c.raw[0])
+	n := int(binary.LittleEndian.Uint16(c.raw[1:headerLen]))
+	if n > MaxPayloadLen {
+		c.in.err = ErrRecordOverflow
+		return c.in.err
+	}
+
+	if err := c.readFromUntil(headerLen + n + TagLen); err != nil {
+		return c.inError(err)
+	}
+
+	// STROBE operations work in place, so copy out the record to leave the raw buffer intact
+	record := append([]byte{}, c.raw[:headerLen+n+TagLen]...)
+	c.raw = c.raw[len(record):]
+
+	opts := &strobe.Options{}
+	if err := c.in.s.RecvCLR(record[:headerLen], &strobe.Options{Meta: true}); err != nil {
+		c.in.err = err
+		return err
+	}
+
+	payload, err := c.in.s.RecvENC(record[headerLen:headerLen+n], opts)
+	if err != nil {
+		c.in.err = err
+		return err
+	}
+
+	if err := c.in.s.RecvMAC(record[headerLen+n:], opts); err != nil {
+		c.in.err = err
+		return err
+	}
+
+	switch {
+	case typ == recordTypeData:
+		c.plaintext = payload
+	case typ == recordTypeCloseNotify && n == 0:
+		c.in.err = io.EOF
+	default:
+		c.in.err = ErrInvalidRecord
+	}
+
+	return c.in.err
+}
+
+// inError tells whether the error of reading from the underlying connection is permanent, which
+// is the case except for timeouts.
+func (c *Conn) inError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return err
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	c.in.err = err
+
+	return err
+}
+
+// writeRecord encrypts and writes a record. It must be called with c.out locked.
+func (c *Conn) writeRecord(typ recordType, payload []byte) error {
+	record := make([]byte, headerLen+len(payload)+TagLen)
+
+	record[0] = byte(typ)
+	binary.LittleEndian.PutUint16(record[1:headerLen], uint16(len(payload)))
+	if err := c.out.s.SendCLR(record[:headerLen], &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	opts := &strobe.Options{}
+
+	n := headerLen + len(payload)
+	copy(record[headerLen:], payload)
+	if _, err := c.out.s.SendENC(record[headerLen:n], opts); err != nil {
+		return err
+	}
+
+	if err := c.out.s.SendMAC(record[n:], opts); err != nil {
+		return err
+	}
+
+	_, err := c.conn.Write(record)
+	return err
+}
@@ -0,0 +1,105 @@
+package strobe
+
+import "crypto/cipher"
+
+var (
+	_ cipher.Stream = (*SendStream)(nil)
+	_ cipher.Stream = (*RecvStream)(nil)
+)
+
+// SendStream is a cipher.Stream encrypting data by a streaming SendENC, which is finalized by
+// Final producing the MAC over the ciphertext. It suits cipher.StreamWriter and the like.
+//
+// The underlying STROBE instance must not be used by anything else until Final is called.
+type SendStream struct {
+	s     *Strobe
+	final bool
+}
+
+// NewSendStream starts a SendENC operation on s.
+func NewSendStream(s *Strobe) *SendStream {
+	// beginning the operation upfront makes all XORKeyStream calls continue it, and keeps the
+	// transcript in sync with RecvStream even if nothing is encrypted
+	if _, err := s.SendENC(nil, &Options{}); err != nil {
+		panic(err)
+	}
+
+	return &SendStream{s: s}
+}
+
+// Final finishes encryption, and places the MAC on mac, which should be sent after the ciphertext.
+func (e *SendStream) Final(mac []byte) error {
+	if e.final {
+		return ErrStreamFinalized
+	}
+	e.final = true
+
+	return e.s.SendMAC(mac, &Options{})
+}
+
+// XORKeyStream encrypts src into dst, which may overlap src entirely or not at all as required by
+// cipher.Stream. It panics if dst is shorter than src, or if the stream is finalized.
+func (e *SendStream) XORKeyStream(dst, src []byte) {
+	if e.final {
+		panic("strobe: XORKeyStream after Final")
+	}
+
+	if _, err := e.s.SendENC(inPlace(dst, src), &Options{Streaming: true}); err != nil {
+		panic(err)
+	}
+}
+
+// RecvStream is a cipher.Stream decrypting data by a streaming RecvENC, which is finalized by
+// Final verifying the MAC. It suits cipher.StreamReader and the like.
+//
+// The decrypted data isn't authenticated until Final succeeds, so it must not be used before
+// then. The underlying STROBE instance must not be used by anything else until Final is called.
+type RecvStream struct {
+	s     *Strobe
+	final bool
+}
+
+// NewRecvStream starts a RecvENC operation on s.
+func NewRecvStream(s *Strobe) *RecvStream {
+	if _, err := s.RecvENC(nil, &Options{}); err != nil {
+		panic(err)
+	}
+
+	return &RecvStream{s: s}
+}
+
+// Final finishes decryption, and verifies the MAC sent by SendStream.Final, failing with
+// ErrAuthenticationFailed if it is invalid. mac is left untouched.
+func (d *RecvStream) Final(mac []byte) error {
+	if d.final {
+		return ErrStreamFinalized
+	}
+	d.final = true
+
+	return d.s.RecvMAC(append([]byte{}, mac...), &Options{})
+}
+
+// XORKeyStream decrypts src into dst, which may overlap src entirely or not at all as required by
+// cipher.Stream. It panics if dst is shorter than src, or if the stream is finalized.
+func (d *RecvStream) XORKeyStream(dst, src []byte) {
+	if d.final {
+		panic("strobe: XORKeyStream after Final")
+	}
+
+	if _, err := d.s.RecvENC(inPlace(dst, src), &Options{Streaming: true}); err != nil {
+		panic(err)
+	}
+}
+
+// inPlace copies src to the front of dst and returns that part, so that the in-place operations
+// transform src into dst without touching src. copy handles overlapping buffers as memmove does.
+func inPlace(dst, src []byte) []byte {
+	if len(dst) < len(src) {
+		panic("strobe: output smaller than input")
+	}
+
+	out := dst[:len(src)]
+	copy(out, src)
+
+	return out
+}
@@ -0,0 +1,161 @@
+package strobe_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"testing"
+
+	"github.com/sammyne/strobe"
+)
+
+func TestSendStream(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 100)
+
+	// the reference is a single SendENC followed by SendMAC
+	ref := mustNewKeyedStrobe(t)
+	expect, err := ref.SendENC(append([]byte{}, plaintext...), &strobe.Options{})
+	if err != nil {
+		t.Fatalf("fail to SendENC: %v", err)
+	}
+	expectMAC := make([]byte, 16)
+	if err := ref.SendMAC(expectMAC, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+
+	// encrypts chunks of various sizes, both in place and into another buffer
+	enc := strobe.NewSendStream(mustNewKeyedStrobe(t))
+	ciphertext := append([]byte{}, plaintext...)
+	for i, n := 0, 1; i < len(ciphertext); i, n = i+n, n+7 {
+		if i+n > len(ciphertext) {
+			n = len(ciphertext) - i
+		}
+
+		if n%2 == 0 {
+			enc.XORKeyStream(ciphertext[i:i+n], ciphertext[i:i+n])
+		} else {
+			dst := make([]byte, n+3)
+			enc.XORKeyStream(dst, ciphertext[i:i+n])
+			copy(ciphertext[i:], dst[:n])
+		}
+	}
+
+	if !bytes.Equal(expect, ciphertext) {
+		t.Fatal("ciphertext mismatches the one of a single SendENC")
+	}
+
+	mac := make([]byte, 16)
+	if err := enc.Final(mac); err != nil {
+		t.Fatalf("fail to finalize: %v", err)
+	} else if !bytes.Equal(expectMAC, mac) {
+		t.Fatalf("invalid MAC: expect %x, got %x", expectMAC, mac)
+	}
+
+	if err := enc.Final(mac); err != strobe.ErrStreamFinalized {
+		t.Fatalf("invalid error for double Final: expect %v, got %v", strobe.ErrStreamFinalized, err)
+	}
+}
+
+func TestRecvStream(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("hello world "), 500)
+
+	var buf bytes.Buffer
+	enc := strobe.NewSendStream(mustNewKeyedStrobe(t))
+	w := &cipher.StreamWriter{S: enc, W: &buf}
+	for i := 0; i < len(plaintext); i += 100 {
+		j := i + 100
+		if j > len(plaintext) {
+			j = len(plaintext)
+		}
+
+		if _, err := w.Write(plaintext[i:j]); err != nil {
+			t.Fatalf("fail to write: %v", err)
+		}
+	}
+
+	mac := make([]byte, 16)
+	if err := enc.Final(mac); err != nil {
+		t.Fatalf("fail to finalize encryption: %v", err)
+	}
+	ciphertext := buf.Bytes()
+
+	dec := strobe.NewRecvStream(mustNewKeyedStrobe(t))
+	got, err := io.ReadAll(cipher.StreamReader{S: dec, R: bytes.NewReader(ciphertext)})
+	if err != nil {
+		t.Fatalf("fail to read: %v", err)
+	} else if !bytes.Equal(plaintext, got) {
+		t.Fatal("plaintext mismatch")
+	}
+
+	macCopy := append([]byte{}, mac...)
+	if err := dec.Final(mac); err != nil {
+		t.Fatalf("fail to verify MAC: %v", err)
+	} else if !bytes.Equal(macCopy, mac) {
+		t.Fatal("MAC modified by Final")
+	}
+
+	// tampered ciphertext and MAC
+	flip := func(b []byte, i int) []byte {
+		out := append([]byte{}, b...)
+		out[i] ^= 1
+		return out
+	}
+
+	testVector := []struct {
+		ciphertext []byte
+		mac        []byte
+	}{
+		{flip(ciphertext, 0), mac},
+		{flip(ciphertext, len(ciphertext)-1), mac},
+		{ciphertext[:len(ciphertext)-1], mac},
+		{ciphertext, flip(mac, 15)},
+	}
+
+	for i, c := range testVector {
+		dec := strobe.NewRecvStream(mustNewKeyedStrobe(t))
+		dec.XORKeyStream(make([]byte, len(c.ciphertext)), c.ciphertext)
+
+		if err := dec.Final(c.mac); err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestRecvStream_Empty(t *testing.T) {
+	mac := make([]byte, 16)
+	if err := strobe.NewSendStream(mustNewKeyedStrobe(t)).Final(mac); err != nil {
+		t.Fatalf("fail to finalize encryption: %v", err)
+	}
+
+	if err := strobe.NewRecvStream(mustNewKeyedStrobe(t)).Final(mac); err != nil {
+		t.Fatalf("fail to verify MAC of empty stream: %v", err)
+	}
+}
+
+func TestSendStream_XORKeyStream_Panics(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s: no panic", name)
+			}
+		}()
+		f()
+	}
+
+	enc := strobe.NewSendStream(mustNewKeyedStrobe(t))
+	mustPanic("short dst", func() { enc.XORKeyStream(make([]byte, 1), make([]byte, 2)) })
+
+	if err := enc.Final(make([]byte, 16)); err != nil {
+		t.Fatalf("fail to finalize: %v", err)
+	}
+	mustPanic("after Final", func() { enc.XORKeyStream(make([]byte, 1), make([]byte, 1)) })
+}
+
+func mustNewKeyedStrobe(t *testing.T) *strobe.Strobe {
+	s := mustNewStrobe(t, "cipher stream", strobe.Bit128)
+	if err := s.KEY([]byte("secret key"), false); err != nil {
+		t.Fatalf("fail to KEY: %v", err)
+	}
+
+	return s
+}
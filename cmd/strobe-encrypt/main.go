@@ -0,0 +1,108 @@
+// Command strobe-encrypt encrypts and decrypts data from stdin to stdout in the streaming format of
+// package stream.
+//
+// Usage:
+//
+//	strobe-encrypt encrypt -key FILE [-chunk-size N] < plaintext > ciphertext
+//	strobe-encrypt decrypt -key FILE < ciphertext > plaintext
+//
+// The key file holds at least 32 raw key bytes, such as those generated by
+//
+//	head -c 32 /dev/urandom > key.bin
+//
+// When decrypting, only authenticated chunks are written to stdout, but the output must be
+// discarded if the command exits with a non-zero status, which signals tampering or truncation.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sammyne/strobe/stream"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := os.Args[1]
+	if cmd != "encrypt" && cmd != "decrypt" {
+		usage()
+	}
+
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	keyPath := fs.String("key", "", "path to the key file")
+	chunkSize := fs.Int("chunk-size", stream.DefaultChunkSize, "size of plaintext chunks in bytes")
+	_ = fs.Parse(os.Args[2:])
+
+	if *keyPath == "" {
+		fatalf("-key is required")
+	}
+
+	if err := run(cmd, *keyPath, *chunkSize, os.Stdout, os.Stdin); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func decrypt(w io.Writer, r io.Reader, key []byte) error {
+	reader, err := stream.NewReader(r, key, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, reader)
+	return err
+}
+
+func encrypt(w io.Writer, r io.Reader, key []byte, chunkSize int) error {
+	writer, err := stream.NewWriter(w, key, &stream.Config{ChunkSize: chunkSize})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, r); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "strobe-encrypt: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// run encrypts or decrypts r into w according to cmd, with the key read from keyPath.
+func run(cmd, keyPath string, chunkSize int, w io.Writer, r io.Reader) error {
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("fail to read key: %w", err)
+	}
+
+	out := bufio.NewWriter(w)
+	in := bufio.NewReader(r)
+
+	if cmd == "encrypt" {
+		err = encrypt(out, in, key, chunkSize)
+	} else {
+		err = decrypt(out, in, key)
+	}
+	if err != nil {
+		return fmt.Errorf("fail to %s: %w", cmd, err)
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("fail to flush output: %w", err)
+	}
+
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: strobe-encrypt encrypt|decrypt -key FILE [-chunk-size N]")
+	os.Exit(2)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/stream"
+)
+
+func TestRun(t *testing.T) {
+	key := mustWriteKey(t, mustRandBytes(t, stream.MinKeyLen))
+	plaintext := mustRandBytes(t, 1000)
+
+	var ciphertext bytes.Buffer
+	if err := run("encrypt", key, 64, &ciphertext, bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	var got bytes.Buffer
+	if err := run("decrypt", key, 0, &got, bytes.NewReader(ciphertext.Bytes())); err != nil {
+		t.Fatalf("fail to decrypt: %v", err)
+	} else if !bytes.Equal(plaintext, got.Bytes()) {
+		t.Fatal("plaintext mismatch")
+	}
+}
+
+func TestRun_InvalidKey(t *testing.T) {
+	key := mustWriteKey(t, mustRandBytes(t, stream.MinKeyLen))
+
+	var ciphertext bytes.Buffer
+	if err := run("encrypt", key, 64, &ciphertext, bytes.NewReader([]byte("hello world"))); err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	testVector := []struct {
+		cmd    string
+		key    string
+		expect error
+	}{
+		{"decrypt", mustWriteKey(t, mustRandBytes(t, stream.MinKeyLen)), strobe.ErrAuthenticationFailed},
+		{"decrypt", mustWriteKey(t, mustRandBytes(t, stream.MinKeyLen-1)), stream.ErrInvalidKey},
+		{"encrypt", mustWriteKey(t, mustRandBytes(t, stream.MinKeyLen-1)), stream.ErrInvalidKey},
+		{"decrypt", filepath.Join(t.TempDir(), "missing.bin"), os.ErrNotExist},
+	}
+
+	for i, c := range testVector {
+		var out bytes.Buffer
+		if err := run(c.cmd, c.key, 64, &out, bytes.NewReader(ciphertext.Bytes())); !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		} else if out.Len() != 0 {
+			t.Fatalf("#%d output written on error", i)
+		}
+	}
+}
+
+func mustRandBytes(t *testing.T, n int) []byte {
+	out := make([]byte, n)
+	if _, err := rand.Read(out); err != nil {
+		t.Fatalf("fail to read random bytes: %v", err)
+	}
+
+	return out
+}
+
+func mustWriteKey(t *testing.T, key []byte) string {
+	path := filepath.Join(t.TempDir(), "key.bin")
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		t.Fatalf("fail to write key: %v", err)
+	}
+
+	return path
+}
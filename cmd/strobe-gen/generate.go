@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+
+	"github.com/sammyne/strobe"
+)
+
+type fieldView struct {
+	Name     string
+	GoType   string
+	Label    string
+	LenLabel string
+	MACLabel string
+	Len      int
+	MaxLen   int
+	Variable bool
+	Encrypt  bool
+	MAC      int
+	// Encode converts the field of m into bytes, and Decode converts bytes b back.
+	Encode string
+	Decode string
+	// Check is the condition under which the field of m has invalid length, if any.
+	Check string
+}
+
+type messageView struct {
+	Name      string
+	From      string
+	Fields    []fieldView
+	HasChecks bool
+}
+
+type methodView struct {
+	// Verb is either "Send" or "Recv".
+	Verb    string
+	Message string
+}
+
+type roleView struct {
+	Name    string
+	Methods []methodView
+}
+
+type stepView struct {
+	Op     string
+	From   string
+	Label  string
+	Len    int
+	MaxLen int
+}
+
+type fileView struct {
+	Source   string
+	Package  string
+	Proto    string
+	Level    string
+	Key      *KeySpec
+	KeyLabel string
+	Steps    []stepView
+	Messages []messageView
+	Roles    []roleView
+}
+
+// Generate emits the Go source of typed Initiator and Responder endpoints for the protocol, where
+// source names the description in the header of the generated file.
+func Generate(spec *Spec, source string) ([]byte, error) {
+	view := fileView{Source: source, Package: spec.Package, Proto: spec.Proto, Level: "strobe.Bit128", Key: spec.Key}
+	if spec.Level == 256 {
+		view.Level = "strobe.Bit256"
+	}
+	if spec.Key != nil {
+		view.KeyLabel = spec.keyLabel()
+	}
+
+	for _, v := range spec.Protocol().Steps {
+		s := stepView{Op: v.Op.String(), Label: v.Label, Len: v.Len, MaxLen: v.MaxLen}
+		switch v.From {
+		case strobe.Initiator:
+			s.From = "Initiator"
+		case strobe.Responder:
+			s.From = "Responder"
+		}
+		view.Steps = append(view.Steps, s)
+	}
+
+	initiator, responder := roleView{Name: "Initiator"}, roleView{Name: "Responder"}
+	for _, m := range spec.Messages {
+		mv := messageView{Name: m.Name, From: m.From}
+		for _, f := range m.Fields {
+			fv := newFieldView(m.Name, &f)
+			mv.Fields = append(mv.Fields, fv)
+			mv.HasChecks = mv.HasChecks || fv.Check != ""
+		}
+		view.Messages = append(view.Messages, mv)
+
+		sender, receiver := &initiator, &responder
+		if m.From == "responder" {
+			sender, receiver = receiver, sender
+		}
+		sender.Methods = append(sender.Methods, methodView{Verb: "Send", Message: m.Name})
+		receiver.Methods = append(receiver.Methods, methodView{Verb: "Recv", Message: m.Name})
+	}
+	view.Roles = []roleView{initiator, responder}
+
+	var b bytes.Buffer
+	if err := fileTemplate.Execute(&b, view); err != nil {
+		return nil, err
+	}
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("fail to format generated code: %w", err)
+	}
+
+	return out, nil
+}
+
+func newFieldView(message string, f *FieldSpec) fieldView {
+	out := fieldView{
+		Name:     f.Name,
+		GoType:   f.goType(),
+		Label:    f.label(message),
+		LenLabel: f.lenLabel(message),
+		MACLabel: f.macLabel(message),
+		Len:      f.fixedLen(),
+		MaxLen:   f.MaxLen,
+		Variable: f.isVariable(),
+		Encrypt:  f.Encrypt,
+		MAC:      f.MAC,
+	}
+
+	switch f.Type {
+	case "bytes":
+		out.Encode, out.Decode = "m."+f.Name, "b"
+	case "string":
+		out.Encode, out.Decode = "[]byte(m."+f.Name+")", "string(b)"
+	}
+
+	switch {
+	case f.Type != "bytes" && f.Type != "string":
+	case f.Len > 0:
+		out.Check = fmt.Sprintf("len(m.%s) != %d", f.Name, f.Len)
+	case f.MaxLen > 0:
+		out.Check = fmt.Sprintf("len(m.%s) > %d", f.Name, f.MaxLen)
+	default:
+		// the length must fit its 32-bit prefix
+		out.Check = fmt.Sprintf("uint64(len(m.%s)) > %d", f.Name, uint64(maxFieldLen))
+	}
+
+	switch f.Type {
+	case "uint32":
+		out.Encode, out.Decode = "binary.LittleEndian.AppendUint32(nil, m."+f.Name+")", "binary.LittleEndian.Uint32(b)"
+	case "uint64":
+		out.Encode, out.Decode = "binary.LittleEndian.AppendUint64(nil, m."+f.Name+")", "binary.LittleEndian.Uint64(b)"
+	}
+
+	return out
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGenerate checks the generated example is up to date with its description, which is tested
+// in package example.
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile("internal/example/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := ParseSpec(data)
+	if err != nil {
+		t.Fatalf("fail to parse spec: %v", err)
+	}
+
+	got, err := Generate(spec, "example.json")
+	if err != nil {
+		t.Fatalf("fail to generate: %v", err)
+	}
+
+	expect, err := os.ReadFile("internal/example/example.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expect, got) {
+		t.Fatal("internal/example/example.go is stale, run go generate ./internal/example")
+	}
+}
+
+func TestParseSpec_Invalid(t *testing.T) {
+	testVector := []struct {
+		spec   string
+		expect string
+	}{
+		{`{"proto": "p", "messages": []}`, "invalid package name"},
+		{`{"package": "p", "messages": []}`, "missing proto"},
+		{`{"package": "p", "proto": "p", "level": 64}`, "invalid security level"},
+		{`{"package": "p", "proto": "p"}`, "no messages"},
+		{`{"package": "p", "proto": "p", "messages": [{"name": "hello", "from": "initiator"}]}`, "exported"},
+		{`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "server"}]}`, "invalid sender"},
+		{`{"package": "type", "proto": "p", "messages": []}`, "is a Go keyword"},
+		{`{"package": "_", "proto": "p", "messages": []}`, "invalid package name"},
+		{`{"package": "p", "proto": "p", "messages": [{"name": "func", "from": "initiator"}]}`, "is a Go keyword"},
+		{`{"package": "p", "proto": "p", "messages": [{"name": "reader", "from": "initiator"}]}`, "exported"},
+		{
+			`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "range", "type": "bytes"}]}]}`,
+			"is a Go keyword",
+		},
+		{
+			`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "A", "type": "int"}]}]}`,
+			"unsupported type",
+		},
+		{
+			`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "A", "type": "bytes", "encrypt": true, "mac": 16}]}]}`,
+			"requires a key",
+		},
+		{
+			`{"package": "p", "proto": "p", "key": {"len": 32}, "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "A", "type": "bytes", "encrypt": true}, {"name": "B", "type": "bytes"}]}]}`,
+			"followed by a MAC",
+		},
+		{
+			`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "A", "type": "bytes"}, {"name": "A", "type": "bytes"}]}]}`,
+			"duplicate field",
+		},
+		{
+			`{"package": "p", "proto": "p", "messages": [{"name": "Hello", "from": "initiator",
+				"fields": [{"name": "A", "type": "bytes", "maxLen": 4294967296}]}]}`,
+			"exceeds 32 bits",
+		},
+	}
+
+	for _, name := range []string{"ErrMalformedMessage", "Initiator", "NewInitiator", "NewResponder", "Protocol", "Responder"} {
+		spec := fmt.Sprintf(`{"package": "p", "proto": "p", "messages": [{"name": %q, "from": "initiator",
+			"fields": [{"name": "A", "type": "bytes"}]}]}`, name)
+		testVector = append(testVector, struct {
+			spec   string
+			expect string
+		}{spec, "reserved by the generated code"})
+	}
+
+	for i, c := range testVector {
+		if _, err := ParseSpec([]byte(c.spec)); err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Fatalf("#%d invalid error: expect %q, got %v", i, c.expect, err)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package example is generated by strobe-gen from example.json, so as to test the generated code.
+package example
+
+//go:generate go run ../.. -in example.json -out example.go
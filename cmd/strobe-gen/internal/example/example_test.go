@@ -0,0 +1,140 @@
+package example_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/cmd/strobe-gen/internal/example"
+	"github.com/sammyne/strobe/protocol"
+)
+
+var psk = bytes.Repeat([]byte{0x42}, 32)
+
+func TestRoundTrip(t *testing.T) {
+	i, r := mustNewPair(t, psk, psk)
+
+	hello := example.Hello{Nonce: make([]byte, 16), Name: "alice"}
+	msg, err := i.SendHello(hello)
+	if err != nil {
+		t.Fatalf("fail to send Hello: %v", err)
+	}
+	gotHello, err := r.RecvHello(msg)
+	if err != nil {
+		t.Fatalf("fail to recv Hello: %v", err)
+	} else if !bytes.Equal(hello.Nonce, gotHello.Nonce) || hello.Name != gotHello.Name {
+		t.Fatalf("invalid Hello: expect %+v, got %+v", hello, gotHello)
+	}
+
+	welcome := example.Welcome{SessionID: 0x0123456789abcdef, Capabilities: 7, Motd: []byte("welcome")}
+	if msg, err = r.SendWelcome(welcome); err != nil {
+		t.Fatalf("fail to send Welcome: %v", err)
+	}
+	gotWelcome, err := i.RecvWelcome(msg)
+	if err != nil {
+		t.Fatalf("fail to recv Welcome: %v", err)
+	} else if welcome.SessionID != gotWelcome.SessionID || welcome.Capabilities != gotWelcome.Capabilities ||
+		!bytes.Equal(welcome.Motd, gotWelcome.Motd) {
+		t.Fatalf("invalid Welcome: expect %+v, got %+v", welcome, gotWelcome)
+	}
+
+	if msg, err = i.SendAck(example.Ack{SessionID: gotWelcome.SessionID}); err != nil {
+		t.Fatalf("fail to send Ack: %v", err)
+	}
+	if ack, err := r.RecvAck(msg); err != nil {
+		t.Fatalf("fail to recv Ack: %v", err)
+	} else if ack.SessionID != welcome.SessionID {
+		t.Fatalf("invalid Ack: expect %x, got %x", welcome.SessionID, ack.SessionID)
+	}
+
+	si, err := i.Strobe()
+	if err != nil {
+		t.Fatalf("fail to get STROBE of initiator: %v", err)
+	}
+	sr, err := r.Strobe()
+	if err != nil {
+		t.Fatalf("fail to get STROBE of responder: %v", err)
+	}
+
+	mac := make([]byte, 16)
+	if err := si.SendMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+	if err := sr.RecvMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("transcripts mismatch: %v", err)
+	}
+}
+
+func TestRecvHello_Errors(t *testing.T) {
+	hello := example.Hello{Nonce: make([]byte, 16), Name: "alice"}
+
+	i, _ := mustNewPair(t, psk, psk)
+	msg, err := i.SendHello(hello)
+	if err != nil {
+		t.Fatalf("fail to send Hello: %v", err)
+	}
+
+	flip := func(j int) []byte {
+		out := append([]byte{}, msg...)
+		out[j] ^= 1
+		return out
+	}
+
+	testVector := []struct {
+		key    []byte
+		msg    []byte
+		expect error
+	}{
+		{bytes.Repeat([]byte{0x43}, 32), msg, strobe.ErrAuthenticationFailed},
+		{psk, flip(0), strobe.ErrAuthenticationFailed},
+		{psk, flip(len(msg) - 1), strobe.ErrAuthenticationFailed},
+		{psk, msg[:len(msg)-1], example.ErrMalformedMessage},
+		{psk, append(msg, 0), example.ErrMalformedMessage},
+		// the highest byte of the length prefix of Name
+		{psk, flip(16 + 3), example.ErrMalformedMessage},
+	}
+
+	for j, c := range testVector {
+		_, r := mustNewPair(t, psk, c.key)
+		if _, err := r.RecvHello(c.msg); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", j, c.expect, err)
+		}
+	}
+}
+
+func TestSendHello_Errors(t *testing.T) {
+	i, r := mustNewPair(t, psk, psk)
+
+	if _, err := i.SendHello(example.Hello{Nonce: make([]byte, 15)}); err != protocol.ErrInvalidLength {
+		t.Fatalf("invalid error for short nonce: expect %v, got %v", protocol.ErrInvalidLength, err)
+	}
+
+	long := example.Hello{Nonce: make([]byte, 16), Name: string(make([]byte, 65))}
+	if _, err := i.SendHello(long); err != protocol.ErrInvalidLength {
+		t.Fatalf("invalid error for long name: expect %v, got %v", protocol.ErrInvalidLength, err)
+	}
+
+	if _, err := r.SendWelcome(example.Welcome{}); !errors.Is(err, protocol.ErrUnexpectedOperation) {
+		t.Fatalf("invalid error for out-of-order message: expect %v, got %v", protocol.ErrUnexpectedOperation, err)
+	}
+
+	// rejected messages leave the endpoint intact
+	if _, err := i.SendHello(example.Hello{Nonce: make([]byte, 16)}); err != nil {
+		t.Fatalf("fail to send Hello: %v", err)
+	}
+}
+
+func mustNewPair(t *testing.T, keyI, keyR []byte) (*example.Initiator, *example.Responder) {
+	i, err := example.NewInitiator(keyI)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	r, err := example.NewResponder(keyR)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return i, r
+}
@@ -0,0 +1,79 @@
+// Command strobe-gen generates Go code of typed protocol endpoints from a JSON description of the
+// protocol, so that both parties are generated from one source of truth.
+//
+// Usage:
+//
+//	strobe-gen -in hello.json [-out hello.go]
+//
+// The description lists the messages in order, each sent by either the initiator or responder,
+// with fields sent in clear or encrypted, and optionally authenticated by a MAC after them:
+//
+//	{
+//	  "package": "hello",
+//	  "proto": "example.com/hello/v1",
+//	  "key": {"label": "psk", "len": 32},
+//	  "messages": [
+//	    {
+//	      "name": "Hello",
+//	      "from": "initiator",
+//	      "fields": [
+//	        {"name": "Nonce", "type": "bytes", "len": 16},
+//	        {"name": "Name", "type": "string", "maxLen": 64, "encrypt": true, "mac": 16}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Supported field types are bytes, string, uint32 and uint64. Fields of bytes or string without
+// a fixed len are prefixed with their little-endian uint32 length.
+//
+// The generated code declares one struct per message, and Initiator and Responder types whose
+// methods such as SendHello and RecvHello drive a protocol.Runtime, which enforces the order of
+// operations declared by the generated Protocol.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	in := flag.String("in", "", "path to the JSON description")
+	out := flag.String("out", "", "path to the generated Go file, which defaults to stdout")
+	flag.Parse()
+
+	if *in == "" {
+		fatalf("-in is required")
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fatalf("fail to read description: %v", err)
+	}
+
+	spec, err := ParseSpec(data)
+	if err != nil {
+		fatalf("invalid description: %v", err)
+	}
+
+	code, err := Generate(spec, filepath.Base(*in))
+	if err != nil {
+		fatalf("fail to generate: %v", err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = os.WriteFile(*out, code, 0o644)
+	}
+	if err != nil {
+		fatalf("fail to write: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "strobe-gen: "+format+"\n", args...)
+	os.Exit(1)
+}
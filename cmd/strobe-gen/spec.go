@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"unicode"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/protocol"
+)
+
+// Spec is the JSON description of a protocol.
+type Spec struct {
+	// Package is the name of the Go package to generate.
+	Package string `json:"package"`
+	// Proto is the STROBE proto of the protocol.
+	Proto string `json:"proto"`
+	// Level is the security level in bits, which defaults to 128.
+	Level int `json:"level,omitempty"`
+	// Key is the pre-shared key taken by the constructors of both roles, which is required if any
+	// field is encrypted.
+	Key *KeySpec `json:"key,omitempty"`
+	// Messages are the messages of the protocol in order.
+	Messages []MessageSpec `json:"messages"`
+}
+
+// KeySpec describes the pre-shared key.
+type KeySpec struct {
+	// Label frames the KEY operation, which defaults to "key".
+	Label string `json:"label,omitempty"`
+	// Len is the exact length of the key in bytes.
+	Len int `json:"len"`
+}
+
+// MessageSpec describes a message, which becomes a struct type with one field per field.
+type MessageSpec struct {
+	Name string `json:"name"`
+	// From is the sending role, either "initiator" or "responder".
+	From   string      `json:"from"`
+	Fields []FieldSpec `json:"fields"`
+}
+
+// FieldSpec describes a field of a message.
+type FieldSpec struct {
+	Name string `json:"name"`
+	// Type is one of "bytes", "string", "uint32" and "uint64".
+	Type string `json:"type"`
+	// Len is the exact length of bytes and string fields, which are prefixed with their length
+	// if zero.
+	Len int `json:"len,omitempty"`
+	// MaxLen is the maximum length of variable-length fields, which is only limited by the 32-bit
+	// length prefix if zero.
+	MaxLen int `json:"maxLen,omitempty"`
+	// Encrypt sends the field with ENC instead of CLR.
+	Encrypt bool `json:"encrypt,omitempty"`
+	// MAC is the length of the MAC sent after the field, authenticating it along with all fields
+	// before it, which is none if zero.
+	MAC int `json:"mac,omitempty"`
+}
+
+// fixedLens are the encoded lengths of fixed-size types.
+var fixedLens = map[string]int{"uint32": 4, "uint64": 8}
+
+// reservedNames are the package-level identifiers declared by the generated code, which messages
+// mustn't be named after.
+var reservedNames = map[string]bool{
+	"ErrMalformedMessage": true,
+	"Initiator":           true,
+	"NewInitiator":        true,
+	"NewResponder":        true,
+	"Protocol":            true,
+	"Responder":           true,
+	"reader":              true,
+	"writer":              true,
+}
+
+// lengthPrefixLen is the length of the little-endian uint32 prefixed to variable-length fields.
+const lengthPrefixLen = 4
+
+// maxFieldLen is the maximum length of variable-length fields encodable by the length prefix.
+const maxFieldLen = 1<<(8*lengthPrefixLen) - 1
+
+// ParseSpec decodes and validates the JSON description of a protocol.
+func ParseSpec(data []byte) (*Spec, error) {
+	var out Spec
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	if err := out.validate(); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Protocol converts the description into the steps run by both roles.
+func (s *Spec) Protocol() *protocol.Protocol {
+	out := &protocol.Protocol{Name: s.Proto, Level: strobe.SecurityLevel(s.Level)}
+
+	if s.Key != nil {
+		out.Steps = append(out.Steps, protocol.Step{Op: protocol.OpKEY, Label: s.keyLabel(), Len: s.Key.Len})
+	}
+
+	for _, m := range s.Messages {
+		from := roleOf(m.From)
+		for _, f := range m.Fields {
+			if f.isVariable() {
+				out.Steps = append(out.Steps,
+					protocol.Step{Op: protocol.OpCLR, From: from, Label: f.lenLabel(m.Name), Len: lengthPrefixLen})
+			}
+
+			value := protocol.Step{Op: protocol.OpCLR, From: from, Label: f.label(m.Name), Len: f.fixedLen()}
+			if f.Encrypt {
+				value.Op = protocol.OpENC
+			}
+			if f.isVariable() {
+				value.MaxLen = f.MaxLen
+			}
+			out.Steps = append(out.Steps, value)
+
+			if f.MAC > 0 {
+				out.Steps = append(out.Steps,
+					protocol.Step{Op: protocol.OpMAC, From: from, Label: f.macLabel(m.Name), Len: f.MAC})
+			}
+		}
+	}
+
+	return out
+}
+
+func (s *Spec) keyLabel() string {
+	if s.Key.Label == "" {
+		return "key"
+	}
+	return s.Key.Label
+}
+
+func (s *Spec) validate() error {
+	if token.IsKeyword(s.Package) {
+		return fmt.Errorf("package name %s is a Go keyword", s.Package)
+	} else if !token.IsIdentifier(s.Package) || s.Package == "_" {
+		return fmt.Errorf("invalid package name %q", s.Package)
+	} else if s.Proto == "" {
+		return errors.New("missing proto")
+	} else if s.Level != 0 && s.Level != 128 && s.Level != 256 {
+		return fmt.Errorf("invalid security level %d", s.Level)
+	} else if s.Key != nil && s.Key.Len <= 0 {
+		return errors.New("key requires a positive length")
+	} else if len(s.Messages) == 0 {
+		return errors.New("no messages")
+	}
+
+	names := make(map[string]bool)
+	for _, m := range s.Messages {
+		if err := m.validate(s.Key != nil); err != nil {
+			return fmt.Errorf("message %s: %w", m.Name, err)
+		} else if names[m.Name] {
+			return fmt.Errorf("duplicate message %s", m.Name)
+		}
+		names[m.Name] = true
+	}
+
+	return s.Protocol().Validate()
+}
+
+func (m *MessageSpec) validate(keyed bool) error {
+	if err := validateName(m.Name); err != nil {
+		return err
+	} else if reservedNames[m.Name] {
+		return fmt.Errorf("name %s is reserved by the generated code", m.Name)
+	} else if m.From != "initiator" && m.From != "responder" {
+		return fmt.Errorf("invalid sender %q", m.From)
+	} else if len(m.Fields) == 0 {
+		return errors.New("no fields")
+	}
+
+	names := make(map[string]bool)
+	authenticated := true
+	for _, f := range m.Fields {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		} else if names[f.Name] {
+			return fmt.Errorf("duplicate field %s", f.Name)
+		} else if f.Encrypt && !keyed {
+			return fmt.Errorf("field %s: encryption requires a key", f.Name)
+		}
+		names[f.Name] = true
+
+		// decrypted fields must be authenticated before being returned
+		authenticated = (authenticated && !f.Encrypt) || f.MAC > 0
+	}
+
+	if !authenticated {
+		return errors.New("encrypted fields must be followed by a MAC")
+	}
+
+	return nil
+}
+
+func (f *FieldSpec) fixedLen() int {
+	if n, ok := fixedLens[f.Type]; ok {
+		return n
+	}
+	return f.Len
+}
+
+// goType returns the Go type of the field.
+func (f *FieldSpec) goType() string {
+	if f.Type == "bytes" {
+		return "[]byte"
+	}
+	return f.Type
+}
+
+func (f *FieldSpec) isVariable() bool {
+	return f.fixedLen() == 0
+}
+
+func (f *FieldSpec) label(message string) string {
+	return message + "." + f.Name
+}
+
+func (f *FieldSpec) lenLabel(message string) string {
+	return f.label(message) + ".len"
+}
+
+func (f *FieldSpec) macLabel(message string) string {
+	return f.label(message) + ".mac"
+}
+
+func (f *FieldSpec) validate() error {
+	if err := validateName(f.Name); err != nil {
+		return err
+	}
+
+	switch f.Type {
+	case "bytes", "string":
+	case "uint32", "uint64":
+		if f.Len != 0 || f.MaxLen != 0 {
+			return fmt.Errorf("%s takes no length", f.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", f.Type)
+	}
+
+	if f.Len < 0 || f.MaxLen < 0 || f.MAC < 0 {
+		return errors.New("negative length")
+	} else if f.Len > 0 && f.MaxLen > 0 {
+		return errors.New("both len and maxLen are set")
+	} else if uint64(f.Len) > maxFieldLen || uint64(f.MaxLen) > maxFieldLen {
+		return errors.New("length exceeds 32 bits")
+	}
+
+	return nil
+}
+
+func isExported(name string) bool {
+	return token.IsIdentifier(name) && unicode.IsUpper([]rune(name)[0])
+}
+
+func roleOf(from string) strobe.Role {
+	if from == "initiator" {
+		return strobe.Initiator
+	}
+	return strobe.Responder
+}
+
+// validateName checks the name of a message or field is an exported identifier.
+func validateName(name string) error {
+	if token.IsKeyword(name) {
+		return fmt.Errorf("name %s is a Go keyword", name)
+	} else if !isExported(name) {
+		return errors.New("name must be an exported identifier")
+	}
+
+	return nil
+}
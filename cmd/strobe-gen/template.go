@@ -0,0 +1,245 @@
+package main
+
+import "text/template"
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by strobe-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/protocol"
+)
+
+// ErrMalformedMessage is the error returned when a message is truncated or has trailing data.
+var ErrMalformedMessage = errors.New("malformed message")
+
+// Protocol is the protocol run by Initiator and Responder.
+var Protocol = &protocol.Protocol{
+	Name:  {{printf "%q" .Proto}},
+	Level: {{.Level}},
+	Steps: []protocol.Step{
+{{- range .Steps}}
+		{Op: protocol.Op{{.Op}}{{if .From}}, From: strobe.{{.From}}{{end}}, Label: {{printf "%q" .Label}}{{if .Len}}, Len: {{.Len}}{{end}}{{if .MaxLen}}, MaxLen: {{.MaxLen}}{{end}}},
+{{- end}}
+	},
+}
+{{range .Messages}}
+// {{.Name}} is the message sent by the {{.From}}.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}}
+{{- end}}
+}
+{{end}}
+{{- range $role := .Roles}}
+// {{.Name}} drives a STROBE instance as the {{.Name}} of Protocol, which isn't safe for concurrent
+// use.
+type {{.Name}} struct {
+	rt *protocol.Runtime
+}
+
+// New{{.Name}} starts Protocol as the {{.Name}}{{if $.Key}}, keyed with the pre-shared key of {{$.Key.Len}} bytes{{end}}.
+func New{{.Name}}({{if $.Key}}key []byte{{end}}) (*{{.Name}}, error) {
+	rt, err := protocol.NewRuntime(Protocol, strobe.{{.Name}})
+	if err != nil {
+		return nil, err
+	}
+{{- if $.Key}}
+
+	if err := rt.KEY({{printf "%q" $.KeyLabel}}, key); err != nil {
+		return nil, err
+	}
+{{- end}}
+
+	return &{{.Name}}{rt: rt}, nil
+}
+{{range .Methods}}
+{{- if eq .Verb "Send"}}
+// Send{{.Message}} encodes the {{.Message}} message.
+func (p *{{$role.Name}}) Send{{.Message}}(m {{.Message}}) ([]byte, error) {
+	return send{{.Message}}(p.rt, m)
+}
+{{else}}
+// Recv{{.Message}} decodes the {{.Message}} message.
+func (p *{{$role.Name}}) Recv{{.Message}}(data []byte) ({{.Message}}, error) {
+	return recv{{.Message}}(p.rt, data)
+}
+{{end}}
+{{- end}}
+// Strobe returns the STROBE instance once all messages are processed.
+func (p *{{.Name}}) Strobe() (*strobe.Strobe, error) {
+	return p.rt.Strobe()
+}
+{{end}}
+{{- range $m := .Messages}}
+func recv{{.Name}}(rt *protocol.Runtime, data []byte) ({{.Name}}, error) {
+	var (
+		m   {{.Name}}
+		r   = reader{rt: rt, data: data}
+		n   int
+		b   []byte
+		err error
+	)
+{{range .Fields}}
+{{- if .Variable}}
+	if n, err = r.length({{printf "%q" .LenLabel}}, {{.MaxLen}}); err != nil {
+		return {{$m.Name}}{}, err
+	}
+{{- else}}
+	n = {{.Len}}
+{{- end}}
+	if b, err = r.{{if .Encrypt}}enc{{else}}clr{{end}}({{printf "%q" .Label}}, n); err != nil {
+		return {{$m.Name}}{}, err
+	}
+	m.{{.Name}} = {{.Decode}}
+{{- if .MAC}}
+	if err = r.mac({{printf "%q" .MACLabel}}, {{.MAC}}); err != nil {
+		return {{$m.Name}}{}, err
+	}
+{{- end}}
+{{end}}
+	if len(r.data) != 0 {
+		return {{.Name}}{}, ErrMalformedMessage
+	}
+
+	return m, nil
+}
+
+func send{{.Name}}(rt *protocol.Runtime, m {{.Name}}) ([]byte, error) {
+{{- if .HasChecks}}
+	// check lengths before any operation, which can't be undone
+{{- range .Fields}}{{if .Check}}
+	if {{.Check}} {
+		return nil, protocol.ErrInvalidLength
+	}
+{{- end}}{{end}}
+
+{{end}}
+	w := writer{rt: rt}
+{{range .Fields}}
+{{- if .Variable}}
+	if err := w.length({{printf "%q" .LenLabel}}, len(m.{{.Name}})); err != nil {
+		return nil, err
+	}
+{{- end}}
+	if err := w.{{if .Encrypt}}enc{{else}}clr{{end}}({{printf "%q" .Label}}, {{.Encode}}); err != nil {
+		return nil, err
+	}
+{{- if .MAC}}
+	if err := w.mac({{printf "%q" .MACLabel}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+{{end}}
+	return w.out, nil
+}
+{{end}}
+// reader consumes a message field by field.
+type reader struct {
+	rt   *protocol.Runtime
+	data []byte
+}
+
+func (r *reader) clr(label string, n int) ([]byte, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.rt.RecvCLR(label, b); err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, b...), nil
+}
+
+func (r *reader) enc(label string, n int) ([]byte, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.rt.RecvENC(label, b)
+}
+
+// length consumes the length prefixed to a variable-length field, which is at most max unless max
+// is zero.
+func (r *reader) length(label string, max int) (int, error) {
+	b, err := r.clr(label, 4)
+	if err != nil {
+		return 0, err
+	}
+
+	n := binary.LittleEndian.Uint32(b)
+	if uint64(n) > uint64(len(r.data)) {
+		return 0, ErrMalformedMessage
+	} else if max > 0 && uint64(n) > uint64(max) {
+		return 0, protocol.ErrInvalidLength
+	}
+
+	return int(n), nil
+}
+
+func (r *reader) mac(label string, n int) error {
+	b, err := r.next(n)
+	if err != nil {
+		return err
+	}
+
+	return r.rt.RecvMAC(label, b)
+}
+
+func (r *reader) next(n int) ([]byte, error) {
+	if len(r.data) < n {
+		return nil, ErrMalformedMessage
+	}
+
+	out := r.data[:n]
+	r.data = r.data[n:]
+
+	return out, nil
+}
+
+// writer produces a message field by field.
+type writer struct {
+	rt  *protocol.Runtime
+	out []byte
+}
+
+func (w *writer) clr(label string, data []byte) error {
+	if err := w.rt.SendCLR(label, data); err != nil {
+		return err
+	}
+
+	w.out = append(w.out, data...)
+	return nil
+}
+
+func (w *writer) enc(label string, data []byte) error {
+	ciphertext, err := w.rt.SendENC(label, data)
+	if err != nil {
+		return err
+	}
+
+	w.out = append(w.out, ciphertext...)
+	return nil
+}
+
+func (w *writer) length(label string, n int) error {
+	return w.clr(label, binary.LittleEndian.AppendUint32(nil, uint32(n)))
+}
+
+func (w *writer) mac(label string) error {
+	mac, err := w.rt.SendMAC(label)
+	if err != nil {
+		return err
+	}
+
+	w.out = append(w.out, mac...)
+	return nil
+}
+`))
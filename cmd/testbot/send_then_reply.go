@@ -0,0 +1,101 @@
+// +build ignore
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+
+	"github.com/mimoo/StrobeGo/strobe"
+)
+
+type TestVector struct {
+	Proto         string
+	SecurityLevel int
+	Cases         []TestCase
+}
+
+type TestCase struct {
+	Key           []byte
+	Request       []byte // plaintext sent by the initiator
+	RequestCipher []byte
+	RequestMAC    []byte
+	Reply         []byte // plaintext sent back by the responder
+	ReplyCipher   []byte
+	ReplyMAC      []byte
+	PRF           []byte // PRF out of both parties after the exchange
+}
+
+func main() {
+	testVectors := []TestVector{
+		{Proto: "strobe-go-128", SecurityLevel: 128},
+		{Proto: "strobe-go-256", SecurityLevel: 256},
+	}
+
+	for i, v := range testVectors {
+		for j := 0; j < 64; j++ {
+			key := mustRandBytes(32)
+
+			initiator := strobe.InitStrobe(v.Proto, v.SecurityLevel)
+			initiator.KEY(key)
+			responder := strobe.InitStrobe(v.Proto, v.SecurityLevel)
+			responder.KEY(key)
+
+			request := mustRandBytes(j)
+			requestCipher := initiator.Send_ENC_unauthenticated(false, request)
+			requestMAC := initiator.Send_MAC(false, 16)
+			responder.Recv_ENC_unauthenticated(false, requestCipher)
+			if !responder.Recv_MAC(false, requestMAC) {
+				panic("invalid request MAC")
+			}
+
+			reply := mustRandBytes(2 * j)
+			replyCipher := responder.Send_ENC_unauthenticated(false, reply)
+			replyMAC := responder.Send_MAC(false, 16)
+			initiator.Recv_ENC_unauthenticated(false, replyCipher)
+			if !initiator.Recv_MAC(false, replyMAC) {
+				panic("invalid reply MAC")
+			}
+
+			prf := initiator.PRF(32)
+			if string(prf) != string(responder.PRF(32)) {
+				panic("diverged transcripts")
+			}
+
+			c := TestCase{
+				Key:           key,
+				Request:       request,
+				RequestCipher: requestCipher,
+				RequestMAC:    requestMAC,
+				Reply:         reply,
+				ReplyCipher:   replyCipher,
+				ReplyMAC:      replyMAC,
+				PRF:           prf,
+			}
+			testVectors[i].Cases = append(testVectors[i].Cases, c)
+		}
+	}
+
+	out, err := json.MarshalIndent(testVectors, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := ioutil.WriteFile("send_then_reply.json", out, 0644); err != nil {
+		panic(err)
+	}
+}
+
+func init() {
+	rand.Seed(0x123456)
+}
+
+func mustRandBytes(ell int) []byte {
+	out := make([]byte, ell)
+	if _, err := rand.Read(out); err != nil {
+		panic(err)
+	}
+
+	return out
+}
@@ -0,0 +1,183 @@
+package commitments
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Contribution is the random value of a party to a coin flip, along with its commitment and
+// opening.
+type Contribution struct {
+	Value      []byte
+	Commitment []byte
+	Opening    []byte
+}
+
+// CoinFlip aggregates the contributions of multiple parties into shared randomness by
+// commit-reveal: every party publishes the commitment to its random value first, then reveals the
+// value and opening once all commitments are in.
+//
+// The randomness is unbiased as long as one party contributes a uniform value, since no party can
+// choose its value after seeing the others. A party refusing to reveal can still abort the coin
+// flip, which callers should treat as a failure rather than restart.
+//
+// A CoinFlip isn't safe for concurrent use.
+type CoinFlip struct {
+	label   []byte
+	parties []string
+	index   map[string]int
+
+	commitments [][]byte
+	values      [][]byte
+	committed   int
+	revealed    int
+}
+
+// Contribute generates a random value of ValueSize bytes with crypto/rand.Reader and commits to it.
+func Contribute() (*Contribution, error) {
+	return ContributeWithRand(rand.Reader)
+}
+
+// ContributeWithRand is like Contribute, but reads randomness from r.
+func ContributeWithRand(r io.Reader) (*Contribution, error) {
+	value := make([]byte, ValueSize)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, err
+	}
+
+	commitment, opening, err := CommitWithRand(r, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Contribution{Value: value, Commitment: commitment, Opening: opening}, nil
+}
+
+// NewCoinFlip starts a coin flip among the given parties, where label identifies the coin flip,
+// e.g. the lottery round, and is bound to the output.
+func NewCoinFlip(label []byte, parties []string) (*CoinFlip, error) {
+	if len(parties) == 0 {
+		return nil, ErrNoParties
+	}
+
+	index := make(map[string]int, len(parties))
+	for i, v := range parties {
+		if _, ok := index[v]; ok {
+			return nil, ErrDuplicateParty
+		}
+		index[v] = i
+	}
+
+	out := &CoinFlip{
+		label:       append([]byte{}, label...),
+		parties:     append([]string{}, parties...),
+		index:       index,
+		commitments: make([][]byte, len(parties)),
+		values:      make([][]byte, len(parties)),
+	}
+
+	return out, nil
+}
+
+// Commit records the commitment of party.
+func (f *CoinFlip) Commit(party string, commitment []byte) error {
+	i, ok := f.index[party]
+	if !ok {
+		return ErrUnknownParty
+	} else if f.commitments[i] != nil {
+		return ErrAlreadyCommitted
+	} else if len(commitment) != CommitmentSize {
+		return ErrInvalidCommitment
+	}
+
+	f.commitments[i] = append([]byte{}, commitment...)
+	f.committed++
+
+	return nil
+}
+
+// Committed reports whether all parties have committed, after which values can be revealed.
+func (f *CoinFlip) Committed() bool {
+	return f.committed == len(f.parties)
+}
+
+// Randomness returns n bytes of shared randomness once all parties have revealed, derived from a
+// transcript of the label, all commitments and all values. Every call returns the same output for
+// the same n. It fails with ErrInvalidRandomnessLength if n is negative or exceeds
+// MaxRandomnessLen.
+func (f *CoinFlip) Randomness(n int) ([]byte, error) {
+	if n < 0 || n > MaxRandomnessLen {
+		return nil, ErrInvalidRandomnessLength
+	} else if !f.Revealed() {
+		return nil, ErrRevealPhaseIncomplete
+	}
+
+	s, err := strobe.New(CoinFlipProto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := absorb(s, labelLabel, f.label); err != nil {
+		return nil, err
+	}
+
+	for i, v := range f.parties {
+		if err := absorb(s, labelParty, []byte(v)); err != nil {
+			return nil, err
+		}
+		if err := absorb(s, labelCommitment, f.commitments[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range f.values {
+		if err := absorb(s, labelValue, v); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, n)
+	if err := s.PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Reveal checks the value revealed by party against its commitment, and records it.
+func (f *CoinFlip) Reveal(party string, value, opening []byte) error {
+	i, ok := f.index[party]
+	if !ok {
+		return ErrUnknownParty
+	} else if !f.Committed() {
+		return ErrCommitPhaseIncomplete
+	} else if f.values[i] != nil {
+		return ErrAlreadyRevealed
+	}
+
+	if len(value) != ValueSize {
+		return ErrInvalidValue
+	} else if !Verify(f.commitments[i], value, opening) {
+		return ErrInvalidOpening
+	}
+
+	f.values[i] = append([]byte{}, value...)
+	f.revealed++
+
+	return nil
+}
+
+// Revealed reports whether all parties have revealed their values.
+func (f *CoinFlip) Revealed() bool {
+	return f.revealed == len(f.parties)
+}
+
+func absorb(s *strobe.Strobe, label string, data []byte) error {
+	if err := s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	return s.AD(data, &strobe.Options{})
+}
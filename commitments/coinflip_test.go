@@ -0,0 +1,128 @@
+package commitments_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sammyne/strobe/commitments"
+)
+
+func TestCoinFlip(t *testing.T) {
+	parties := []string{"alice", "bob", "carol"}
+
+	contributions := make([]*commitments.Contribution, len(parties))
+	for i := range contributions {
+		var err error
+		if contributions[i], err = commitments.Contribute(); err != nil {
+			t.Fatalf("fail to contribute: %v", err)
+		}
+	}
+
+	run := func(label string) []byte {
+		f, err := commitments.NewCoinFlip([]byte(label), parties)
+		if err != nil {
+			t.Fatalf("fail to new coin flip: %v", err)
+		}
+
+		for i, v := range parties {
+			if err := f.Reveal(v, contributions[i].Value, contributions[i].Opening); err != commitments.ErrCommitPhaseIncomplete {
+				t.Fatalf("invalid error for early reveal: expect %v, got %v", commitments.ErrCommitPhaseIncomplete, err)
+			}
+
+			if err := f.Commit(v, contributions[i].Commitment); err != nil {
+				t.Fatalf("fail to commit for %s: %v", v, err)
+			}
+		}
+
+		if _, err := f.Randomness(32); err != commitments.ErrRevealPhaseIncomplete {
+			t.Fatalf("invalid error for early randomness: expect %v, got %v", commitments.ErrRevealPhaseIncomplete, err)
+		}
+
+		for i, v := range parties {
+			if err := f.Reveal(v, contributions[i].Value, contributions[i].Opening); err != nil {
+				t.Fatalf("fail to reveal for %s: %v", v, err)
+			}
+		}
+
+		out, err := f.Randomness(32)
+		if err != nil {
+			t.Fatalf("fail to get randomness: %v", err)
+		}
+
+		if again, _ := f.Randomness(32); !bytes.Equal(out, again) {
+			t.Fatalf("randomness should be stable: expect %x, got %x", out, again)
+		}
+
+		for _, n := range []int{-1, commitments.MaxRandomnessLen + 1} {
+			if _, err := f.Randomness(n); err != commitments.ErrInvalidRandomnessLength {
+				t.Fatalf("invalid error for length %d: expect %v, got %v", n, commitments.ErrInvalidRandomnessLength, err)
+			}
+		}
+		if long, err := f.Randomness(commitments.MaxRandomnessLen); err != nil || len(long) != commitments.MaxRandomnessLen {
+			t.Fatalf("fail to get randomness of the maximum length: %v", err)
+		}
+
+		return out
+	}
+
+	if a, b := run("round 1"), run("round 2"); bytes.Equal(a, b) {
+		t.Fatal("randomness should be bound to the label")
+	}
+}
+
+func TestCoinFlip_Errors(t *testing.T) {
+	if _, err := commitments.NewCoinFlip(nil, nil); err != commitments.ErrNoParties {
+		t.Fatalf("invalid error: expect %v, got %v", commitments.ErrNoParties, err)
+	}
+	if _, err := commitments.NewCoinFlip(nil, []string{"alice", "alice"}); err != commitments.ErrDuplicateParty {
+		t.Fatalf("invalid error: expect %v, got %v", commitments.ErrDuplicateParty, err)
+	}
+
+	f, err := commitments.NewCoinFlip(nil, []string{"alice", "bob"})
+	if err != nil {
+		t.Fatalf("fail to new coin flip: %v", err)
+	}
+
+	alice, err := commitments.Contribute()
+	if err != nil {
+		t.Fatalf("fail to contribute: %v", err)
+	}
+
+	commits := []struct {
+		party      string
+		commitment []byte
+		expect     error
+	}{
+		{"carol", alice.Commitment, commitments.ErrUnknownParty},
+		{"alice", alice.Commitment[1:], commitments.ErrInvalidCommitment},
+		{"alice", alice.Commitment, nil},
+		{"alice", alice.Commitment, commitments.ErrAlreadyCommitted},
+		{"bob", alice.Commitment, nil},
+	}
+	for i, c := range commits {
+		if err := f.Commit(c.party, c.commitment); err != c.expect {
+			t.Fatalf("#%d invalid error for Commit: expect %v, got %v", i, c.expect, err)
+		}
+	}
+
+	tampered := append([]byte{}, alice.Value...)
+	tampered[0] ^= 1
+
+	reveals := []struct {
+		party          string
+		value, opening []byte
+		expect         error
+	}{
+		{"carol", alice.Value, alice.Opening, commitments.ErrUnknownParty},
+		{"alice", tampered, alice.Opening, commitments.ErrInvalidOpening},
+		{"alice", alice.Value[:commitments.ValueSize-1], alice.Opening, commitments.ErrInvalidValue},
+		{"alice", append(append([]byte{}, alice.Value...), 0), alice.Opening, commitments.ErrInvalidValue},
+		{"alice", alice.Value, alice.Opening, nil},
+		{"alice", alice.Value, alice.Opening, commitments.ErrAlreadyRevealed},
+	}
+	for i, c := range reveals {
+		if err := f.Reveal(c.party, c.value, c.opening); err != c.expect {
+			t.Fatalf("#%d invalid error for Reveal: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
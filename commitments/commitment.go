@@ -0,0 +1,78 @@
+// Package commitments implements hiding and binding commitments with STROBE, and commit-reveal
+// coin flipping among multiple parties on top of them.
+//
+// A commitment to a message is the PRF output of a STROBE instance keyed with a random blinding
+// key, i.e. the opening, and then absorbing the message. It hides the message as long as the
+// opening is secret, and binds the committer to the message by the collision resistance of the
+// sponge.
+package commitments
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Commit commits to msg with a blinding key read from crypto/rand.Reader, returning the
+// commitment to publish and the opening to keep secret until revealing msg.
+func Commit(msg []byte) (commitment, opening []byte, err error) {
+	return CommitWithRand(rand.Reader, msg)
+}
+
+// CommitWithRand is like Commit, but reads the blinding key from r.
+func CommitWithRand(r io.Reader, msg []byte) (commitment, opening []byte, err error) {
+	opening = make([]byte, OpeningSize)
+	if _, err := io.ReadFull(r, opening); err != nil {
+		return nil, nil, err
+	}
+
+	if commitment, err = commit(msg, opening); err != nil {
+		return nil, nil, err
+	}
+
+	return commitment, opening, nil
+}
+
+// Verify reports whether commitment is a commitment to msg with the given opening.
+func Verify(commitment, msg, opening []byte) bool {
+	if len(commitment) != CommitmentSize || len(opening) != OpeningSize {
+		return false
+	}
+
+	expect, err := commit(msg, opening)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(expect, commitment) == 1
+}
+
+func commit(msg, opening []byte) ([]byte, error) {
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelBlindingKey), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, opening...), false); err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelMessage), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(msg, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, CommitmentSize)
+	if err := s.PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package commitments_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sammyne/strobe/commitments"
+)
+
+func TestCommit(t *testing.T) {
+	msg := []byte("hello world")
+
+	commitment, opening, err := commitments.Commit(msg)
+	if err != nil {
+		t.Fatalf("fail to commit: %v", err)
+	}
+
+	if !commitments.Verify(commitment, msg, opening) {
+		t.Fatal("fail to verify")
+	}
+
+	// hiding requires a fresh blinding key per commitment
+	commitment2, _, err := commitments.Commit(msg)
+	if err != nil {
+		t.Fatalf("fail to commit again: %v", err)
+	} else if bytes.Equal(commitment, commitment2) {
+		t.Fatal("commitments to the same message should differ")
+	}
+
+	flip := func(b []byte, i int) []byte {
+		out := append([]byte{}, b...)
+		out[i] ^= 1
+		return out
+	}
+
+	testVector := []struct {
+		commitment, msg, opening []byte
+	}{
+		{flip(commitment, 0), msg, opening},
+		{commitment, flip(msg, 0), opening},
+		{commitment, append(msg, 0), opening},
+		{commitment, msg, flip(opening, commitments.OpeningSize-1)},
+		{commitment[1:], msg, opening},
+		{commitment, msg, opening[1:]},
+	}
+
+	for i, c := range testVector {
+		if commitments.Verify(c.commitment, c.msg, c.opening) {
+			t.Fatalf("#%d verification should fail", i)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package commitments
+
+// Protos of the STROBE instances.
+const (
+	// Proto is the STROBE proto of commitments.
+	Proto = "github.com/sammyne/strobe/commitments"
+	// CoinFlipProto is the STROBE proto of the transcript aggregating coin flips.
+	CoinFlipProto = "github.com/sammyne/strobe/commitments/coin-flip"
+)
+
+const (
+	// CommitmentSize is the size of commitments in bytes.
+	CommitmentSize = 32
+	// MaxRandomnessLen is the maximum length of the randomness output by a coin flip in bytes.
+	MaxRandomnessLen = 1 << 16
+	// OpeningSize is the size of openings, i.e. blinding keys, in bytes.
+	OpeningSize = 32
+	// ValueSize is the size of the random values contributed to coin flips in bytes.
+	ValueSize = 32
+)
+
+// Labels framing the operations of the transcripts.
+const (
+	labelBlindingKey = "blinding key"
+	labelCommitment  = "commitment"
+	labelLabel       = "label"
+	labelMessage     = "message"
+	labelParty       = "party"
+	labelValue       = "value"
+)
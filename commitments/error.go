@@ -0,0 +1,31 @@
+package commitments
+
+import "errors"
+
+var (
+	// ErrAlreadyCommitted is the error returned when a party commits twice.
+	ErrAlreadyCommitted = errors.New("already committed")
+	// ErrAlreadyRevealed is the error returned when a party reveals twice.
+	ErrAlreadyRevealed = errors.New("already revealed")
+	// ErrCommitPhaseIncomplete is the error returned when a party reveals before all parties
+	// have committed.
+	ErrCommitPhaseIncomplete = errors.New("commit phase incomplete")
+	// ErrDuplicateParty is the error returned when a party is listed more than once.
+	ErrDuplicateParty = errors.New("duplicate party")
+	// ErrInvalidCommitment is the error returned when a commitment isn't CommitmentSize bytes.
+	ErrInvalidCommitment = errors.New("invalid commitment")
+	// ErrInvalidOpening is the error returned when a revealed value doesn't match the commitment.
+	ErrInvalidOpening = errors.New("invalid opening")
+	// ErrInvalidRandomnessLength is the error returned when the length of the randomness is
+	// negative or exceeds MaxRandomnessLen.
+	ErrInvalidRandomnessLength = errors.New("invalid randomness length")
+	// ErrInvalidValue is the error returned when a revealed value isn't ValueSize bytes.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrNoParties is the error returned when a coin flip has no parties.
+	ErrNoParties = errors.New("no parties")
+	// ErrRevealPhaseIncomplete is the error returned when the randomness is requested before all
+	// parties have revealed.
+	ErrRevealPhaseIncomplete = errors.New("reveal phase incomplete")
+	// ErrUnknownParty is the error returned when a party isn't part of the coin flip.
+	ErrUnknownParty = errors.New("unknown party")
+)
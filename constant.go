@@ -76,3 +76,11 @@ const (
 	// Bit256 targets a security level of 256 bits.
 	Bit256 SecurityLevel = 256
 )
+
+const (
+	// MessageHeaderLen is the length of the header prefixed to messages by SendMessage, i.e. the
+	// little-endian uint32 length of the payload.
+	MessageHeaderLen = 4
+	// MessageMACLen is the length of the MAC appended to messages by SendMessage.
+	MessageMACLen = 16
+)
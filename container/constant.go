@@ -0,0 +1,50 @@
+package container
+
+// DefaultBlockSize is the default size of plaintext blocks in bytes.
+const DefaultBlockSize = 4096
+
+// DefaultProto is the default STROBE proto of the container.
+const DefaultProto = "github.com/sammyne/strobe/container/v1"
+
+const (
+	// SaltLen is the length of the random salt diversifying each container in bytes.
+	SaltLen = 32
+	// TagLen is the length of the MAC of the header and each block in bytes.
+	TagLen = 16
+)
+
+// Version is the version of the container format.
+const Version = 1
+
+const (
+	// counterLen is the length of the write counter leading each block record.
+	counterLen = 8
+	// counterReservation is the number of write counters reserved by the header at a time.
+	counterReservation = 1 << 16
+	// headerLen is the length of the header excluding its MAC, laid out as
+	//	magic (4) | version (1) | security level in bytes (1) | block size (4) | salt (SaltLen) |
+	//	size (8) | reserved write counter (8) | state (1)
+	// where integers are little-endian.
+	headerLen = 4 + 1 + 1 + 4 + SaltLen + 8 + 8 + 1
+	// maxBlockSize bounds the block size in bytes.
+	maxBlockSize = 1 << 20
+)
+
+// Labels framing the operations of the transcripts.
+const (
+	labelBlock    = "block"
+	labelCounters = "counters"
+	labelHeader   = "header"
+)
+
+// States of the header.
+const (
+	// stateSynced marks a header whose MAC covers the write counters of all blocks.
+	stateSynced = 0
+	// stateDirty marks a header written before the first change since the last sync, whose MAC
+	// covers the header only.
+	stateDirty = 1
+)
+
+// magic leads the container header.
+var magic = [4]byte{'S', 'T', 'R', 'C'}
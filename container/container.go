@@ -0,0 +1,319 @@
+// Package container implements a seekable encrypted container over STROBE, which supports random
+// reads and in-place rewrites as needed by encrypted disk images and database files.
+//
+// The plaintext is split into fixed-size blocks. Each block is stored as a record of its write
+// counter, ciphertext and MAC, where the keystream and MAC come from a fork of the keyed transcript
+// absorbing the block index and write counter. So blocks can't be swapped, and rewriting a block
+// never reuses its keystream.
+//
+// Write counters are drawn from a sequence shared by all blocks, which the header reserves ahead
+// of use. So no counter is reused, even after truncating or recovering the container.
+//
+// The header authenticates the container size along with the write counters of all blocks, so a
+// block rolled back to an older record is detected. Rolling back the whole container, including
+// its header, to an earlier synced state can't be detected without external state.
+package container
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/sammyne/strobe"
+)
+
+// Storage is the backing store of a container, which *os.File satisfies.
+type Storage interface {
+	io.ReaderAt
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// Config specifies how a container is created.
+type Config struct {
+	// Proto is the STROBE proto, which defaults to DefaultProto if empty.
+	Proto string
+	// Level is the security level, which defaults to strobe.Bit128 if zero.
+	Level strobe.SecurityLevel
+	// BlockSize is the size of plaintext blocks, which defaults to DefaultBlockSize if zero.
+	BlockSize int
+	// Rand is the source of randomness for the salt, which defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Container is an encrypted container implementing io.ReaderAt and io.WriterAt, which is safe for
+// concurrent use.
+//
+// Writes are persisted into the storage immediately, but the header authenticating them is only
+// updated by Sync and Close. Before the first change since the last sync, the header is marked
+// dirty, so that a container which isn't synced afterwards, e.g. because of a crash, fails to open
+// with ErrUnsynced and can still be loaded by Recover.
+type Container struct {
+	mu sync.RWMutex
+
+	st   Storage
+	base *strobe.Strobe
+
+	level     strobe.SecurityLevel
+	blockSize int
+	salt      []byte
+	size      int64
+	counters  []uint64
+	// counter is the last write counter drawn, and reserved is the last one reserved by the header.
+	counter, reserved uint64
+
+	dirty  bool
+	closed bool
+}
+
+// Close syncs the container, after which it can't be used any more. The storage isn't closed.
+func (c *Container) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	if err := c.sync(); err != nil {
+		return err
+	}
+	c.closed = true
+
+	return nil
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at offset off, which implements io.ReaderAt.
+func (c *Container) ReadAt(p []byte, off int64) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	} else if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	end := off + int64(len(p))
+	if end > c.size {
+		end = c.size
+	}
+
+	var n int
+	for pos := off; pos < end; {
+		i := pos / int64(c.blockSize)
+
+		block, err := c.readBlock(i)
+		if err != nil {
+			return n, err
+		}
+
+		m := copy(p[n:end-off], block[pos-i*int64(c.blockSize):])
+		n, pos = n+m, pos+int64(m)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Size returns the size of the plaintext.
+func (c *Container) Size() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.size
+}
+
+// Sync updates the header to authenticate the writes so far.
+func (c *Container) Sync() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	return c.sync()
+}
+
+// Truncate changes the size of the plaintext. Extended data reads as zeros.
+func (c *Container) Truncate(size int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	} else if size < 0 {
+		return ErrInvalidOffset
+	}
+
+	if size >= c.size {
+		return c.extend(size)
+	}
+
+	n := c.numBlocks(size)
+	if err := c.markDirty(); err != nil {
+		return err
+	}
+
+	if tail := int(size % int64(c.blockSize)); tail != 0 {
+		// keep the padding of the last block zeroed, so that extending it later reads as zeros
+		block, err := c.readBlock(n - 1)
+		if err != nil {
+			return err
+		}
+
+		for i := tail; i < len(block); i++ {
+			block[i] = 0
+		}
+
+		if err := c.writeBlock(n-1, block); err != nil {
+			return err
+		}
+	}
+
+	// the header shrinks before the storage, so that it never claims missing blocks
+	c.counters = c.counters[:n]
+	c.size = size
+	if err := c.writeHeader(stateDirty); err != nil {
+		return err
+	}
+
+	return c.st.Truncate(c.recordOffset(n))
+}
+
+// WriteAt writes len(p) bytes of plaintext starting at offset off, which implements io.WriterAt.
+// Writing beyond the end extends the container, where the gap reads as zeros.
+func (c *Container) WriteAt(p []byte, off int64) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	} else if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	if off > c.size {
+		if err := c.extend(off); err != nil {
+			return 0, err
+		}
+	}
+
+	size, end := c.size, off+int64(len(p))
+	if end > off {
+		if err := c.markDirty(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	for pos := off; pos < end; {
+		i := pos / int64(c.blockSize)
+
+		var block []byte
+		if i < int64(len(c.counters)) {
+			var err error
+			if block, err = c.readBlock(i); err != nil {
+				return n, err
+			}
+		} else {
+			block = make([]byte, c.blockSize)
+			c.counters = append(c.counters, 0)
+		}
+
+		m := copy(block[pos-i*int64(c.blockSize):], p[n:])
+		if err := c.writeBlock(i, block); err != nil {
+			return n, err
+		}
+
+		n, pos = n+m, pos+int64(m)
+		if pos > c.size {
+			c.size = pos
+		}
+	}
+
+	// the header grows after the storage, so that it never claims missing blocks
+	if c.size != size {
+		if err := c.writeHeader(stateDirty); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Create initializes an empty container in the storage, encrypted under the key. A nil config
+// means the default one.
+//
+// The key must be at least as long as the security level, e.g. 16 bytes for strobe.Bit128, or
+// Create fails with ErrInvalidKey.
+func Create(st Storage, key []byte, config *Config) (*Container, error) {
+	var cfg Config
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Proto == "" {
+		cfg.Proto = DefaultProto
+	}
+	if cfg.Level == 0 {
+		cfg.Level = strobe.Bit128
+	}
+	if cfg.BlockSize == 0 {
+		cfg.BlockSize = DefaultBlockSize
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = rand.Reader
+	}
+
+	if cfg.BlockSize < 0 || cfg.BlockSize > maxBlockSize {
+		return nil, ErrInvalidConfig
+	}
+
+	out := &Container{
+		st:        st,
+		level:     cfg.Level,
+		blockSize: cfg.BlockSize,
+		salt:      make([]byte, SaltLen),
+		dirty:     true,
+	}
+	if _, err := io.ReadFull(cfg.Rand, out.salt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if out.base, err = newBase(cfg.Proto, out.level, out.blockSize, out.salt, key); err != nil {
+		return nil, err
+	}
+
+	if err := st.Truncate(0); err != nil {
+		return nil, err
+	}
+
+	if err := out.sync(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Open loads the container from the storage, and verifies the header along with the write
+// counters of all blocks. Only the Proto field of config is used.
+//
+// A container which was changed without being synced afterwards fails with ErrUnsynced.
+func Open(st Storage, key []byte, config *Config) (*Container, error) {
+	return load(st, key, config, false)
+}
+
+// Recover loads the container from the storage like Open, except that a container failing with
+// ErrUnsynced is loaded as well by trusting the write counters of the blocks in the storage.
+//
+// Blocks are still authenticated one by one, but a block rolled back to an older record can't be
+// detected, and a block torn by a crash fails to read. The recovered container is authenticated
+// again by Sync or Close.
+func Recover(st Storage, key []byte, config *Config) (*Container, error) {
+	return load(st, key, config, true)
+}
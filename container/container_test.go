@@ -0,0 +1,447 @@
+package container_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/container"
+)
+
+const blockSize = 32
+
+// headerLen is the length of the header excluding its MAC, of which the layout is detailed by
+// TestOpen_TamperedSize.
+const headerLen = 59
+
+var key = []byte("a super secret key")
+
+func TestContainer(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+
+	// mirror the expected plaintext
+	var expect []byte
+	writeAt := func(p []byte, off int64) {
+		if _, err := c.WriteAt(p, off); err != nil {
+			t.Fatalf("fail to write at %d: %v", off, err)
+		}
+
+		if end := int(off) + len(p); end > len(expect) {
+			expect = append(expect, make([]byte, end-len(expect))...)
+		}
+		copy(expect[off:], p)
+	}
+
+	writeAt(mustRandBytes(t, 100), 0)
+	writeAt(mustRandBytes(t, 10), 45)               // rewrite across blocks
+	writeAt(mustRandBytes(t, 7), 200)               // extend with a gap
+	writeAt(mustRandBytes(t, blockSize), blockSize) // rewrite a whole block
+
+	mustReadAll(t, c, expect)
+
+	if err := c.Truncate(70); err != nil {
+		t.Fatalf("fail to truncate: %v", err)
+	}
+	expect = expect[:70]
+	mustReadAll(t, c, expect)
+
+	if err := c.Truncate(150); err != nil {
+		t.Fatalf("fail to extend: %v", err)
+	}
+	expect = append(expect, make([]byte, 80)...)
+	mustReadAll(t, c, expect)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	c = mustOpen(t, st)
+	mustReadAll(t, c, expect)
+
+	var buf [10]byte
+	if n, err := c.ReadAt(buf[:], 145); err != io.EOF || n != 5 {
+		t.Fatalf("invalid read beyond the end: expect 5 bytes and %v, got %d bytes and %v", io.EOF, n, err)
+	}
+}
+
+func TestContainer_File(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "container"))
+	if err != nil {
+		t.Fatalf("fail to create file: %v", err)
+	}
+	defer f.Close()
+
+	c, err := container.Create(f, key, nil)
+	if err != nil {
+		t.Fatalf("fail to create container: %v", err)
+	}
+
+	data := mustRandBytes(t, 3*container.DefaultBlockSize+1)
+	if _, err := c.WriteAt(data, 0); err != nil {
+		t.Fatalf("fail to write: %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	c, err = container.Open(f, key, nil)
+	if err != nil {
+		t.Fatalf("fail to open container: %v", err)
+	}
+	mustReadAll(t, c, data)
+}
+
+func TestContainer_Tampered(t *testing.T) {
+	recordLen := 8 + blockSize + container.TagLen
+	record := func(data []byte, i int) []byte {
+		off := headerLen + container.TagLen + i*recordLen
+		return data[off : off+recordLen]
+	}
+
+	testVector := []struct {
+		name   string
+		tamper func(data, snapshot []byte)
+	}{
+		{"flipped ciphertext", func(data, _ []byte) { record(data, 1)[20] ^= 1 }},
+		{
+			"swapped blocks",
+			func(data, _ []byte) {
+				b0 := append([]byte{}, record(data, 0)...)
+				copy(record(data, 0), record(data, 1))
+				copy(record(data, 1), b0)
+			},
+		},
+		{"rolled back block", func(data, snapshot []byte) { copy(record(data, 2), record(snapshot, 2)) }},
+		{"flipped header", func(data, _ []byte) { data[headerLen+container.TagLen-1] ^= 1 }},
+		{"flipped state", func(data, _ []byte) { data[headerLen-1] ^= 1 }},
+	}
+
+	for _, c := range testVector {
+		st := &memStorage{}
+
+		ct := mustCreate(t, st)
+		if _, err := ct.WriteAt(mustRandBytes(t, 4*blockSize), 0); err != nil {
+			t.Fatalf("%s: fail to write: %v", c.name, err)
+		}
+		if err := ct.Close(); err != nil {
+			t.Fatalf("%s: fail to close: %v", c.name, err)
+		}
+		snapshot := append([]byte{}, st.data...)
+
+		ct = mustOpen(t, st)
+		if _, err := ct.WriteAt([]byte("rewrite"), 2*blockSize); err != nil {
+			t.Fatalf("%s: fail to rewrite: %v", c.name, err)
+		}
+		if err := ct.Close(); err != nil {
+			t.Fatalf("%s: fail to close: %v", c.name, err)
+		}
+
+		c.tamper(st.data, snapshot)
+
+		// errors show up either on open or on read
+		ct, err := container.Open(st, key, nil)
+		if err == nil {
+			_, err = ct.ReadAt(make([]byte, 4*blockSize), 0)
+		}
+		if err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestContainer_Unsynced(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+
+	expect := mustRandBytes(t, 3*blockSize)
+	if _, err := c.WriteAt(expect, 0); err != nil {
+		t.Fatalf("fail to write: %v", err)
+	}
+	if err := c.Sync(); err != nil {
+		t.Fatalf("fail to sync: %v", err)
+	}
+
+	// rewrite a block and extend without syncing, as if crashed
+	rewrite := func(p []byte, off int64) {
+		if _, err := c.WriteAt(p, off); err != nil {
+			t.Fatalf("fail to write at %d: %v", off, err)
+		}
+
+		if end := int(off) + len(p); end > len(expect) {
+			expect = append(expect, make([]byte, end-len(expect))...)
+		}
+		copy(expect[off:], p)
+	}
+	rewrite(mustRandBytes(t, 10), blockSize+3)
+	rewrite(mustRandBytes(t, blockSize+5), 3*blockSize+7)
+	crashed := &memStorage{data: append([]byte{}, st.data...)}
+
+	if _, err := container.Open(crashed, key, nil); err != container.ErrUnsynced {
+		t.Fatalf("invalid error: expect %v, got %v", container.ErrUnsynced, err)
+	}
+
+	c, err := container.Recover(crashed, key, nil)
+	if err != nil {
+		t.Fatalf("fail to recover: %v", err)
+	}
+	mustReadAll(t, c, expect)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+	mustReadAll(t, mustOpen(t, crashed), expect)
+
+	// a synced container is recovered as is
+	if _, err := container.Recover(crashed, key, nil); err != nil {
+		t.Fatalf("fail to recover synced container: %v", err)
+	}
+}
+
+func TestContainer_NoCounterReuse(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+
+	ciphertext := func() []byte {
+		off := headerLen + container.TagLen + 8
+		return append([]byte{}, st.data[off:off+blockSize]...)
+	}
+
+	// the same block is written after truncation and again after reopening
+	var ciphertexts [][]byte
+	for i := 0; i < 3; i++ {
+		if _, err := c.WriteAt(make([]byte, blockSize), 0); err != nil {
+			t.Fatalf("#%d fail to write: %v", i, err)
+		}
+		ciphertexts = append(ciphertexts, ciphertext())
+
+		if err := c.Truncate(0); err != nil {
+			t.Fatalf("#%d fail to truncate: %v", i, err)
+		}
+		if i == 1 {
+			if err := c.Close(); err != nil {
+				t.Fatalf("fail to close: %v", err)
+			}
+			c = mustOpen(t, st)
+		}
+	}
+
+	for i := range ciphertexts {
+		for j := 0; j < i; j++ {
+			if bytes.Equal(ciphertexts[i], ciphertexts[j]) {
+				t.Fatalf("keystream of write #%d reused by write #%d", j, i)
+			}
+		}
+	}
+}
+
+func TestContainer_RolledBackWhileOpen(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+
+	if _, err := c.WriteAt(mustRandBytes(t, blockSize), 0); err != nil {
+		t.Fatalf("fail to write: %v", err)
+	}
+	old := append([]byte{}, st.data...)
+
+	if _, err := c.WriteAt(mustRandBytes(t, blockSize), 0); err != nil {
+		t.Fatalf("fail to rewrite: %v", err)
+	}
+	st.data = old
+
+	if _, err := c.ReadAt(make([]byte, blockSize), 0); err != container.ErrRollback {
+		t.Fatalf("invalid error: expect %v, got %v", container.ErrRollback, err)
+	}
+}
+
+func TestOpen_WrongKey(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	if _, err := container.Open(st, []byte("another secret key"), nil); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	if _, err := container.Create(&memStorage{}, key[:15], nil); err != container.ErrInvalidKey {
+		t.Fatalf("invalid error of Create: expect %v, got %v", container.ErrInvalidKey, err)
+	}
+
+	config := &container.Config{Level: strobe.Bit256}
+	if _, err := container.Create(&memStorage{}, bytes.Repeat(key, 2)[:31], config); err != container.ErrInvalidKey {
+		t.Fatalf("invalid error of Create at 256 bits: expect %v, got %v", container.ErrInvalidKey, err)
+	}
+
+	st := &memStorage{}
+	if err := mustCreate(t, st).Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+	if _, err := container.Open(st, nil, nil); err != container.ErrInvalidKey {
+		t.Fatalf("invalid error of Open: expect %v, got %v", container.ErrInvalidKey, err)
+	}
+}
+
+func TestOpen_TamperedSize(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+	if _, err := c.WriteAt(mustRandBytes(t, 2*blockSize), 0); err != nil {
+		t.Fatalf("fail to write: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	// the header is laid out as magic (4) | version (1) | level (1) | block size (4) | salt (32) |
+	// size (8) | reserved write counter (8) | state (1)
+	tamper := func(blockSize uint32, size uint64) []byte {
+		out := append([]byte{}, st.data...)
+		binary.LittleEndian.PutUint32(out[6:], blockSize)
+		binary.LittleEndian.PutUint64(out[42:], size)
+		return out
+	}
+
+	testVector := []struct {
+		data   []byte
+		expect error
+	}{
+		{tamper(1, 1<<62), container.ErrInvalidHeader},
+		{tamper(1, math.MaxInt64), container.ErrInvalidHeader},
+		{tamper(blockSize, 1<<40), container.ErrTruncated},
+		{tamper(blockSize, 3*blockSize), container.ErrTruncated},
+		{tamper(blockSize, math.MaxUint64), container.ErrInvalidHeader},
+		{tamper(blockSize, blockSize), strobe.ErrAuthenticationFailed},
+	}
+
+	for i, c := range testVector {
+		if _, err := container.Open(&memStorage{data: c.data}, key, nil); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
+
+func TestOpen_EOFWithFullRead(t *testing.T) {
+	st := &memStorage{}
+	c := mustCreate(t, st)
+
+	// the last block ends exactly at the end of the storage
+	data := mustRandBytes(t, 2*blockSize)
+	if _, err := c.WriteAt(data, 0); err != nil {
+		t.Fatalf("fail to write: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	mustReadAll(t, mustOpen(t, &eofStorage{st}), data)
+
+	// so does the header of an empty container
+	st = &memStorage{}
+	if err := mustCreate(t, st).Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+	mustReadAll(t, mustOpen(t, &eofStorage{st}), nil)
+}
+
+// eofStorage returns io.EOF along with reads ending exactly at the end of the storage, as allowed
+// by io.ReaderAt.
+type eofStorage struct {
+	*memStorage
+}
+
+func (e *eofStorage) ReadAt(p []byte, off int64) (int, error) {
+	n, err := e.memStorage.ReadAt(p, off)
+	if err == nil && off+int64(n) == int64(len(e.data)) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// memStorage is an in-memory container.Storage.
+type memStorage struct {
+	data []byte
+}
+
+func (m *memStorage) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (m *memStorage) Truncate(size int64) error {
+	if size <= int64(len(m.data)) {
+		m.data = m.data[:size]
+	} else {
+		m.data = append(m.data, make([]byte, size-int64(len(m.data)))...)
+	}
+
+	return nil
+}
+
+func (m *memStorage) WriteAt(p []byte, off int64) (int, error) {
+	if end := off + int64(len(p)); end > int64(len(m.data)) {
+		if err := m.Truncate(end); err != nil {
+			return 0, err
+		}
+	}
+
+	return copy(m.data[off:], p), nil
+}
+
+func mustCreate(t *testing.T, st container.Storage) *container.Container {
+	c, err := container.Create(st, key, &container.Config{BlockSize: blockSize})
+	if err != nil {
+		t.Fatalf("fail to create container: %v", err)
+	}
+
+	return c
+}
+
+func mustOpen(t *testing.T, st container.Storage) *container.Container {
+	c, err := container.Open(st, key, nil)
+	if err != nil {
+		t.Fatalf("fail to open container: %v", err)
+	}
+
+	return c
+}
+
+func mustRandBytes(t *testing.T, n int) []byte {
+	out := make([]byte, n)
+	if _, err := rand.Read(out); err != nil {
+		t.Fatalf("fail to read random bytes: %v", err)
+	}
+
+	return out
+}
+
+func mustReadAll(t *testing.T, c *container.Container, expect []byte) {
+	if c.Size() != int64(len(expect)) {
+		t.Fatalf("invalid size: expect %d, got %d", len(expect), c.Size())
+	}
+
+	got := make([]byte, len(expect))
+	if _, err := c.ReadAt(got, 0); err != nil {
+		t.Fatalf("fail to read: %v", err)
+	} else if !bytes.Equal(expect, got) {
+		t.Fatalf("invalid plaintext: expect %x, got %x", expect, got)
+	}
+}
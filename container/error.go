@@ -0,0 +1,27 @@
+package container
+
+import "errors"
+
+var (
+	// ErrClosed is the error returned when operating on a closed container.
+	ErrClosed = errors.New("container closed")
+	// ErrInvalidConfig is the error returned when the config is unsupported.
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrInvalidHeader is the error returned when the header is malformed.
+	ErrInvalidHeader = errors.New("invalid header")
+	// ErrInvalidKey is the error returned when the key is shorter than the security level.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrInvalidOffset is the error returned when the offset or size is negative.
+	ErrInvalidOffset = errors.New("invalid offset")
+	// ErrRollback is the error returned when a block record is older or newer than the version
+	// authenticated by the header, e.g. because it has been rolled back.
+	ErrRollback = errors.New("block rolled back")
+	// ErrTruncated is the error returned when the storage is too short to hold all blocks of the
+	// size claimed by the header.
+	ErrTruncated = errors.New("container truncated")
+	// ErrUnsupportedVersion is the error returned when the container is of an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	// ErrUnsynced is the error returned by Open when the container was changed without being synced
+	// afterwards, e.g. because of a crash. Such a container can be loaded by Recover.
+	ErrUnsynced = errors.New("container not synced")
+)
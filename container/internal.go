@@ -0,0 +1,373 @@
+package container
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/sammyne/strobe"
+)
+
+// blockTranscript forks the keyed transcript for the block with the given write counter.
+func (c *Container) blockTranscript(i int64, counter uint64) (*strobe.Strobe, error) {
+	s := c.base.Clone()
+
+	var v [16]byte
+	binary.LittleEndian.PutUint64(v[:8], uint64(i))
+	binary.LittleEndian.PutUint64(v[8:], counter)
+
+	if err := s.AD([]byte(labelBlock), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(v[:], &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// extend extends the container with zeros up to the given size, where the padding of the current
+// last block is already zeros.
+func (c *Container) extend(size int64) error {
+	if size == c.size {
+		return nil
+	}
+
+	if err := c.markDirty(); err != nil {
+		return err
+	}
+
+	for i := int64(len(c.counters)); i < c.numBlocks(size); i++ {
+		c.counters = append(c.counters, 0)
+		if err := c.writeBlock(i, make([]byte, c.blockSize)); err != nil {
+			return err
+		}
+	}
+
+	// the header grows after the storage, so that it never claims missing blocks
+	c.size = size
+	return c.writeHeader(stateDirty)
+}
+
+// headerTranscript forks the keyed transcript absorbing the header, followed by the write counters
+// of all blocks if the header is synced, which is left for the MAC.
+func (c *Container) headerTranscript(header []byte) (*strobe.Strobe, error) {
+	s := c.base.Clone()
+
+	if err := s.AD([]byte(labelHeader), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(header, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	if header[headerLen-1] != stateSynced {
+		return s, nil
+	}
+
+	counters := make([]byte, 0, counterLen*len(c.counters))
+	for _, v := range c.counters {
+		counters = binary.LittleEndian.AppendUint64(counters, v)
+	}
+
+	if err := s.AD([]byte(labelCounters), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(counters, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// markDirty marks the header dirty before the first change since the last sync, and commits it
+// before any block changes, so that a crash before the next sync fails Open with ErrUnsynced
+// instead of an authentication error.
+func (c *Container) markDirty() error {
+	if c.dirty {
+		return nil
+	}
+
+	if err := c.writeHeader(stateDirty); err != nil {
+		return err
+	}
+	if err := c.syncStorage(); err != nil {
+		return err
+	}
+	c.dirty = true
+
+	return nil
+}
+
+func (c *Container) marshalHeader(state byte) []byte {
+	out := make([]byte, 0, headerLen)
+
+	out = append(out, magic[:]...)
+	out = append(out, Version, byte(c.level/8))
+	out = binary.LittleEndian.AppendUint32(out, uint32(c.blockSize))
+	out = append(out, c.salt...)
+	out = binary.LittleEndian.AppendUint64(out, uint64(c.size))
+	out = binary.LittleEndian.AppendUint64(out, c.reserved)
+	out = append(out, state)
+
+	return out
+}
+
+func (c *Container) numBlocks(size int64) int64 {
+	n := size / int64(c.blockSize)
+	if size%int64(c.blockSize) != 0 {
+		n++
+	}
+
+	return n
+}
+
+// readBlock reads and verifies the i-th block, which must exist.
+func (c *Container) readBlock(i int64) ([]byte, error) {
+	record := make([]byte, c.recordLen())
+	if err := readFullAt(c.st, record, c.recordOffset(i)); err != nil {
+		return nil, err
+	}
+
+	counter := binary.LittleEndian.Uint64(record)
+	if counter != c.counters[i] {
+		return nil, ErrRollback
+	}
+
+	s, err := c.blockTranscript(i, counter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &strobe.Options{}
+
+	n := counterLen + c.blockSize
+	plaintext, err := s.RecvENC(record[counterLen:n], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RecvMAC(record[n:], opts); err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+func (c *Container) recordLen() int {
+	return counterLen + c.blockSize + TagLen
+}
+
+func (c *Container) recordOffset(i int64) int64 {
+	return headerLen + TagLen + i*int64(c.recordLen())
+}
+
+func (c *Container) sync() error {
+	if !c.dirty {
+		return nil
+	}
+
+	if err := c.writeHeader(stateSynced); err != nil {
+		return err
+	}
+	if err := c.syncStorage(); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
+
+// syncStorage commits the storage to stable storage if supported, e.g. by *os.File.
+func (c *Container) syncStorage() error {
+	if syncer, ok := c.st.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
+
+// verifyHeader checks the MAC of the raw header read from the storage.
+func (c *Container) verifyHeader(raw []byte) error {
+	s, err := c.headerTranscript(raw[:headerLen])
+	if err != nil {
+		return err
+	}
+
+	return s.RecvMAC(raw[headerLen:], &strobe.Options{})
+}
+
+// writeBlock encrypts the i-th block with the next write counter. block is modified in place.
+func (c *Container) writeBlock(i int64, block []byte) error {
+	if c.counter == c.reserved {
+		// commit the reservation before using it, so that no counter is reused after a crash
+		c.reserved += counterReservation
+		if err := c.writeHeader(stateDirty); err != nil {
+			return err
+		}
+		if err := c.syncStorage(); err != nil {
+			return err
+		}
+	}
+	c.counter++
+	counter := c.counter
+
+	s, err := c.blockTranscript(i, counter)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, counterLen, c.recordLen())
+	binary.LittleEndian.PutUint64(record, counter)
+
+	opts := &strobe.Options{}
+	ciphertext, err := s.SendENC(block, opts)
+	if err != nil {
+		return err
+	}
+	record = append(record, ciphertext...)
+
+	var mac [TagLen]byte
+	if err := s.SendMAC(mac[:], opts); err != nil {
+		return err
+	}
+	record = append(record, mac[:]...)
+
+	if _, err := c.st.WriteAt(record, c.recordOffset(i)); err != nil {
+		return err
+	}
+	c.counters[i] = counter
+
+	return nil
+}
+
+// writeHeader writes the header in the given state along with its MAC.
+func (c *Container) writeHeader(state byte) error {
+	raw := c.marshalHeader(state)
+
+	s, err := c.headerTranscript(raw)
+	if err != nil {
+		return err
+	}
+
+	var mac [TagLen]byte
+	if err := s.SendMAC(mac[:], &strobe.Options{}); err != nil {
+		return err
+	}
+
+	_, err = c.st.WriteAt(append(raw, mac[:]...), 0)
+	return err
+}
+
+// load loads the container from the storage for Open and Recover, where recovering tells whether a
+// dirty container is loaded as well.
+func load(st Storage, key []byte, config *Config, recovering bool) (*Container, error) {
+	proto := DefaultProto
+	if config != nil && config.Proto != "" {
+		proto = config.Proto
+	}
+
+	raw := make([]byte, headerLen+TagLen)
+	if err := readFullAt(st, raw, 0); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	if [4]byte(raw[:4]) != magic {
+		return nil, ErrInvalidHeader
+	} else if raw[4] != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	out := &Container{
+		st:        st,
+		level:     strobe.SecurityLevel(raw[5]) * 8,
+		blockSize: int(binary.LittleEndian.Uint32(raw[6:])),
+		salt:      raw[10 : 10+SaltLen],
+		size:      int64(binary.LittleEndian.Uint64(raw[10+SaltLen:])),
+		reserved:  binary.LittleEndian.Uint64(raw[18+SaltLen:]),
+	}
+	// any counter up to the reservation may have been used
+	out.counter = out.reserved
+	state := raw[headerLen-1]
+	if out.blockSize <= 0 || out.blockSize > maxBlockSize || out.size < 0 ||
+		(state != stateSynced && state != stateDirty) {
+		return nil, ErrInvalidHeader
+	}
+
+	var err error
+	if out.base, err = newBase(proto, out.level, out.blockSize, out.salt, key); err != nil {
+		return nil, err
+	}
+
+	// the MAC of a dirty header covers the header only, which is checked before trusting the size
+	if state == stateDirty {
+		if err := out.verifyHeader(raw); err != nil {
+			return nil, err
+		} else if !recovering {
+			return nil, ErrUnsynced
+		}
+		out.dirty = true
+	}
+
+	// the size of a synced header isn't authenticated yet, so check the storage holds all blocks
+	// before allocating their counters
+	n := out.numBlocks(out.size)
+	if n > (math.MaxInt64-headerLen-TagLen)/int64(out.recordLen()) {
+		return nil, ErrInvalidHeader
+	} else if n > 0 {
+		if err := readFullAt(st, make([]byte, out.recordLen()), out.recordOffset(n-1)); err != nil {
+			return nil, ErrTruncated
+		}
+	}
+
+	out.counters = make([]uint64, n)
+	for i := range out.counters {
+		var counter [counterLen]byte
+		if err := readFullAt(st, counter[:], out.recordOffset(int64(i))); err != nil {
+			return nil, err
+		}
+		out.counters[i] = binary.LittleEndian.Uint64(counter[:])
+	}
+
+	if state == stateSynced {
+		if err := out.verifyHeader(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// newBase initializes the keyed transcript shared by the header and all blocks.
+func newBase(proto string, level strobe.SecurityLevel, blockSize int, salt, key []byte) (*strobe.Strobe,
+	error) {
+	s, err := strobe.New(proto, level)
+	if err != nil {
+		return nil, err
+	} else if len(key) < int(level)/8 {
+		return nil, ErrInvalidKey
+	}
+
+	params := binary.LittleEndian.AppendUint32(nil, uint32(blockSize))
+	params = append(params, salt...)
+	if err := s.AD(params, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	if err := s.KEY(append([]byte{}, key...), false); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// readFullAt reads exactly len(p) bytes at offset off. A full read along with io.EOF counts as
+// success, as allowed by io.ReaderAt when the read ends at the end of the storage.
+func readFullAt(r io.ReaderAt, p []byte, off int64) error {
+	n, err := r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+
+	return err
+}
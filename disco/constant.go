@@ -0,0 +1,22 @@
+package disco
+
+const (
+	// DHLen is the length of X25519 public keys and shared secrets in bytes.
+	DHLen = 32
+	// MaxMsgLen is the maximum length of a handshake message in bytes.
+	MaxMsgLen = 65535
+	// PSKLen is the length of pre-shared keys in bytes.
+	PSKLen = 32
+	// TagLen is the length of the MAC appended to encrypted data in bytes.
+	TagLen = 16
+)
+
+// protocolNameFormat produces the protocol name from the pattern name, which is also the STROBE
+// proto of the handshake.
+const protocolNameFormat = "Noise_%s_25519_STROBEv1.0.2"
+
+// Labels to diversify the post-handshake instances of each direction.
+const (
+	labelInitiatorToResponder = "initiator"
+	labelResponderToInitiator = "responder"
+)
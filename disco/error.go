@@ -0,0 +1,26 @@
+package disco
+
+import "errors"
+
+var (
+	// ErrHandshakeComplete is the error returned when a message is written or read after the
+	// handshake completes.
+	ErrHandshakeComplete = errors.New("handshake complete")
+	// ErrHandshakeIncomplete is the error returned by Split before the handshake completes.
+	ErrHandshakeIncomplete = errors.New("handshake incomplete")
+	// ErrInvalidModifier is the error returned when the pattern has an unsupported modifier.
+	ErrInvalidModifier = errors.New("invalid pattern modifier")
+	// ErrInvalidPSK is the error returned when the pre-shared key isn't PSKLen bytes.
+	ErrInvalidPSK = errors.New("invalid pre-shared key")
+	// ErrMessageTooLong is the error returned when a message exceeds MaxMsgLen.
+	ErrMessageTooLong = errors.New("message too long")
+	// ErrMessageTooShort is the error returned when a received message is truncated.
+	ErrMessageTooShort = errors.New("message too short")
+	// ErrMissingKey is the error returned when a key required by the pattern isn't provided.
+	ErrMissingKey = errors.New("missing key")
+	// ErrOutOfTurn is the error returned when a party writes a message it should read, or vice
+	// versa.
+	ErrOutOfTurn = errors.New("out of turn")
+	// ErrUnknownPattern is the error returned when the pattern isn't supported.
+	ErrUnknownPattern = errors.New("unknown pattern")
+)
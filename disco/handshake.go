@@ -0,0 +1,342 @@
+// Package disco implements handshakes of the Noise protocol framework, where the symmetric state of
+// the handshake is a STROBE instance after the design of Disco.
+//
+// Public keys are absorbed with AD, DH outputs and pre-shared keys with KEY, while encrypted
+// static keys and payloads go through SendENC/SendMAC (or SendCLR before any key is mixed in).
+// Once the handshake completes, the transcript is split into two STROBE instances, one for each
+// direction.
+//
+// This package isn't compatible with Disco. Its wire format is pinned by vectors produced by this
+// package only, and hasn't been checked against the Disco specification or libdisco.
+//
+// See also https://www.discocrypto.com/disco.html and https://noiseprotocol.org/noise.html.
+package disco
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Config specifies the handshake to run.
+type Config struct {
+	// Pattern is the name of the handshake pattern, such as "XX" or "IKpsk2".
+	Pattern string
+	// Initiator specifies whether the local party is the initiator.
+	Initiator bool
+	// Prologue is the data both parties must agree on, absorbed before the handshake.
+	Prologue []byte
+	// StaticKey is the long-term key of the local party, required if the pattern uses one.
+	StaticKey *ecdh.PrivateKey
+	// EphemeralKey is the ephemeral key of the local party. It is generated from Rand if nil, and
+	// should be set only for testing.
+	EphemeralKey *ecdh.PrivateKey
+	// RemoteStatic is the long-term public key of the remote party known in advance, required if
+	// the pattern has a pre-message from the remote party.
+	RemoteStatic *ecdh.PublicKey
+	// PresharedKey is the PSKLen-byte symmetric key required by psk modifiers.
+	PresharedKey []byte
+	// Rand is the source of randomness for ephemeral keys, which defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// HandshakeState tracks the progress of a handshake for one party.
+type HandshakeState struct {
+	ss        *symmetricState
+	pattern   *HandshakePattern
+	initiator bool
+	msgIdx    int
+
+	s   *ecdh.PrivateKey
+	e   *ecdh.PrivateKey
+	rs  *ecdh.PublicKey
+	re  *ecdh.PublicKey
+	psk []byte
+
+	rand io.Reader
+}
+
+// Finished reports whether all messages of the handshake have been processed.
+func (h *HandshakeState) Finished() bool {
+	return h.msgIdx >= len(h.pattern.Messages)
+}
+
+// HandshakeHash returns a digest of the transcript so far, which can serve as channel binding.
+func (h *HandshakeState) HandshakeHash() ([]byte, error) {
+	if h.ss.s == nil {
+		return nil, ErrHandshakeComplete
+	}
+
+	return h.ss.handshakeHash()
+}
+
+// PeerStatic returns the remote party's static public key, which is nil if not yet known.
+func (h *HandshakeState) PeerStatic() *ecdh.PublicKey {
+	return h.rs
+}
+
+// ReadMessage processes a handshake message from the remote party, and returns the decrypted
+// payload.
+func (h *HandshakeState) ReadMessage(message []byte) ([]byte, error) {
+	if h.Finished() {
+		return nil, ErrHandshakeComplete
+	} else if h.isMyTurn() {
+		return nil, ErrOutOfTurn
+	} else if len(message) > MaxMsgLen {
+		return nil, ErrMessageTooLong
+	}
+
+	for _, t := range h.pattern.Messages[h.msgIdx] {
+		var err error
+		switch t {
+		case TokenE:
+			if len(message) < DHLen {
+				return nil, ErrMessageTooShort
+			}
+			if h.re, err = ecdh.X25519().NewPublicKey(message[:DHLen]); err != nil {
+				return nil, err
+			}
+			message = message[DHLen:]
+			err = h.mixEphemeral(h.re)
+		case TokenS:
+			n := DHLen
+			if h.ss.keyed {
+				n += TagLen
+			}
+			if len(message) < n {
+				return nil, ErrMessageTooShort
+			}
+
+			var pub []byte
+			if pub, err = h.ss.decryptAndHash(message[:n]); err != nil {
+				return nil, err
+			}
+			message = message[n:]
+			h.rs, err = ecdh.X25519().NewPublicKey(pub)
+		default:
+			err = h.mixToken(t)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if h.ss.keyed && len(message) < TagLen {
+		return nil, ErrMessageTooShort
+	}
+
+	payload, err := h.ss.decryptAndHash(message)
+	if err != nil {
+		return nil, err
+	}
+	h.msgIdx++
+
+	return payload, nil
+}
+
+// Split returns the STROBE instances for sending to and receiving from the remote party once the
+// handshake completes. The handshake state shouldn't be used any more afterwards.
+//
+// The returned instances only support transport operations in their own direction, and both
+// parties must apply the same sequence of operations to either direction.
+func (h *HandshakeState) Split() (send, recv *strobe.Strobe, err error) {
+	if !h.Finished() {
+		return nil, nil, ErrHandshakeIncomplete
+	} else if h.ss.s == nil {
+		return nil, nil, ErrHandshakeComplete
+	}
+
+	i2r, r2i, err := h.ss.split()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if h.initiator {
+		return i2r, r2i, nil
+	}
+	return r2i, i2r, nil
+}
+
+// WriteMessage produces the next handshake message carrying the given payload.
+func (h *HandshakeState) WriteMessage(payload []byte) ([]byte, error) {
+	if h.Finished() {
+		return nil, ErrHandshakeComplete
+	} else if !h.isMyTurn() {
+		return nil, ErrOutOfTurn
+	}
+
+	var out []byte
+	for _, t := range h.pattern.Messages[h.msgIdx] {
+		var err error
+		switch t {
+		case TokenE:
+			if h.e == nil {
+				if h.e, err = ecdh.X25519().GenerateKey(h.rand); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, h.e.PublicKey().Bytes()...)
+			err = h.mixEphemeral(h.e.PublicKey())
+		case TokenS:
+			out, err = h.ss.encryptAndHash(out, h.s.PublicKey().Bytes())
+		default:
+			err = h.mixToken(t)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := h.ss.encryptAndHash(out, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > MaxMsgLen {
+		return nil, ErrMessageTooLong
+	}
+	h.msgIdx++
+
+	return out, nil
+}
+
+func (h *HandshakeState) isMyTurn() bool {
+	return (h.msgIdx%2 == 0) == h.initiator
+}
+
+// mixEphemeral absorbs the ephemeral public key, which is also mixed in as a key for handshakes
+// with psk modifiers, as required by Noise.
+func (h *HandshakeState) mixEphemeral(pub *ecdh.PublicKey) error {
+	if err := h.ss.mixHash(pub.Bytes()); err != nil {
+		return err
+	}
+
+	if h.pattern.hasPSK() {
+		return h.ss.mixKey(pub.Bytes())
+	}
+
+	return nil
+}
+
+// mixToken processes the tokens independent of the message direction, i.e. DHs and psk.
+func (h *HandshakeState) mixToken(t Token) error {
+	var (
+		priv *ecdh.PrivateKey
+		pub  *ecdh.PublicKey
+	)
+
+	switch t {
+	case TokenEE:
+		priv, pub = h.e, h.re
+	case TokenES:
+		if h.initiator {
+			priv, pub = h.e, h.rs
+		} else {
+			priv, pub = h.s, h.re
+		}
+	case TokenSE:
+		if h.initiator {
+			priv, pub = h.s, h.re
+		} else {
+			priv, pub = h.e, h.rs
+		}
+	case TokenSS:
+		priv, pub = h.s, h.rs
+	case TokenPSK:
+		return h.ss.mixKey(h.psk)
+	default:
+		return fmt.Errorf("%w: token %q", ErrUnknownPattern, t)
+	}
+
+	if priv == nil || pub == nil {
+		return fmt.Errorf("%w: for token %q", ErrMissingKey, t)
+	}
+
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return err
+	}
+
+	return h.ss.mixKey(secret)
+}
+
+// NewHandshakeState initializes the handshake described by the config.
+//
+// The STROBE proto is the protocol name like "Noise_XX_25519_STROBEv1.0.2", followed by the AD
+// of the prologue and the pre-message keys of the initiator and then the responder.
+func NewHandshakeState(config *Config) (*HandshakeState, error) {
+	pattern, err := ParsePattern(config.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &HandshakeState{
+		pattern:   pattern,
+		initiator: config.Initiator,
+		s:         config.StaticKey,
+		e:         config.EphemeralKey,
+		rs:        config.RemoteStatic,
+		psk:       config.PresharedKey,
+		rand:      config.Rand,
+	}
+	if out.rand == nil {
+		out.rand = rand.Reader
+	}
+
+	if pattern.needs(out.initiator, TokenS) && out.s == nil {
+		return nil, fmt.Errorf("%w: local static key", ErrMissingKey)
+	}
+
+	remotePre := pattern.ResponderPreMessage
+	if !out.initiator {
+		remotePre = pattern.InitiatorPreMessage
+	}
+	if len(remotePre) > 0 && out.rs == nil {
+		return nil, fmt.Errorf("%w: remote static key", ErrMissingKey)
+	}
+
+	if pattern.hasPSK() && len(out.psk) != PSKLen {
+		return nil, ErrInvalidPSK
+	}
+
+	if out.ss, err = newSymmetricState(fmt.Sprintf(protocolNameFormat, pattern.Name)); err != nil {
+		return nil, err
+	}
+
+	if err := out.ss.mixHash(config.Prologue); err != nil {
+		return nil, err
+	}
+
+	// pre-messages only carry static keys among the supported patterns
+	var initiatorStatic, responderStatic *ecdh.PublicKey
+	if out.initiator {
+		initiatorStatic, responderStatic = publicKeyOf(out.s), out.rs
+	} else {
+		initiatorStatic, responderStatic = out.rs, publicKeyOf(out.s)
+	}
+	if len(pattern.InitiatorPreMessage) > 0 {
+		if err := out.ss.mixHash(initiatorStatic.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if len(pattern.ResponderPreMessage) > 0 {
+		if err := out.ss.mixHash(responderStatic.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func publicKeyOf(priv *ecdh.PrivateKey) *ecdh.PublicKey {
+	if priv == nil {
+		return nil
+	}
+
+	return priv.PublicKey()
+}
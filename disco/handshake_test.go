@@ -0,0 +1,350 @@
+package disco_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/disco"
+)
+
+var allPatterns = []string{
+	"N", "K", "X", "NN", "NK", "KK", "XX", "IK", "XK",
+	"Npsk0", "Kpsk0", "Xpsk1", "NNpsk0", "NNpsk2", "NKpsk2", "KKpsk0", "XXpsk3", "IKpsk1",
+	"IKpsk2", "XKpsk3", "XXpsk0+psk3",
+}
+
+func TestHandshakeState(t *testing.T) {
+	for _, p := range allPatterns {
+		p := p
+		t.Run(p, func(t *testing.T) {
+			initiator, responder := mustNewHandshakePair(t, p, nil)
+
+			initiatorSend, initiatorRecv, responderSend, responderRecv := mustHandshake(t, initiator,
+				responder)
+
+			mustTransport(t, initiatorSend, responderRecv)
+			mustTransport(t, responderSend, initiatorRecv)
+			mustTransport(t, initiatorSend, responderRecv)
+		})
+	}
+}
+
+func TestHandshakeState_PeerStatic(t *testing.T) {
+	initiator, responder := mustNewHandshakePair(t, "XX", nil)
+	mustHandshake(t, initiator, responder)
+
+	if !initiator.PeerStatic().Equal(mustStaticKey(t, false).PublicKey()) {
+		t.Fatal("initiator learns invalid responder static key")
+	}
+	if !responder.PeerStatic().Equal(mustStaticKey(t, true).PublicKey()) {
+		t.Fatal("responder learns invalid initiator static key")
+	}
+}
+
+func TestHandshakeState_OutOfTurn(t *testing.T) {
+	initiator, responder := mustNewHandshakePair(t, "NN", nil)
+
+	if _, err := responder.WriteMessage(nil); err != disco.ErrOutOfTurn {
+		t.Fatalf("invalid error for responder writing first: expect %v, got %v", disco.ErrOutOfTurn, err)
+	}
+	if _, err := initiator.ReadMessage(nil); err != disco.ErrOutOfTurn {
+		t.Fatalf("invalid error for initiator reading first: expect %v, got %v", disco.ErrOutOfTurn, err)
+	}
+
+	if _, _, err := initiator.Split(); err != disco.ErrHandshakeIncomplete {
+		t.Fatalf("invalid error for early split: expect %v, got %v", disco.ErrHandshakeIncomplete, err)
+	}
+
+	mustHandshake(t, initiator, responder)
+
+	if _, err := initiator.WriteMessage(nil); err != disco.ErrHandshakeComplete {
+		t.Fatalf("invalid error for extra message: expect %v, got %v", disco.ErrHandshakeComplete, err)
+	}
+}
+
+func TestHandshakeState_Mismatch(t *testing.T) {
+	testVector := []struct {
+		pattern string
+		mutate  func(initiator, responder *disco.Config)
+	}{
+		{"NN", func(i, r *disco.Config) { r.Prologue = []byte("another prologue") }},
+		{"NNpsk2", func(i, r *disco.Config) { r.PresharedKey = bytes.Repeat([]byte{0xff}, disco.PSKLen) }},
+		{"NK", func(i, r *disco.Config) { i.RemoteStatic = mustStaticKey(t, true).PublicKey() }},
+		{"KK", func(i, r *disco.Config) { r.RemoteStatic = mustStaticKey(t, false).PublicKey() }},
+	}
+
+	for i, c := range testVector {
+		initiator, responder := mustNewHandshakePair(t, c.pattern, c.mutate)
+
+		if err := tryHandshake(initiator, responder); !errors.Is(err, strobe.ErrAuthenticationFailed) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestHandshakeState_Tampered(t *testing.T) {
+	for _, p := range []string{"NK", "XX", "IKpsk2"} {
+		initiator, responder := mustNewHandshakePair(t, p, nil)
+
+		msg, err := initiator.WriteMessage([]byte("hello"))
+		if err != nil {
+			t.Fatalf("%s: fail to write message: %v", p, err)
+		}
+		msg[len(msg)-1] ^= 1
+
+		_, err = responder.ReadMessage(msg)
+		if p == "XX" { // the first message of XX is unauthenticated
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", p, err)
+			}
+			continue
+		}
+
+		if err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("%s: invalid error: expect %v, got %v", p, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestNewHandshakeState_Error(t *testing.T) {
+	testVector := []struct {
+		config disco.Config
+		expect error
+	}{
+		{disco.Config{Pattern: "YY"}, disco.ErrUnknownPattern},
+		{disco.Config{Pattern: "XX", Initiator: true}, disco.ErrMissingKey},
+		{disco.Config{Pattern: "NK", Initiator: true}, disco.ErrMissingKey},
+		{disco.Config{Pattern: "NNpsk0", PresharedKey: []byte("short")}, disco.ErrInvalidPSK},
+	}
+
+	for i, c := range testVector {
+		if _, err := disco.NewHandshakeState(&c.config); !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
+
+type handshakeVector struct {
+	Pattern            string
+	Prologue           []byte
+	PresharedKey       []byte
+	InitiatorStatic    []byte
+	InitiatorEphemeral []byte
+	ResponderStatic    []byte
+	ResponderEphemeral []byte
+	Messages           []handshakeMessage
+	HandshakeHash      []byte
+}
+
+type handshakeMessage struct {
+	Payload    []byte
+	Ciphertext []byte
+}
+
+// TestHandshakeState_Vectors replays handshakes with fixed keys, of which the vectors are
+// produced by this implementation to pin the wire format down.
+func TestHandshakeState_Vectors(t *testing.T) {
+	var testVector []handshakeVector
+	if err := json.Unmarshal(mustReadFile(t, "testdata/handshakes.json"), &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, v := range testVector {
+		initiator, responder := mustNewHandshakePairFromVector(t, &v)
+
+		for j, m := range v.Messages {
+			writer, reader := initiator, responder
+			if j%2 == 1 {
+				writer, reader = responder, initiator
+			}
+
+			ciphertext, err := writer.WriteMessage(m.Payload)
+			if err != nil {
+				t.Fatalf("#%d-%d fail to write: %v", i, j, err)
+			} else if !bytes.Equal(m.Ciphertext, ciphertext) {
+				t.Fatalf("#%d-%d invalid ciphertext: expect %x, got %x", i, j, m.Ciphertext, ciphertext)
+			}
+
+			payload, err := reader.ReadMessage(ciphertext)
+			if err != nil {
+				t.Fatalf("#%d-%d fail to read: %v", i, j, err)
+			} else if !bytes.Equal(m.Payload, payload) {
+				t.Fatalf("#%d-%d invalid payload: expect %x, got %x", i, j, m.Payload, payload)
+			}
+		}
+
+		for j, h := range []*disco.HandshakeState{initiator, responder} {
+			hash, err := h.HandshakeHash()
+			if err != nil {
+				t.Fatalf("#%d-%d fail to get handshake hash: %v", i, j, err)
+			} else if !bytes.Equal(v.HandshakeHash, hash) {
+				t.Fatalf("#%d-%d invalid handshake hash: expect %x, got %x", i, j, v.HandshakeHash, hash)
+			}
+		}
+	}
+}
+
+func mustHandshake(t *testing.T, initiator, responder *disco.HandshakeState) (initiatorSend,
+	initiatorRecv, responderSend, responderRecv *strobe.Strobe) {
+	if err := tryHandshake(initiator, responder); err != nil {
+		t.Fatalf("fail to handshake: %v", err)
+	}
+
+	var err error
+	if initiatorSend, initiatorRecv, err = initiator.Split(); err != nil {
+		t.Fatalf("initiator fail to split: %v", err)
+	}
+	if responderSend, responderRecv, err = responder.Split(); err != nil {
+		t.Fatalf("responder fail to split: %v", err)
+	}
+
+	return
+}
+
+func mustNewHandshakePair(t *testing.T, pattern string,
+	mutate func(initiator, responder *disco.Config)) (*disco.HandshakeState, *disco.HandshakeState) {
+	p, err := disco.ParsePattern(pattern)
+	if err != nil {
+		t.Fatalf("fail to parse pattern: %v", err)
+	}
+
+	initiatorConfig := &disco.Config{
+		Pattern:      pattern,
+		Initiator:    true,
+		Prologue:     []byte("prologue"),
+		StaticKey:    mustStaticKey(t, true),
+		PresharedKey: bytes.Repeat([]byte{0x01}, disco.PSKLen),
+	}
+	responderConfig := &disco.Config{
+		Pattern:      pattern,
+		Prologue:     []byte("prologue"),
+		StaticKey:    mustStaticKey(t, false),
+		PresharedKey: bytes.Repeat([]byte{0x01}, disco.PSKLen),
+	}
+	if len(p.ResponderPreMessage) > 0 {
+		initiatorConfig.RemoteStatic = responderConfig.StaticKey.PublicKey()
+	}
+	if len(p.InitiatorPreMessage) > 0 {
+		responderConfig.RemoteStatic = initiatorConfig.StaticKey.PublicKey()
+	}
+
+	if mutate != nil {
+		mutate(initiatorConfig, responderConfig)
+	}
+
+	initiator, err := disco.NewHandshakeState(initiatorConfig)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	responder, err := disco.NewHandshakeState(responderConfig)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return initiator, responder
+}
+
+func mustNewHandshakePairFromVector(t *testing.T,
+	v *handshakeVector) (*disco.HandshakeState, *disco.HandshakeState) {
+	return mustNewHandshakePair(t, v.Pattern, func(i, r *disco.Config) {
+		i.Prologue, r.Prologue = v.Prologue, v.Prologue
+		i.PresharedKey, r.PresharedKey = v.PresharedKey, v.PresharedKey
+		i.StaticKey, r.StaticKey = mustX25519Key(t, v.InitiatorStatic), mustX25519Key(t, v.ResponderStatic)
+		i.EphemeralKey = mustX25519Key(t, v.InitiatorEphemeral)
+		r.EphemeralKey = mustX25519Key(t, v.ResponderEphemeral)
+		if i.RemoteStatic != nil {
+			i.RemoteStatic = r.StaticKey.PublicKey()
+		}
+		if r.RemoteStatic != nil {
+			r.RemoteStatic = i.StaticKey.PublicKey()
+		}
+	})
+}
+
+func mustReadFile(t *testing.T, filename string) []byte {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func mustStaticKey(t *testing.T, initiator bool) *ecdh.PrivateKey {
+	seed := byte(0x11)
+	if !initiator {
+		seed = 0x22
+	}
+
+	return mustX25519Key(t, bytes.Repeat([]byte{seed}, 32))
+}
+
+// mustTransport sends an encrypted and authenticated message from sender to receiver.
+func mustTransport(t *testing.T, sender, receiver *strobe.Strobe) {
+	const msg = "hello world"
+	opts := &strobe.Options{}
+
+	ciphertext, err := sender.SendENC([]byte(msg), opts)
+	if err != nil {
+		t.Fatalf("fail to SendENC: %v", err)
+	}
+
+	var mac [disco.TagLen]byte
+	if err := sender.SendMAC(mac[:], opts); err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+
+	plaintext, err := receiver.RecvENC(ciphertext, opts)
+	if err != nil {
+		t.Fatalf("fail to RecvENC: %v", err)
+	} else if string(plaintext) != msg {
+		t.Fatalf("invalid plaintext: expect %q, got %q", msg, plaintext)
+	}
+
+	if err := receiver.RecvMAC(mac[:], opts); err != nil {
+		t.Fatalf("fail to RecvMAC: %v", err)
+	}
+}
+
+func mustX25519Key(t *testing.T, key []byte) *ecdh.PrivateKey {
+	out, err := ecdh.X25519().NewPrivateKey(key)
+	if err != nil {
+		t.Fatalf("fail to load X25519 key: %v", err)
+	}
+
+	return out
+}
+
+func tryHandshake(initiator, responder *disco.HandshakeState) error {
+	writer, reader := initiator, responder
+	for i := 0; !initiator.Finished(); i++ {
+		payload := []byte(fmt.Sprintf("payload #%d", i))
+
+		msg, err := writer.WriteMessage(payload)
+		if err != nil {
+			return fmt.Errorf("fail to write message #%d: %w", i, err)
+		}
+
+		got, err := reader.ReadMessage(msg)
+		if err != nil {
+			return fmt.Errorf("fail to read message #%d: %w", i, err)
+		} else if !bytes.Equal(payload, got) {
+			return fmt.Errorf("invalid payload #%d: expect %x, got %x", i, payload, got)
+		}
+
+		writer, reader = reader, writer
+	}
+
+	if !responder.Finished() {
+		return errors.New("responder unfinished")
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package disco
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Token is a step of a handshake message, as defined by the Noise protocol framework.
+type Token string
+
+// Tokens available in handshake patterns.
+const (
+	TokenE   Token = "e"
+	TokenS   Token = "s"
+	TokenEE  Token = "ee"
+	TokenES  Token = "es"
+	TokenSE  Token = "se"
+	TokenSS  Token = "ss"
+	TokenPSK Token = "psk"
+)
+
+// HandshakePattern describes the sequence of messages of a handshake.
+type HandshakePattern struct {
+	// Name is the full name of the pattern including modifiers, such as "XXpsk3".
+	Name string
+	// InitiatorPreMessage lists the initiator's keys known by the responder in advance.
+	InitiatorPreMessage []Token
+	// ResponderPreMessage lists the responder's keys known by the initiator in advance.
+	ResponderPreMessage []Token
+	// Messages lists the tokens of each message. Messages of even indices are sent by the
+	// initiator, and those of odd indices are sent by the responder.
+	Messages [][]Token
+}
+
+// basePatterns are the supported fundamental patterns.
+var basePatterns = map[string]HandshakePattern{
+	"N": {
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES}},
+	},
+	"K": {
+		InitiatorPreMessage: []Token{TokenS},
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES, TokenSS}},
+	},
+	"X": {
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES, TokenS, TokenSS}},
+	},
+	"NN": {
+		Messages: [][]Token{{TokenE}, {TokenE, TokenEE}},
+	},
+	"NK": {
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES}, {TokenE, TokenEE}},
+	},
+	"KK": {
+		InitiatorPreMessage: []Token{TokenS},
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES, TokenSS}, {TokenE, TokenEE, TokenSE}},
+	},
+	"XX": {
+		Messages: [][]Token{{TokenE}, {TokenE, TokenEE, TokenS, TokenES}, {TokenS, TokenSE}},
+	},
+	"IK": {
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES, TokenS, TokenSS}, {TokenE, TokenEE, TokenSE}},
+	},
+	"XK": {
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES}, {TokenE, TokenEE}, {TokenS, TokenSE}},
+	},
+}
+
+// ParsePattern parses a pattern name such as "NN", "IKpsk2" or "XXpsk0+psk3" into its messages.
+//
+// A pskN modifier places a psk token at the beginning of the first message if N is 0, or at the
+// end of the N-th message otherwise.
+func ParsePattern(name string) (*HandshakePattern, error) {
+	i := 0
+	for i < len(name) && name[i] >= 'A' && name[i] <= 'Z' {
+		i++
+	}
+
+	base, ok := basePatterns[name[:i]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPattern, name)
+	}
+
+	out := &HandshakePattern{
+		Name:                name,
+		InitiatorPreMessage: base.InitiatorPreMessage,
+		ResponderPreMessage: base.ResponderPreMessage,
+		Messages:            make([][]Token, len(base.Messages)),
+	}
+	for j, v := range base.Messages {
+		out.Messages[j] = append([]Token{}, v...)
+	}
+
+	if i == len(name) {
+		return out, nil
+	}
+
+	seen := make(map[int]bool)
+	for _, m := range strings.Split(name[i:], "+") {
+		if !strings.HasPrefix(m, "psk") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidModifier, m)
+		}
+
+		n, err := strconv.Atoi(m[len("psk"):])
+		if err != nil || n < 0 || n > len(out.Messages) || seen[n] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidModifier, m)
+		}
+		seen[n] = true
+
+		if n == 0 {
+			out.Messages[0] = append([]Token{TokenPSK}, out.Messages[0]...)
+		} else {
+			out.Messages[n-1] = append(out.Messages[n-1], TokenPSK)
+		}
+	}
+
+	return out, nil
+}
+
+// String renders the pattern in the notation of the Noise specification.
+func (p *HandshakePattern) String() string {
+	var b strings.Builder
+
+	b.WriteString(p.Name + ":\n")
+	if len(p.InitiatorPreMessage) > 0 {
+		fmt.Fprintf(&b, "  -> %s\n", joinTokens(p.InitiatorPreMessage))
+	}
+	if len(p.ResponderPreMessage) > 0 {
+		fmt.Fprintf(&b, "  <- %s\n", joinTokens(p.ResponderPreMessage))
+	}
+	if len(p.InitiatorPreMessage)+len(p.ResponderPreMessage) > 0 {
+		b.WriteString("  ...\n")
+	}
+	for i, v := range p.Messages {
+		arrow := "->"
+		if i%2 == 1 {
+			arrow = "<-"
+		}
+		fmt.Fprintf(&b, "  %s %s\n", arrow, joinTokens(v))
+	}
+
+	return b.String()
+}
+
+func (p *HandshakePattern) hasPSK() bool {
+	for _, v := range p.Messages {
+		for _, t := range v {
+			if t == TokenPSK {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// needs reports whether the given token appears in the pre-message or any message sent by the
+// party.
+func (p *HandshakePattern) needs(initiator bool, token Token) bool {
+	pre := p.ResponderPreMessage
+	if initiator {
+		pre = p.InitiatorPreMessage
+	}
+	for _, t := range pre {
+		if t == token {
+			return true
+		}
+	}
+
+	for i, v := range p.Messages {
+		if (i%2 == 0) != initiator {
+			continue
+		}
+		for _, t := range v {
+			if t == token {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func joinTokens(tokens []Token) string {
+	out := make([]string, len(tokens))
+	for i, v := range tokens {
+		out[i] = string(v)
+	}
+
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,72 @@
+package disco_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sammyne/strobe/disco"
+)
+
+func TestParsePattern(t *testing.T) {
+	testVector := []struct {
+		name   string
+		expect [][]disco.Token
+	}{
+		{"NN", [][]disco.Token{{"e"}, {"e", "ee"}}},
+		{"NNpsk0", [][]disco.Token{{"psk", "e"}, {"e", "ee"}}},
+		{"NNpsk2", [][]disco.Token{{"e"}, {"e", "ee", "psk"}}},
+		{"XXpsk0+psk3", [][]disco.Token{{"psk", "e"}, {"e", "ee", "s", "es"}, {"s", "se", "psk"}}},
+		{"Npsk0", [][]disco.Token{{"psk", "e", "es"}}},
+	}
+
+	for i, c := range testVector {
+		p, err := disco.ParsePattern(c.name)
+		if err != nil {
+			t.Fatalf("#%d unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(c.expect, p.Messages) {
+			t.Fatalf("#%d invalid messages: expect %v, got %v", i, c.expect, p.Messages)
+		}
+	}
+}
+
+func TestParsePattern_Error(t *testing.T) {
+	testVector := []struct {
+		name   string
+		expect error
+	}{
+		{"", disco.ErrUnknownPattern},
+		{"IX", disco.ErrUnknownPattern},
+		{"xx", disco.ErrUnknownPattern},
+		{"NNfallback", disco.ErrInvalidModifier},
+		{"NNpsk", disco.ErrInvalidModifier},
+		{"NNpsk3", disco.ErrInvalidModifier},
+		{"NNpsk0+psk0", disco.ErrInvalidModifier},
+	}
+
+	for i, c := range testVector {
+		if _, err := disco.ParsePattern(c.name); !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
+
+func TestHandshakePattern_String(t *testing.T) {
+	const expect = `IKpsk2:
+  <- s
+  ...
+  -> e, es, s, ss
+  <- e, ee, se, psk
+`
+
+	p, err := disco.ParsePattern("IKpsk2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := p.String(); expect != got {
+		t.Fatalf("invalid rendering: expect\n%s\ngot\n%s", expect, got)
+	}
+}
@@ -0,0 +1,123 @@
+package disco
+
+import (
+	"github.com/sammyne/strobe"
+)
+
+// symmetricState is the counterpart of the SymmetricState in Noise, where a single STROBE
+// instance replaces the CipherState, chaining key and handshake hash.
+type symmetricState struct {
+	s     *strobe.Strobe
+	keyed bool
+}
+
+// decryptAndHash reads len(ciphertext)-TagLen bytes of ciphertext followed by a MAC if keyed, or
+// the plaintext otherwise.
+func (ss *symmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	opts := &strobe.Options{}
+
+	data := append([]byte{}, ciphertext...)
+	if !ss.keyed {
+		if err := ss.s.RecvCLR(data, opts); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	if len(data) < TagLen {
+		return nil, ErrMessageTooShort
+	}
+
+	n := len(data) - TagLen
+	plaintext, err := ss.s.RecvENC(data[:n], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ss.s.RecvMAC(data[n:], opts); err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+// encryptAndHash appends the ciphertext of plaintext followed by a MAC to out if keyed, or the
+// plaintext otherwise.
+func (ss *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	opts := &strobe.Options{}
+
+	n := len(out)
+	out = append(out, plaintext...)
+	if !ss.keyed {
+		return out, ss.s.SendCLR(out[n:], opts)
+	}
+
+	if _, err := ss.s.SendENC(out[n:], opts); err != nil {
+		return nil, err
+	}
+
+	var mac [TagLen]byte
+	if err := ss.s.SendMAC(mac[:], opts); err != nil {
+		return nil, err
+	}
+
+	return append(out, mac[:]...), nil
+}
+
+func (ss *symmetricState) handshakeHash() ([]byte, error) {
+	out := make([]byte, DHLen)
+	if err := ss.s.Clone().PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (ss *symmetricState) mixHash(data []byte) error {
+	return ss.s.AD(data, &strobe.Options{})
+}
+
+func (ss *symmetricState) mixKey(inputKeyMaterial []byte) error {
+	if err := ss.s.KEY(append([]byte{}, inputKeyMaterial...), false); err != nil {
+		return err
+	}
+
+	ss.keyed = true
+	return nil
+}
+
+// split forks the state into two instances for the initiator-to-responder and the
+// responder-to-initiator directions respectively, each ratcheted to prevent rollback.
+func (ss *symmetricState) split() (*strobe.Strobe, *strobe.Strobe, error) {
+	i2r, r2i := ss.s, ss.s.Clone()
+
+	directions := []struct {
+		s     *strobe.Strobe
+		label string
+	}{
+		{i2r, labelInitiatorToResponder},
+		{r2i, labelResponderToInitiator},
+	}
+
+	for _, v := range directions {
+		if err := v.s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, nil, err
+		}
+		if err := v.s.RATCHET(DHLen); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ss.s = nil
+
+	return i2r, r2i, nil
+}
+
+func newSymmetricState(protocolName string) (*symmetricState, error) {
+	s, err := strobe.New(protocolName, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	return &symmetricState{s: s}, nil
+}
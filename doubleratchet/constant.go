@@ -0,0 +1,39 @@
+package doubleratchet
+
+const (
+	// KeyLen is the length of root, chain, message and header keys in bytes.
+	KeyLen = 32
+	// MaxSkip is the maximum number of message keys skipped in a single chain.
+	MaxSkip = 1000
+	// MaxSkippedKeys is the maximum number of skipped message keys cached, beyond which the oldest
+	// ones are evicted.
+	MaxSkippedKeys = 2000
+	// NonceLen is the length of the random nonce of encrypted headers in bytes.
+	NonceLen = 16
+	// TagLen is the length of the MAC of headers and messages in bytes.
+	TagLen = 16
+)
+
+// headerLen is the length of the plaintext header, i.e. the X25519 ratchet public key followed by
+// the little-endian 32-bit PN and N.
+const headerLen = 32 + 4 + 4
+
+// encryptedHeaderLen is the length of the encrypted header on the wire.
+const encryptedHeaderLen = NonceLen + headerLen + TagLen
+
+// STROBE protos for the KDFs and AEADs.
+const (
+	protoChain   = "github.com/sammyne/strobe/doubleratchet/chain"
+	protoHeader  = "github.com/sammyne/strobe/doubleratchet/header"
+	protoMessage = "github.com/sammyne/strobe/doubleratchet/message"
+	protoRoot    = "github.com/sammyne/strobe/doubleratchet/root"
+)
+
+// Labels framing the outputs of the KDFs.
+const (
+	labelChainKey   = "chain key"
+	labelDH         = "dh"
+	labelHeaderKey  = "header key"
+	labelMessageKey = "message key"
+	labelRootKey    = "root key"
+)
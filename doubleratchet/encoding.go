@@ -0,0 +1,99 @@
+package doubleratchet
+
+import (
+	"crypto/ecdh"
+	"encoding/json"
+)
+
+type sessionJSON struct {
+	DHs     []byte
+	DHr     []byte
+	RK      []byte
+	CKs     []byte
+	CKr     []byte
+	HKs     []byte
+	HKr     []byte
+	NHKs    []byte
+	NHKr    []byte
+	Ns      uint32
+	Nr      uint32
+	PN      uint32
+	Skipped []skippedKeyJSON
+}
+
+type skippedKeyJSON struct {
+	HK []byte
+	N  uint32
+	MK []byte
+}
+
+// MarshalJSON marshals the session state as JSON to persist, which contains secret keys and must
+// be stored securely.
+func (s *Session) MarshalJSON() ([]byte, error) {
+	st := &s.st
+
+	ss := sessionJSON{
+		DHs:  st.dhs.Bytes(),
+		RK:   st.rk,
+		CKs:  st.cks,
+		CKr:  st.ckr,
+		HKs:  st.hks,
+		HKr:  st.hkr,
+		NHKs: st.nhks,
+		NHKr: st.nhkr,
+		Ns:   st.ns,
+		Nr:   st.nr,
+		PN:   st.pn,
+	}
+	if st.dhr != nil {
+		ss.DHr = st.dhr.Bytes()
+	}
+	for _, v := range st.skipped {
+		ss.Skipped = append(ss.Skipped, skippedKeyJSON{HK: v.hk, N: v.n, MK: v.mk})
+	}
+
+	return json.Marshal(ss)
+}
+
+// UnmarshalJSON unmarshals the session state from JSON exported by MarshalJSON. The source of
+// randomness is reset to crypto/rand.Reader.
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var ss sessionJSON
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return err
+	}
+
+	dhs, err := ecdh.X25519().NewPrivateKey(ss.DHs)
+	if err != nil {
+		return err
+	}
+
+	var dhr *ecdh.PublicKey
+	if ss.DHr != nil {
+		if dhr, err = ecdh.X25519().NewPublicKey(ss.DHr); err != nil {
+			return err
+		}
+	}
+
+	out := newSession(nil)
+	out.st = state{
+		dhs:  dhs,
+		dhr:  dhr,
+		rk:   ss.RK,
+		cks:  ss.CKs,
+		ckr:  ss.CKr,
+		hks:  ss.HKs,
+		hkr:  ss.HKr,
+		nhks: ss.NHKs,
+		nhkr: ss.NHKr,
+		ns:   ss.Ns,
+		nr:   ss.Nr,
+		pn:   ss.PN,
+	}
+	for _, v := range ss.Skipped {
+		out.st.skipped = append(out.st.skipped, skippedKey{hk: v.HK, n: v.N, mk: v.MK})
+	}
+
+	*s = *out
+	return nil
+}
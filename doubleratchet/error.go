@@ -0,0 +1,17 @@
+package doubleratchet
+
+import "errors"
+
+var (
+	// ErrInvalidKey is the error returned when a key isn't KeyLen bytes.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrInvalidMessage is the error returned when a message is malformed, or its header can't be
+	// decrypted with any known header key, which includes replayed messages.
+	ErrInvalidMessage = errors.New("invalid message")
+	// ErrNoSendingChain is the error returned by Encrypt when the responder hasn't received any
+	// message.
+	ErrNoSendingChain = errors.New("no sending chain")
+	// ErrTooManySkipped is the error returned when a message would skip more than MaxSkip message
+	// keys.
+	ErrTooManySkipped = errors.New("too many skipped messages")
+)
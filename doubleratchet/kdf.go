@@ -0,0 +1,138 @@
+package doubleratchet
+
+import (
+	"github.com/sammyne/strobe"
+)
+
+// kdfCK advances the chain key, returning the next chain key and the message key. The message key
+// is extracted before a RATCHET, so that it can't be recovered from the next chain key.
+func kdfCK(ck []byte) (nextCK, mk []byte, err error) {
+	s, err := newKeyed(protoChain, ck)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mk, err = prf(s, labelMessageKey); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.RATCHET(KeyLen); err != nil {
+		return nil, nil, err
+	}
+
+	if nextCK, err = prf(s, labelChainKey); err != nil {
+		return nil, nil, err
+	}
+
+	return nextCK, mk, nil
+}
+
+// kdfRK mixes the DH output into the root key, returning the next root key, a chain key and the
+// next header key.
+func kdfRK(rk, dhOut []byte) (nextRK, ck, nhk []byte, err error) {
+	s, err := newKeyed(protoRoot, rk)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := s.AD([]byte(labelDH), &strobe.Options{Meta: true}); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := s.KEY(append([]byte{}, dhOut...), false); err != nil {
+		return nil, nil, nil, err
+	}
+
+	outs := []*[]byte{&nextRK, &ck, &nhk}
+	for i, label := range []string{labelRootKey, labelChainKey, labelHeaderKey} {
+		if *outs[i], err = prf(s, label); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return nextRK, ck, nhk, nil
+}
+
+// newKeyed initializes a STROBE instance keyed with a copy of the key.
+func newKeyed(proto string, key []byte) (*strobe.Strobe, error) {
+	s, err := strobe.New(proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.KEY(append([]byte{}, key...), false); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// open verifies and decrypts the ciphertext followed by a MAC produced by seal.
+func open(proto string, key, ad, sealed []byte) ([]byte, error) {
+	if len(sealed) < TagLen {
+		return nil, ErrInvalidMessage
+	}
+
+	s, err := newKeyed(proto, key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &strobe.Options{}
+	if err := s.AD(ad, opts); err != nil {
+		return nil, err
+	}
+
+	data := append([]byte{}, sealed...)
+	n := len(data) - TagLen
+
+	plaintext, err := s.RecvENC(data[:n], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RecvMAC(data[n:], opts); err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+func prf(s *strobe.Strobe, label string) ([]byte, error) {
+	if err := s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, KeyLen)
+	if err := s.PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// seal encrypts the plaintext bound to the associated data, and appends a MAC. The key must be
+// unique per call, or the associated data must contain a nonce.
+func seal(proto string, key, ad, plaintext []byte) ([]byte, error) {
+	s, err := newKeyed(proto, key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &strobe.Options{}
+	if err := s.AD(ad, opts); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(plaintext)+TagLen)
+	copy(out, plaintext)
+
+	if _, err := s.SendENC(out[:len(plaintext)], opts); err != nil {
+		return nil, err
+	}
+
+	if err := s.SendMAC(out[len(plaintext):], opts); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
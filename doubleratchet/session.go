@@ -0,0 +1,338 @@
+// Package doubleratchet implements the Signal Double Ratchet algorithm with header encryption,
+// where STROBE serves as the KDF chains and the AEAD.
+//
+// The root chain mixes X25519 outputs in with KEY and extracts keys with PRF, while the symmetric
+// chains extract each message key with PRF and RATCHET before deriving the next chain key, so that
+// compromising a chain key doesn't expose earlier message keys.
+//
+// The initial shared secret and header keys are expected to come from a key agreement like X3DH,
+// which is out of scope.
+//
+// See also https://signal.org/docs/specifications/doubleratchet/.
+package doubleratchet
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+// Session is one party of a Double Ratchet conversation, which isn't safe for concurrent use.
+type Session struct {
+	st   state
+	rand io.Reader
+}
+
+// state follows the variables of the specification, where nil keys mean None.
+type state struct {
+	dhs  *ecdh.PrivateKey
+	dhr  *ecdh.PublicKey
+	rk   []byte
+	cks  []byte
+	ckr  []byte
+	hks  []byte
+	hkr  []byte
+	nhks []byte
+	nhkr []byte
+	ns   uint32
+	nr   uint32
+	pn   uint32
+
+	// skipped caches the skipped message keys in insertion order.
+	skipped []skippedKey
+}
+
+type skippedKey struct {
+	hk []byte
+	n  uint32
+	mk []byte
+}
+
+type header struct {
+	dh *ecdh.PublicKey
+	pn uint32
+	n  uint32
+}
+
+// Decrypt decrypts and verifies the message produced by the remote party's Encrypt with the same
+// associated data. The state is left unchanged on failure.
+func (s *Session) Decrypt(message, ad []byte) ([]byte, error) {
+	if len(message) < encryptedHeaderLen+TagLen {
+		return nil, ErrInvalidMessage
+	}
+	encHeader, body := message[:encryptedHeaderLen], message[encryptedHeaderLen:]
+	ad = append(append([]byte{}, ad...), encHeader...)
+
+	// work on a copy, which is committed only on success
+	st := s.st.clone()
+
+	if plaintext, ok, err := st.trySkippedMessageKeys(encHeader, body, ad); err != nil {
+		return nil, err
+	} else if ok {
+		s.st = st
+		return plaintext, nil
+	}
+
+	h, dhRatchet, err := st.decryptHeader(encHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	if dhRatchet {
+		if err := st.skipMessageKeys(h.pn); err != nil {
+			return nil, err
+		}
+		if err := st.dhRatchet(h, s.rand); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := st.skipMessageKeys(h.n); err != nil {
+		return nil, err
+	}
+
+	var mk []byte
+	if st.ckr, mk, err = kdfCK(st.ckr); err != nil {
+		return nil, err
+	}
+	st.nr++
+
+	plaintext, err := open(protoMessage, mk, ad, body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.st = st
+	return plaintext, nil
+}
+
+// Encrypt encrypts the plaintext bound to the associated data, and returns the message consisting
+// of the encrypted header followed by the ciphertext.
+//
+// The responder can't encrypt before decrypting the first message from the initiator.
+func (s *Session) Encrypt(plaintext, ad []byte) ([]byte, error) {
+	if s.st.cks == nil {
+		return nil, ErrNoSendingChain
+	}
+
+	cks, mk, err := kdfCK(s.st.cks)
+	if err != nil {
+		return nil, err
+	}
+
+	h := header{dh: s.st.dhs.PublicKey(), pn: s.st.pn, n: s.st.ns}
+
+	nonce := make([]byte, NonceLen)
+	if _, err := io.ReadFull(s.rand, nonce); err != nil {
+		return nil, err
+	}
+
+	encHeader, err := seal(protoHeader, s.st.hks, nonce, h.marshal())
+	if err != nil {
+		return nil, err
+	}
+	encHeader = append(nonce, encHeader...)
+
+	body, err := seal(protoMessage, mk, append(append([]byte{}, ad...), encHeader...), plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	s.st.cks = cks
+	s.st.ns++
+
+	return append(encHeader, body...), nil
+}
+
+// NewInitiator initializes the session of the party sending the first message, i.e. Alice, with
+// the shared secret, the shared header keys and the responder's ratchet public key. A nil rand
+// means crypto/rand.Reader.
+func NewInitiator(sk, sharedHKA, sharedNHKB []byte, responderPub *ecdh.PublicKey,
+	rand io.Reader) (*Session, error) {
+	if err := checkKeys(sk, sharedHKA, sharedNHKB); err != nil {
+		return nil, err
+	}
+
+	out := newSession(rand)
+
+	var err error
+	if out.st.dhs, err = ecdh.X25519().GenerateKey(out.rand); err != nil {
+		return nil, err
+	}
+	out.st.dhr = responderPub
+
+	dhOut, err := out.st.dhs.ECDH(responderPub)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.st.rk, out.st.cks, out.st.nhks, err = kdfRK(sk, dhOut); err != nil {
+		return nil, err
+	}
+	out.st.hks = append([]byte{}, sharedHKA...)
+	out.st.nhkr = append([]byte{}, sharedNHKB...)
+
+	return out, nil
+}
+
+// NewResponder initializes the session of the party receiving the first message, i.e. Bob, with
+// the shared secret, the shared header keys and the ratchet key pair whose public key is known to
+// the initiator. A nil rand means crypto/rand.Reader.
+func NewResponder(sk, sharedHKA, sharedNHKB []byte, key *ecdh.PrivateKey,
+	rand io.Reader) (*Session, error) {
+	if err := checkKeys(sk, sharedHKA, sharedNHKB); err != nil {
+		return nil, err
+	}
+
+	out := newSession(rand)
+	out.st.dhs = key
+	out.st.rk = append([]byte{}, sk...)
+	out.st.nhks = append([]byte{}, sharedNHKB...)
+	out.st.nhkr = append([]byte{}, sharedHKA...)
+
+	return out, nil
+}
+
+func (st *state) clone() state {
+	out := *st
+	out.skipped = append([]skippedKey{}, st.skipped...)
+	return out
+}
+
+// decryptHeader decrypts the header with the current receiving header key, or the next one which
+// means a DH ratchet step.
+func (st *state) decryptHeader(encHeader []byte) (*header, bool, error) {
+	for i, hk := range [][]byte{st.hkr, st.nhkr} {
+		if hk == nil {
+			continue
+		}
+
+		if h, err := openHeader(hk, encHeader); err == nil {
+			return h, i == 1, nil
+		}
+	}
+
+	return nil, false, ErrInvalidMessage
+}
+
+func (st *state) dhRatchet(h *header, rand io.Reader) error {
+	st.pn, st.ns, st.nr = st.ns, 0, 0
+	st.hks, st.hkr = st.nhks, st.nhkr
+	st.dhr = h.dh
+
+	dhOut, err := st.dhs.ECDH(st.dhr)
+	if err != nil {
+		return err
+	}
+	if st.rk, st.ckr, st.nhkr, err = kdfRK(st.rk, dhOut); err != nil {
+		return err
+	}
+
+	if st.dhs, err = ecdh.X25519().GenerateKey(rand); err != nil {
+		return err
+	}
+
+	if dhOut, err = st.dhs.ECDH(st.dhr); err != nil {
+		return err
+	}
+	st.rk, st.cks, st.nhks, err = kdfRK(st.rk, dhOut)
+
+	return err
+}
+
+// skipMessageKeys caches the message keys of the receiving chain up to the given number.
+func (st *state) skipMessageKeys(until uint32) error {
+	if uint64(st.nr)+MaxSkip < uint64(until) {
+		return ErrTooManySkipped
+	}
+
+	if st.ckr == nil {
+		return nil
+	}
+
+	for st.nr < until {
+		var (
+			mk  []byte
+			err error
+		)
+		if st.ckr, mk, err = kdfCK(st.ckr); err != nil {
+			return err
+		}
+
+		st.skipped = append(st.skipped, skippedKey{hk: st.hkr, n: st.nr, mk: mk})
+		st.nr++
+	}
+
+	if n := len(st.skipped) - MaxSkippedKeys; n > 0 {
+		st.skipped = append([]skippedKey{}, st.skipped[n:]...)
+	}
+
+	return nil
+}
+
+// trySkippedMessageKeys decrypts the message with a cached message key if any matches.
+func (st *state) trySkippedMessageKeys(encHeader, body, ad []byte) ([]byte, bool, error) {
+	for i, v := range st.skipped {
+		h, err := openHeader(v.hk, encHeader)
+		if err != nil || h.n != v.n {
+			continue
+		}
+
+		plaintext, err := open(protoMessage, v.mk, ad, body)
+		if err != nil {
+			return nil, false, err
+		}
+
+		st.skipped = append(st.skipped[:i:i], st.skipped[i+1:]...)
+		return plaintext, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (h *header) marshal() []byte {
+	out := append([]byte{}, h.dh.Bytes()...)
+	out = binary.LittleEndian.AppendUint32(out, h.pn)
+	out = binary.LittleEndian.AppendUint32(out, h.n)
+
+	return out
+}
+
+func checkKeys(keys ...[]byte) error {
+	for _, v := range keys {
+		if len(v) != KeyLen {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
+
+func newSession(r io.Reader) *Session {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	return &Session{rand: r}
+}
+
+func openHeader(hk, encHeader []byte) (*header, error) {
+	nonce := encHeader[:NonceLen]
+
+	raw, err := open(protoHeader, hk, nonce, encHeader[NonceLen:])
+	if err != nil {
+		return nil, err
+	}
+
+	dh, err := ecdh.X25519().NewPublicKey(raw[:32])
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+
+	return &header{
+		dh: dh,
+		pn: binary.LittleEndian.Uint32(raw[32:]),
+		n:  binary.LittleEndian.Uint32(raw[36:]),
+	}, nil
+}
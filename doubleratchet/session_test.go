@@ -0,0 +1,193 @@
+package doubleratchet_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/doubleratchet"
+)
+
+var ad = []byte("associated data")
+
+func TestSession(t *testing.T) {
+	alice, bob := mustNewSessionPair(t)
+
+	if _, err := bob.Encrypt([]byte("too early"), ad); err != doubleratchet.ErrNoSendingChain {
+		t.Fatalf("invalid error: expect %v, got %v", doubleratchet.ErrNoSendingChain, err)
+	}
+
+	// ping-pong with several messages per turn, so as to run both the symmetric and DH ratchets
+	for round := 0; round < 4; round++ {
+		sender, receiver := alice, bob
+		if round%2 == 1 {
+			sender, receiver = bob, alice
+		}
+
+		for i := 0; i < 3; i++ {
+			mustTransfer(t, sender, receiver, fmt.Sprintf("round %d message %d", round, i))
+		}
+	}
+}
+
+func TestSession_OutOfOrder(t *testing.T) {
+	alice, bob := mustNewSessionPair(t)
+
+	var msgs [][]byte
+	for i := 0; i < 5; i++ {
+		msgs = append(msgs, mustEncrypt(t, alice, fmt.Sprintf("message %d", i)))
+	}
+
+	// bob replies in between, which ratchets alice's chains
+	mustTransfer(t, alice, bob, "flush")
+	mustTransfer(t, bob, alice, "reply")
+	late := mustEncrypt(t, alice, "after reply")
+
+	for _, i := range []int{3, 0, 4, 2, 1} {
+		mustDecrypt(t, bob, msgs[i], fmt.Sprintf("message %d", i))
+	}
+	mustDecrypt(t, bob, late, "after reply")
+
+	// replays fail since the skipped keys are deleted once used
+	if _, err := bob.Decrypt(msgs[0], ad); err != doubleratchet.ErrInvalidMessage {
+		t.Fatalf("invalid error for replay: expect %v, got %v", doubleratchet.ErrInvalidMessage, err)
+	}
+}
+
+func TestSession_TooManySkipped(t *testing.T) {
+	alice, bob := mustNewSessionPair(t)
+
+	for i := 0; i <= doubleratchet.MaxSkip; i++ {
+		mustEncrypt(t, alice, "dropped")
+	}
+	msg := mustEncrypt(t, alice, "too far")
+
+	if _, err := bob.Decrypt(msg, ad); err != doubleratchet.ErrTooManySkipped {
+		t.Fatalf("invalid error: expect %v, got %v", doubleratchet.ErrTooManySkipped, err)
+	}
+}
+
+func TestSession_Tampered(t *testing.T) {
+	alice, bob := mustNewSessionPair(t)
+
+	msg := mustEncrypt(t, alice, "hello")
+
+	testVector := []struct {
+		name   string
+		msg    []byte
+		ad     []byte
+		expect error
+	}{
+		{"flipped header", flip(msg, 20), ad, doubleratchet.ErrInvalidMessage},
+		{"flipped body", flip(msg, len(msg)-1), ad, strobe.ErrAuthenticationFailed},
+		{"other ad", msg, []byte("another ad"), strobe.ErrAuthenticationFailed},
+		{"truncated", msg[:40], ad, doubleratchet.ErrInvalidMessage},
+	}
+
+	for _, c := range testVector {
+		if _, err := bob.Decrypt(c.msg, c.ad); err != c.expect {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, c.expect, err)
+		}
+	}
+
+	// failures leave the state unchanged
+	mustDecrypt(t, bob, msg, "hello")
+}
+
+func TestSession_MarshalJSON(t *testing.T) {
+	alice, bob := mustNewSessionPair(t)
+
+	mustTransfer(t, alice, bob, "hello")
+	mustTransfer(t, bob, alice, "hi")
+	skipped := mustEncrypt(t, alice, "skipped")
+	mustTransfer(t, alice, bob, "how are you")
+
+	restore := func(s *doubleratchet.Session) *doubleratchet.Session {
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("fail to marshal: %v", err)
+		}
+
+		var out doubleratchet.Session
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("fail to unmarshal: %v", err)
+		}
+
+		return &out
+	}
+
+	alice, bob = restore(alice), restore(bob)
+
+	mustDecrypt(t, bob, skipped, "skipped")
+	mustTransfer(t, bob, alice, "fine")
+	mustTransfer(t, alice, bob, "great")
+}
+
+func flip(b []byte, i int) []byte {
+	out := append([]byte{}, b...)
+	out[i] ^= 1
+	return out
+}
+
+func mustDecrypt(t *testing.T, receiver *doubleratchet.Session, msg []byte, expect string) {
+	got, err := receiver.Decrypt(msg, ad)
+	if err != nil {
+		t.Fatalf("fail to decrypt %q: %v", expect, err)
+	} else if string(got) != expect {
+		t.Fatalf("invalid plaintext: expect %q, got %q", expect, got)
+	}
+}
+
+func mustEncrypt(t *testing.T, sender *doubleratchet.Session, msg string) []byte {
+	out, err := sender.Encrypt([]byte(msg), ad)
+	if err != nil {
+		t.Fatalf("fail to encrypt %q: %v", msg, err)
+	}
+
+	return out
+}
+
+func mustNewSessionPair(t *testing.T) (*doubleratchet.Session, *doubleratchet.Session) {
+	sk := bytes.Repeat([]byte{0x01}, doubleratchet.KeyLen)
+	hka := bytes.Repeat([]byte{0x02}, doubleratchet.KeyLen)
+	nhkb := bytes.Repeat([]byte{0x03}, doubleratchet.KeyLen)
+
+	bobKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	alice, err := doubleratchet.NewInitiator(sk, hka, nhkb, bobKey.PublicKey(), nil)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	bob, err := doubleratchet.NewResponder(sk, hka, nhkb, bobKey, nil)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return alice, bob
+}
+
+func mustTransfer(t *testing.T, sender, receiver *doubleratchet.Session, msg string) {
+	mustDecrypt(t, receiver, mustEncrypt(t, sender, msg), msg)
+}
+
+func TestNewInitiator_InvalidKey(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	short := make([]byte, doubleratchet.KeyLen-1)
+	full := make([]byte, doubleratchet.KeyLen)
+
+	if _, err := doubleratchet.NewInitiator(short, full, full, key.PublicKey(), nil); err != doubleratchet.ErrInvalidKey {
+		t.Fatalf("invalid error: expect %v, got %v", doubleratchet.ErrInvalidKey, err)
+	}
+}
@@ -5,7 +5,16 @@ import "errors"
 var (
 	// ErrAuthenticationFailed is the error returned by RecvMAC when MAC is invalid
 	ErrAuthenticationFailed = errors.New("authentication failed")
+	// ErrInvalidMessage is the error returned by RecvMessage when the framing of the message is
+	// malformed
+	ErrInvalidMessage = errors.New("invalid message")
 	// ErrInvalidSecurityLevel is the error returned by New when the specified security level is
 	// unsupported
 	ErrInvalidSecurityLevel = errors.New("only 128 or 256 bit security is supported")
+	// ErrMessageTooLong is the error returned by RecvMessage when the payload exceeds the maximum
+	// length, or by SendMessage when the payload length overflows uint32
+	ErrMessageTooLong = errors.New("message too long")
+	// ErrStreamFinalized is the error returned by SendStream.Final and RecvStream.Final when the
+	// stream is already finalized
+	ErrStreamFinalized = errors.New("stream finalized")
 )
@@ -0,0 +1,95 @@
+package strobe_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sammyne/strobe"
+)
+
+func TestStrobe_SendMessage(t *testing.T) {
+	alice := mustNewStrobe(t, "framing", strobe.Bit128)
+	bob := mustNewStrobe(t, "framing", strobe.Bit128)
+
+	mustKey := func(s *strobe.Strobe) {
+		if err := s.KEY([]byte("secret key"), false); err != nil {
+			t.Fatalf("fail to KEY: %v", err)
+		}
+	}
+	mustKey(alice)
+	mustKey(bob)
+
+	testVector := []struct {
+		sender, receiver *strobe.Strobe
+		label            string
+		payload          []byte
+	}{
+		{alice, bob, "hello", []byte("hello bob")},
+		{alice, bob, "empty", nil},
+		{bob, alice, "reply", []byte("hi alice")},
+		{alice, bob, "bulk", bytes.Repeat([]byte{0xab}, 1000)},
+	}
+
+	for i, c := range testVector {
+		msg, err := c.sender.SendMessage(c.label, c.payload)
+		if err != nil {
+			t.Fatalf("#%d fail to send: %v", i, err)
+		} else if len(msg) != strobe.MessageHeaderLen+len(c.payload)+strobe.MessageMACLen {
+			t.Fatalf("#%d invalid message length: expect %d, got %d", i,
+				strobe.MessageHeaderLen+len(c.payload)+strobe.MessageMACLen, len(msg))
+		}
+
+		got, err := c.receiver.RecvMessage(c.label, msg, len(c.payload))
+		if err != nil {
+			t.Fatalf("#%d fail to recv: %v", i, err)
+		} else if !bytes.Equal(c.payload, got) {
+			t.Fatalf("#%d invalid payload: expect %x, got %x", i, c.payload, got)
+		}
+	}
+}
+
+func TestStrobe_RecvMessage_Errors(t *testing.T) {
+	sender := mustNewStrobe(t, "framing", strobe.Bit128)
+	msg, err := sender.SendMessage("label", []byte("hello world"))
+	if err != nil {
+		t.Fatalf("fail to send: %v", err)
+	}
+
+	flip := func(i int) []byte {
+		out := append([]byte{}, msg...)
+		out[i] ^= 1
+		return out
+	}
+
+	testVector := []struct {
+		label  string
+		msg    []byte
+		maxLen int
+		expect error
+	}{
+		{"label", msg, 10, strobe.ErrMessageTooLong},
+		{"label", msg, -1, strobe.ErrMessageTooLong},
+		{"label", msg[:len(msg)-1], 100, strobe.ErrInvalidMessage},
+		{"label", msg[:strobe.MessageHeaderLen+strobe.MessageMACLen-1], 100, strobe.ErrInvalidMessage},
+		{"label", flip(0), 100, strobe.ErrInvalidMessage},
+		{"other label", msg, 100, strobe.ErrAuthenticationFailed},
+		{"label", flip(strobe.MessageHeaderLen), 100, strobe.ErrAuthenticationFailed},
+		{"label", flip(len(msg) - 1), 100, strobe.ErrAuthenticationFailed},
+	}
+
+	for i, c := range testVector {
+		receiver := mustNewStrobe(t, "framing", strobe.Bit128)
+
+		if _, err := receiver.RecvMessage(c.label, c.msg, c.maxLen); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+
+		// rejections before the MAC leave the state intact
+		if c.expect == strobe.ErrAuthenticationFailed {
+			continue
+		}
+		if _, err := receiver.RecvMessage("label", msg, 100); err != nil {
+			t.Fatalf("#%d fail to recv after rejection: %v", i, err)
+		}
+	}
+}
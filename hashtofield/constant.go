@@ -0,0 +1,19 @@
+package hashtofield
+
+const (
+	// MaxCount is the maximum number of elements derived by a single HashToField, which bounds the
+	// PRF output and fits the 32-bit count absorbed for domain separation.
+	MaxCount = 1 << 16
+	// MaxRejections is the maximum number of candidates drawn by rejection sampling, each of
+	// which is rejected with probability less than 1/2.
+	MaxRejections = 128
+	// SecurityMargin is the number of extra bits squeezed by wide reduction beyond the size of the
+	// modulus, which bounds the statistical bias by 2^-SecurityMargin.
+	SecurityMargin = 128
+)
+
+// Methods mapping PRF output to field elements, which are absorbed for domain separation.
+const (
+	methodRejection = "rejection"
+	methodWide      = "wide"
+)
@@ -0,0 +1,70 @@
+package hashtofield
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/sammyne/strobe"
+)
+
+// Curve describes the scalar field of an elliptic curve, i.e. the integers modulo the order of its
+// prime-order group, along with the encoding of scalars.
+type Curve struct {
+	// Name is the name of the curve.
+	Name string
+	// Order is the order of the prime-order group, which must not be modified.
+	Order *big.Int
+	// ScalarSize is the size of encoded scalars in bytes.
+	ScalarSize int
+	// LittleEndian specifies whether scalars are encoded in little-endian, rather than big-endian.
+	LittleEndian bool
+}
+
+// Curves of which scalars are commonly derived.
+var (
+	// P256 is NIST P-256, whose scalars are encoded in big-endian as by crypto/ecdsa.
+	P256 = &Curve{Name: "P-256", Order: elliptic.P256().Params().N, ScalarSize: 32}
+	// P384 is NIST P-384, whose scalars are encoded in big-endian as by crypto/ecdsa.
+	P384 = &Curve{Name: "P-384", Order: elliptic.P384().Params().N, ScalarSize: 48}
+	// Edwards25519 is the twisted Edwards curve birationally equivalent to Curve25519, whose
+	// scalars are encoded in little-endian as by RFC 8032.
+	Edwards25519 = &Curve{Name: "edwards25519", Order: edwards25519Order(), ScalarSize: 32,
+		LittleEndian: true}
+)
+
+// HashToScalar derives a scalar by wide reduction, encoded in ScalarSize bytes.
+func (c *Curve) HashToScalar(s *strobe.Strobe, label string) ([]byte, error) {
+	out, err := HashToScalar(s, label, c.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.encode(out), nil
+}
+
+// SampleScalar derives a scalar by rejection sampling, encoded in ScalarSize bytes.
+func (c *Curve) SampleScalar(s *strobe.Strobe, label string) ([]byte, error) {
+	out, err := SampleScalar(s, label, c.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.encode(out), nil
+}
+
+func (c *Curve) encode(x *big.Int) []byte {
+	out := x.FillBytes(make([]byte, c.ScalarSize))
+	if c.LittleEndian {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+
+	return out
+}
+
+// edwards25519Order returns 2^252 + 27742317777372353535851937790883648493.
+func edwards25519Order() *big.Int {
+	out, _ := new(big.Int).SetString("27742317777372353535851937790883648493", 10)
+	return out.Add(out, new(big.Int).Lsh(big.NewInt(1), 252))
+}
@@ -0,0 +1,14 @@
+package hashtofield
+
+import "errors"
+
+var (
+	// ErrInvalidCount is the error returned by HashToField when the count isn't positive or exceeds
+	// MaxCount.
+	ErrInvalidCount = errors.New("invalid count")
+	// ErrInvalidModulus is the error returned when the modulus is less than 2.
+	ErrInvalidModulus = errors.New("invalid modulus")
+	// ErrTooManyRejections is the error returned when rejection sampling draws MaxRejections
+	// candidates out of range, which happens with negligible probability.
+	ErrTooManyRejections = errors.New("too many rejections")
+)
@@ -0,0 +1,104 @@
+// Package hashtofield derives uniformly distributed field elements and scalars from a STROBE
+// transcript, for VRFs, PAKEs, signatures and the like.
+//
+// Each derivation absorbs the label and then a descriptor of the method, count and modulus as
+// meta-AD, before squeezing output from PRF, so that outputs of different labels, moduli or methods
+// are independent. Two methods are supported:
+//
+//   - wide reduction, which reduces SecurityMargin bits more than the size of the modulus, so the
+//     bias is negligible, and the PRF output has a fixed length;
+//   - rejection sampling, which draws candidates of the size of the modulus until one is in range,
+//     so there is no bias at all, at the cost of variable-length PRF output.
+//
+// All functions advance the transcript, which should be cloned beforehand if it is to be kept
+// unchanged.
+package hashtofield
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/sammyne/strobe"
+)
+
+// HashToField derives count elements of the field of integers modulo modulus by wide reduction.
+func HashToField(s *strobe.Strobe, label string, modulus *big.Int, count int) ([]*big.Int, error) {
+	if modulus.Cmp(big.NewInt(1)) <= 0 {
+		return nil, ErrInvalidModulus
+	} else if count <= 0 || count > MaxCount {
+		return nil, ErrInvalidCount
+	}
+
+	if err := absorbDomain(s, label, methodWide, modulus, count); err != nil {
+		return nil, err
+	}
+
+	l := (modulus.BitLen() + SecurityMargin + 7) / 8
+	wide := make([]byte, count*l)
+	if err := s.PRF(wide, false); err != nil {
+		return nil, err
+	}
+
+	out := make([]*big.Int, count)
+	for i := range out {
+		out[i] = new(big.Int).SetBytes(wide[i*l : (i+1)*l])
+		out[i].Mod(out[i], modulus)
+	}
+
+	return out, nil
+}
+
+// HashToScalar derives an integer in [0, modulus) by wide reduction.
+func HashToScalar(s *strobe.Strobe, label string, modulus *big.Int) (*big.Int, error) {
+	out, err := HashToField(s, label, modulus, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return out[0], nil
+}
+
+// SampleScalar derives an integer in [0, modulus) by rejection sampling.
+func SampleScalar(s *strobe.Strobe, label string, modulus *big.Int) (*big.Int, error) {
+	if modulus.Cmp(big.NewInt(1)) <= 0 {
+		return nil, ErrInvalidModulus
+	}
+
+	if err := absorbDomain(s, label, methodRejection, modulus, 1); err != nil {
+		return nil, err
+	}
+
+	bitLen := modulus.BitLen()
+	candidate := make([]byte, (bitLen+7)/8)
+	// masks out the bits of the leading byte beyond the modulus, so each candidate is accepted with
+	// probability more than 1/2
+	mask := byte(0xff >> (8*len(candidate) - bitLen))
+
+	out := new(big.Int)
+	for i := 0; i < MaxRejections; i++ {
+		// later candidates continue the output of the same PRF
+		if err := s.PRF(candidate, i > 0); err != nil {
+			return nil, err
+		}
+		candidate[0] &= mask
+
+		if out.SetBytes(candidate).Cmp(modulus) < 0 {
+			return out, nil
+		}
+	}
+
+	return nil, ErrTooManyRejections
+}
+
+// absorbDomain absorbs the label, followed by the method, count and modulus, as meta-AD.
+func absorbDomain(s *strobe.Strobe, label, method string, modulus *big.Int, count int) error {
+	if err := s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	domain := append([]byte(method), 0)
+	domain = binary.LittleEndian.AppendUint32(domain, uint32(count))
+	domain = append(domain, modulus.Bytes()...)
+
+	return s.AD(domain, &strobe.Options{Meta: true})
+}
@@ -0,0 +1,236 @@
+package hashtofield_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/hashtofield"
+)
+
+func TestHashToField(t *testing.T) {
+	modulus := hashtofield.P256.Order
+
+	got, err := hashtofield.HashToField(mustTranscript(t), "label", modulus, 3)
+	if err != nil {
+		t.Fatalf("fail to hash to field: %v", err)
+	} else if len(got) != 3 {
+		t.Fatalf("invalid #(elements): expect 3, got %d", len(got))
+	}
+
+	for i, v := range got {
+		if v.Sign() < 0 || v.Cmp(modulus) >= 0 {
+			t.Fatalf("#%d element out of range: %v", i, v)
+		}
+	}
+
+	// the count is bound to the output
+	first, err := hashtofield.HashToScalar(mustTranscript(t), "label", modulus)
+	if err != nil {
+		t.Fatalf("fail to hash to scalar: %v", err)
+	} else if first.Cmp(got[0]) == 0 {
+		t.Fatal("outputs of different counts collide")
+	}
+}
+
+func TestHashToScalar_DomainSeparation(t *testing.T) {
+	p256, p384 := hashtofield.P256.Order, hashtofield.P384.Order
+
+	hash := func(label string, modulus *big.Int, rejection bool) *big.Int {
+		var (
+			out *big.Int
+			err error
+		)
+		if rejection {
+			out, err = hashtofield.SampleScalar(mustTranscript(t), label, modulus)
+		} else {
+			out, err = hashtofield.HashToScalar(mustTranscript(t), label, modulus)
+		}
+		if err != nil {
+			t.Fatalf("fail to derive scalar: %v", err)
+		}
+
+		return out
+	}
+
+	expect := hash("label", p384, false)
+	if got := hash("label", p384, false); expect.Cmp(got) != 0 {
+		t.Fatalf("non-deterministic scalar: expect %v, got %v", expect, got)
+	}
+
+	testVector := []*big.Int{
+		hash("label2", p384, false),
+		hash("label", p384, true),
+		hash("label", p256, false),
+		hash("label", p256, true),
+	}
+
+	for i, c := range testVector {
+		if expect.Cmp(c) == 0 {
+			t.Fatalf("#%d scalar collides", i)
+		}
+	}
+}
+
+func TestSampleScalar(t *testing.T) {
+	// candidates are rejected with probability about 1/2, and the output should be uniform
+	const samples = 3000
+	modulus := big.NewInt(129)
+
+	s := mustTranscript(t)
+	var low int
+	for i := 0; i < samples; i++ {
+		v, err := hashtofield.SampleScalar(s, "label", modulus)
+		if err != nil {
+			t.Fatalf("#%d fail to sample: %v", i, err)
+		} else if v.Sign() < 0 || v.Cmp(modulus) >= 0 {
+			t.Fatalf("#%d scalar out of range: %v", i, v)
+		}
+
+		if v.Int64() < 64 {
+			low++
+		}
+	}
+
+	// 64/129 of samples are expected to be low, i.e. 1488 ± 4.5 standard deviations
+	if low < 1365 || low > 1612 {
+		t.Fatalf("biased samples: %d of %d below 64", low, samples)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	s := mustTranscript(t)
+
+	for i, c := range []*big.Int{big.NewInt(-7), big.NewInt(0), big.NewInt(1)} {
+		if _, err := hashtofield.HashToScalar(s, "label", c); err != hashtofield.ErrInvalidModulus {
+			t.Fatalf("#%d invalid error of HashToScalar: expect %v, got %v", i,
+				hashtofield.ErrInvalidModulus, err)
+		}
+
+		if _, err := hashtofield.SampleScalar(s, "label", c); err != hashtofield.ErrInvalidModulus {
+			t.Fatalf("#%d invalid error of SampleScalar: expect %v, got %v", i,
+				hashtofield.ErrInvalidModulus, err)
+		}
+	}
+
+	for i, c := range []int{-1, 0, hashtofield.MaxCount + 1} {
+		if _, err := hashtofield.HashToField(s, "label", big.NewInt(7), c); err != hashtofield.ErrInvalidCount {
+			t.Fatalf("#%d invalid error of HashToField: expect %v, got %v", i, hashtofield.ErrInvalidCount, err)
+		}
+	}
+
+	if _, err := hashtofield.HashToField(s, "label", big.NewInt(7), hashtofield.MaxCount); err != nil {
+		t.Fatalf("fail to hash MaxCount elements: %v", err)
+	}
+}
+
+func TestCurve(t *testing.T) {
+	order, _ := hex.DecodeString("1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed")
+	if !bytes.Equal(order, hashtofield.Edwards25519.Order.Bytes()) {
+		t.Fatalf("invalid order of edwards25519: expect %x, got %x", order,
+			hashtofield.Edwards25519.Order.Bytes())
+	}
+
+	curves := []*hashtofield.Curve{hashtofield.P256, hashtofield.P384, hashtofield.Edwards25519}
+	for _, c := range curves {
+		wide, err := c.HashToScalar(mustTranscript(t), "label")
+		if err != nil {
+			t.Fatalf("%s: fail to hash to scalar: %v", c.Name, err)
+		}
+
+		x, err := c.SampleScalar(mustTranscript(t), "label")
+		if err != nil {
+			t.Fatalf("%s: fail to sample scalar: %v", c.Name, err)
+		}
+
+		for i, v := range [][]byte{wide, x} {
+			if len(v) != c.ScalarSize {
+				t.Fatalf("%s: #%d invalid scalar size: expect %d, got %d", c.Name, i, c.ScalarSize, len(v))
+			} else if decode(c, v).Cmp(c.Order) >= 0 {
+				t.Fatalf("%s: #%d scalar out of range: %x", c.Name, i, v)
+			}
+		}
+	}
+}
+
+// TestCurve_Vectors checks scalars produced by this implementation, so as to pin down the
+// derivation.
+func TestCurve_Vectors(t *testing.T) {
+	type TestCase struct {
+		Curve  string
+		Method string
+		Label  string
+		Scalar []byte
+	}
+
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []TestCase
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	curves := map[string]*hashtofield.Curve{}
+	for _, v := range []*hashtofield.Curve{hashtofield.P256, hashtofield.P384, hashtofield.Edwards25519} {
+		curves[v.Name] = v
+	}
+
+	for i, c := range testVector {
+		curve, ok := curves[c.Curve]
+		if !ok {
+			t.Fatalf("#%d unknown curve: %s", i, c.Curve)
+		}
+
+		s := mustTranscript(t)
+
+		var (
+			got []byte
+			err error
+		)
+		switch c.Method {
+		case "wide":
+			got, err = curve.HashToScalar(s, c.Label)
+		case "rejection":
+			got, err = curve.SampleScalar(s, c.Label)
+		default:
+			t.Fatalf("#%d unknown method: %s", i, c.Method)
+		}
+
+		if err != nil {
+			t.Fatalf("#%d fail to derive scalar: %v", i, err)
+		} else if !bytes.Equal(c.Scalar, got) {
+			t.Fatalf("#%d invalid scalar: expect %x, got %x", i, c.Scalar, got)
+		}
+	}
+}
+
+func decode(c *hashtofield.Curve, scalar []byte) *big.Int {
+	b := append([]byte{}, scalar...)
+	if c.LittleEndian {
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+
+	return new(big.Int).SetBytes(b)
+}
+
+func mustTranscript(t *testing.T) *strobe.Strobe {
+	s, err := strobe.New("hashtofield test", strobe.Bit128)
+	if err != nil {
+		t.Fatalf("fail to new STROBE: %v", err)
+	}
+
+	if err := s.AD([]byte("transcript"), &strobe.Options{}); err != nil {
+		t.Fatalf("fail to absorb transcript: %v", err)
+	}
+
+	return s
+}
@@ -0,0 +1,55 @@
+package hpke
+
+// Proto is the STROBE proto of the key schedule.
+const Proto = "github.com/sammyne/strobe/hpke"
+
+const (
+	// MaxExportLen is the maximum length of exported secrets in bytes, which is 255*Nh as by
+	// RFC 9180 with the Nh = 32 of HKDF-SHA256.
+	MaxExportLen = 255 * 32
+	// MinPSKLen is the minimum length of pre-shared keys in bytes.
+	MinPSKLen = 32
+	// TagLen is the length of the MAC appended to ciphertexts in bytes.
+	TagLen = 16
+)
+
+// Mode is the mode of the scheme, which specifies how the sender is authenticated.
+type Mode uint8
+
+// Modes as specified by RFC 9180.
+const (
+	// ModeBase authenticates no sender.
+	ModeBase Mode = 0x00
+	// ModePSK authenticates the sender by possession of a pre-shared key.
+	ModePSK Mode = 0x01
+	// ModeAuth authenticates the sender by possession of a static private key.
+	ModeAuth Mode = 0x02
+	// ModeAuthPSK combines ModePSK and ModeAuth.
+	ModeAuthPSK Mode = 0x03
+)
+
+// KEM identifies the DH-based KEM, with the values of RFC 9180.
+type KEM uint16
+
+const (
+	// KEMP256 is the DHKEM over P-256.
+	KEMP256 KEM = 0x0010
+	// KEMX25519 is the DHKEM over X25519.
+	KEMX25519 KEM = 0x0020
+)
+
+// Labels framing the operations of the key schedule.
+const (
+	labelAAD       = "aad"
+	labelDH        = "dh"
+	labelEnc       = "enc"
+	labelExport    = "export"
+	labelInfo      = "info"
+	labelKEM       = "kem"
+	labelMode      = "mode"
+	labelPSK       = "psk"
+	labelPSKID     = "psk id"
+	labelRecipient = "recipient"
+	labelSender    = "sender"
+	labelStaticDH  = "static dh"
+)
@@ -0,0 +1,24 @@
+package hpke
+
+import "errors"
+
+var (
+	// ErrCurveMismatch is the error returned when keys of the sender and recipient are on
+	// different curves.
+	ErrCurveMismatch = errors.New("curve mismatch")
+	// ErrInconsistentPSKInputs is the error returned when only one of the pre-shared key and its
+	// ID is given.
+	ErrInconsistentPSKInputs = errors.New("inconsistent psk inputs")
+	// ErrInvalidEncapsulation is the error returned when the encapsulated key isn't a valid public
+	// key.
+	ErrInvalidEncapsulation = errors.New("invalid encapsulated key")
+	// ErrInvalidExportLength is the error returned when the length of an exported secret is
+	// negative or exceeds MaxExportLen.
+	ErrInvalidExportLength = errors.New("invalid export length")
+	// ErrInvalidPSK is the error returned when the pre-shared key is shorter than MinPSKLen.
+	ErrInvalidPSK = errors.New("invalid pre-shared key")
+	// ErrMessageTooShort is the error returned when a ciphertext is shorter than TagLen.
+	ErrMessageTooShort = errors.New("message too short")
+	// ErrUnsupportedCurve is the error returned when a key isn't on X25519 or P-256.
+	ErrUnsupportedCurve = errors.New("unsupported curve")
+)
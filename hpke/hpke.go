@@ -0,0 +1,388 @@
+// Package hpke implements a hybrid public key encryption scheme in the style of RFC 9180 (HPKE),
+// where a DH-based KEM over X25519 or P-256 from crypto/ecdh establishes the shared secret, and a
+// STROBE transcript acts as both the KDF and the AEAD.
+//
+// The key schedule absorbs the KEM, mode, info, the encapsulated key, the public keys involved and
+// the PSK ID with AD, and the DH outputs and PSK with KEY. All four modes of RFC 9180 are
+// supported: ModeBase, ModePSK, ModeAuth and ModeAuthPSK, which are selected by the Options in
+// use.
+//
+// Each message is sealed as SendENC followed by a SendMAC of TagLen bytes, after absorbing the
+// additional data. Since the transcript carries the sequence of messages, a Receiver must open
+// messages in the order they are sealed. Exported secrets are derived from a fork of the
+// transcript taken right after the key schedule, so they don't depend on the messages.
+//
+// This package isn't interoperable with RFC 9180.
+package hpke
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Options selects the mode of the scheme and its inputs. Zero Options means ModeBase.
+type Options struct {
+	// PSK is the pre-shared key of at least MinPSKLen bytes for ModePSK and ModeAuthPSK, which
+	// must be given along with PSKID.
+	PSK []byte
+	// PSKID identifies the pre-shared key.
+	PSKID []byte
+	// SenderKey is the static private key of the sender for ModeAuth and ModeAuthPSK, used by
+	// NewSender and Seal.
+	SenderKey *ecdh.PrivateKey
+	// SenderPublicKey is the static public key of the sender for ModeAuth and ModeAuthPSK, used by
+	// NewReceiver and Open.
+	SenderPublicKey *ecdh.PublicKey
+	// EphemeralKey is the ephemeral key of the sender. It is generated from Rand if nil, and
+	// should be set only for testing.
+	EphemeralKey *ecdh.PrivateKey
+	// Rand is the source of randomness for ephemeral keys, which defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Sender seals messages to the recipient, which isn't safe for concurrent use.
+type Sender struct {
+	s        *strobe.Strobe
+	exporter *strobe.Strobe
+	mode     Mode
+}
+
+// Receiver opens messages from the sender in order, which isn't safe for concurrent use.
+type Receiver struct {
+	s        *strobe.Strobe
+	exporter *strobe.Strobe
+	mode     Mode
+}
+
+// keySchedule collects the inputs of the key schedule.
+type keySchedule struct {
+	mode     Mode
+	kem      KEM
+	info     []byte
+	enc      []byte
+	pkR      []byte
+	pkS      []byte
+	dh       []byte
+	staticDH []byte
+	psk      []byte
+	pskID    []byte
+}
+
+// NewReceiver sets up the context to open messages encapsulated by enc and encrypted with the
+// same info.
+func NewReceiver(skR *ecdh.PrivateKey, enc, info []byte, opts *Options) (*Receiver, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	kem, err := kemOf(skR.Curve())
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := modeOf(opts, opts.SenderPublicKey != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pkE, err := skR.Curve().NewPublicKey(enc)
+	if err != nil {
+		return nil, ErrInvalidEncapsulation
+	}
+
+	dh, err := skR.ECDH(pkE)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &keySchedule{mode: mode, kem: kem, info: info, enc: enc, pkR: skR.PublicKey().Bytes(), dh: dh,
+		psk: opts.PSK, pskID: opts.PSKID}
+
+	if opts.SenderPublicKey != nil {
+		if opts.SenderPublicKey.Curve() != skR.Curve() {
+			return nil, ErrCurveMismatch
+		}
+
+		ks.pkS = opts.SenderPublicKey.Bytes()
+		if ks.staticDH, err = skR.ECDH(opts.SenderPublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := ks.run()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Receiver{s: s, exporter: s.Clone(), mode: mode}, nil
+}
+
+// NewSender sets up the context to seal messages to pkR, returning the encapsulated key to send
+// along with the messages.
+func NewSender(pkR *ecdh.PublicKey, info []byte, opts *Options) (enc []byte, sender *Sender, err error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	kem, err := kemOf(pkR.Curve())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mode, err := modeOf(opts, opts.SenderKey != nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skE := opts.EphemeralKey
+	if skE == nil {
+		r := opts.Rand
+		if r == nil {
+			r = rand.Reader
+		}
+
+		if skE, err = pkR.Curve().GenerateKey(r); err != nil {
+			return nil, nil, err
+		}
+	} else if skE.Curve() != pkR.Curve() {
+		return nil, nil, ErrCurveMismatch
+	}
+
+	dh, err := skE.ECDH(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc = skE.PublicKey().Bytes()
+
+	ks := &keySchedule{mode: mode, kem: kem, info: info, enc: enc, pkR: pkR.Bytes(), dh: dh,
+		psk: opts.PSK, pskID: opts.PSKID}
+
+	if opts.SenderKey != nil {
+		if opts.SenderKey.Curve() != pkR.Curve() {
+			return nil, nil, ErrCurveMismatch
+		}
+
+		ks.pkS = opts.SenderKey.PublicKey().Bytes()
+		if ks.staticDH, err = opts.SenderKey.ECDH(pkR); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s, err := ks.run()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return enc, &Sender{s: s, exporter: s.Clone(), mode: mode}, nil
+}
+
+// Open decrypts a single message sealed by Seal.
+func Open(skR *ecdh.PrivateKey, enc, info, aad, ciphertext []byte, opts *Options) ([]byte, error) {
+	r, err := NewReceiver(skR, enc, info, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Open(aad, ciphertext)
+}
+
+// Seal encrypts a single message to pkR, returning the encapsulated key along with the
+// ciphertext.
+func Seal(pkR *ecdh.PublicKey, info, aad, plaintext []byte, opts *Options) (enc, ciphertext []byte, err error) {
+	enc, s, err := NewSender(pkR, info, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ciphertext, err = s.Seal(aad, plaintext); err != nil {
+		return nil, nil, err
+	}
+
+	return enc, ciphertext, nil
+}
+
+// Export derives a secret of length bytes bound to exporterContext, which agrees with that of
+// the Receiver. It fails with ErrInvalidExportLength if length is negative or exceeds MaxExportLen.
+func (s *Sender) Export(exporterContext []byte, length int) ([]byte, error) {
+	return export(s.exporter, exporterContext, length)
+}
+
+// Mode returns the mode of the scheme.
+func (s *Sender) Mode() Mode {
+	return s.mode
+}
+
+// Seal encrypts the next message with the additional data aad, which is authenticated but not
+// encrypted.
+func (s *Sender) Seal(aad, plaintext []byte) ([]byte, error) {
+	if err := absorb(s.s, labelAAD, aad); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.s.SendENC(append([]byte{}, plaintext...), &strobe.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	tag := make([]byte, TagLen)
+	if err := s.s.SendMAC(tag, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return append(ciphertext, tag...), nil
+}
+
+// Export derives a secret of length bytes bound to exporterContext, which agrees with that of
+// the Sender. It fails with ErrInvalidExportLength if length is negative or exceeds MaxExportLen.
+func (r *Receiver) Export(exporterContext []byte, length int) ([]byte, error) {
+	return export(r.exporter, exporterContext, length)
+}
+
+// Mode returns the mode of the scheme.
+func (r *Receiver) Mode() Mode {
+	return r.mode
+}
+
+// Open decrypts the next message sealed with the additional data aad. Failures leave the
+// Receiver intact, so that forgeries can't disrupt it.
+func (r *Receiver) Open(aad, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < TagLen {
+		return nil, ErrMessageTooShort
+	}
+	n := len(ciphertext) - TagLen
+
+	s := r.s.Clone()
+	if err := absorb(s, labelAAD, aad); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.RecvENC(append([]byte{}, ciphertext[:n]...), &strobe.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RecvMAC(append([]byte{}, ciphertext[n:]...), &strobe.Options{}); err != nil {
+		return nil, err
+	}
+	r.s = s
+
+	return plaintext, nil
+}
+
+func (ks *keySchedule) run() (*strobe.Strobe, error) {
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	public := []struct {
+		label string
+		data  []byte
+	}{
+		{labelKEM, binary.BigEndian.AppendUint16(nil, uint16(ks.kem))},
+		{labelMode, []byte{byte(ks.mode)}},
+		{labelInfo, ks.info},
+		{labelEnc, ks.enc},
+		{labelRecipient, ks.pkR},
+		{labelSender, ks.pkS},
+		{labelPSKID, ks.pskID},
+	}
+	for _, v := range public {
+		if err := absorb(s, v.label, v.data); err != nil {
+			return nil, err
+		}
+	}
+
+	secret := []struct {
+		label string
+		data  []byte
+	}{
+		{labelDH, ks.dh},
+		{labelStaticDH, ks.staticDH},
+		{labelPSK, ks.psk},
+	}
+	for _, v := range secret {
+		if len(v.data) == 0 {
+			continue
+		}
+
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.KEY(append([]byte{}, v.data...), false); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func absorb(s *strobe.Strobe, label string, data []byte) error {
+	if err := s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	return s.AD(data, &strobe.Options{})
+}
+
+// export forks the exporter, and binds the output to both the context and length, so that outputs
+// of different lengths aren't prefixes of each other.
+func export(exporter *strobe.Strobe, exporterContext []byte, length int) ([]byte, error) {
+	if length < 0 || uint64(length) > MaxExportLen {
+		return nil, ErrInvalidExportLength
+	}
+
+	s := exporter.Clone()
+
+	if err := absorb(s, labelExport, exporterContext); err != nil {
+		return nil, err
+	}
+	if err := s.AD(binary.LittleEndian.AppendUint32(nil, uint32(length)), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, length)
+	if err := s.PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func kemOf(curve ecdh.Curve) (KEM, error) {
+	switch curve {
+	case ecdh.P256():
+		return KEMP256, nil
+	case ecdh.X25519():
+		return KEMX25519, nil
+	default:
+		return 0, ErrUnsupportedCurve
+	}
+}
+
+// modeOf decides the mode by whether the PSK and sender's static key are given.
+func modeOf(opts *Options, auth bool) (Mode, error) {
+	if (len(opts.PSK) == 0) != (len(opts.PSKID) == 0) {
+		return 0, ErrInconsistentPSKInputs
+	}
+
+	psk := len(opts.PSK) != 0
+	if psk && len(opts.PSK) < MinPSKLen {
+		return 0, ErrInvalidPSK
+	}
+
+	switch {
+	case psk && auth:
+		return ModeAuthPSK, nil
+	case psk:
+		return ModePSK, nil
+	case auth:
+		return ModeAuth, nil
+	default:
+		return ModeBase, nil
+	}
+}
@@ -0,0 +1,292 @@
+package hpke_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/hpke"
+)
+
+type encryption struct {
+	AAD        []byte
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+type export struct {
+	Context []byte
+	Length  int
+	Value   []byte
+}
+
+type vector struct {
+	KEM          hpke.KEM
+	Mode         hpke.Mode
+	Info         []byte
+	RecipientKey []byte
+	SenderKey    []byte
+	EphemeralKey []byte
+	PSK          []byte
+	PSKID        []byte
+	Enc          []byte
+	Encryptions  []encryption
+	Exports      []export
+}
+
+func (v *vector) receiverOptions(t *testing.T) *hpke.Options {
+	opts := &hpke.Options{PSK: v.PSK, PSKID: v.PSKID}
+	if v.SenderKey != nil {
+		opts.SenderPublicKey = mustNewPrivateKey(t, curveOf(v.KEM), v.SenderKey).PublicKey()
+	}
+
+	return opts
+}
+
+func (v *vector) senderOptions(t *testing.T) *hpke.Options {
+	opts := &hpke.Options{PSK: v.PSK, PSKID: v.PSKID}
+	opts.EphemeralKey = mustNewPrivateKey(t, curveOf(v.KEM), v.EphemeralKey)
+	if v.SenderKey != nil {
+		opts.SenderKey = mustNewPrivateKey(t, curveOf(v.KEM), v.SenderKey)
+	}
+
+	return opts
+}
+
+func TestSeal(t *testing.T) {
+	for _, curve := range []ecdh.Curve{ecdh.X25519(), ecdh.P256()} {
+		skR := mustGenerateKey(t, curve)
+		skS := mustGenerateKey(t, curve)
+		psk := bytes.Repeat([]byte{0x42}, hpke.MinPSKLen)
+
+		testVector := []struct {
+			sender, receiver *hpke.Options
+			mode             hpke.Mode
+		}{
+			{nil, nil, hpke.ModeBase},
+			{&hpke.Options{PSK: psk, PSKID: []byte("id")}, &hpke.Options{PSK: psk, PSKID: []byte("id")}, hpke.ModePSK},
+			{&hpke.Options{SenderKey: skS}, &hpke.Options{SenderPublicKey: skS.PublicKey()}, hpke.ModeAuth},
+			{
+				&hpke.Options{PSK: psk, PSKID: []byte("id"), SenderKey: skS},
+				&hpke.Options{PSK: psk, PSKID: []byte("id"), SenderPublicKey: skS.PublicKey()},
+				hpke.ModeAuthPSK,
+			},
+		}
+
+		for i, c := range testVector {
+			info, aad, msg := []byte("info"), []byte("aad"), []byte("hello world")
+
+			enc, ciphertext, err := hpke.Seal(skR.PublicKey(), info, aad, msg, c.sender)
+			if err != nil {
+				t.Fatalf("#%d fail to seal: %v", i, err)
+			}
+
+			got, err := hpke.Open(skR, enc, info, aad, ciphertext, c.receiver)
+			if err != nil {
+				t.Fatalf("#%d fail to open: %v", i, err)
+			} else if !bytes.Equal(msg, got) {
+				t.Fatalf("#%d invalid plaintext: expect %s, got %s", i, msg, got)
+			}
+
+			// any other input of the key schedule fails to open
+			if _, err := hpke.Open(skR, enc, []byte("other info"), aad, ciphertext, c.receiver); err != strobe.ErrAuthenticationFailed {
+				t.Fatalf("#%d invalid error for other info: expect %v, got %v", i, strobe.ErrAuthenticationFailed, err)
+			}
+
+			for j, v := range testVector {
+				if j == i {
+					continue
+				}
+
+				if _, err := hpke.Open(skR, enc, info, aad, ciphertext, v.receiver); err != strobe.ErrAuthenticationFailed {
+					t.Fatalf("#%d invalid error for mode %d: expect %v, got %v", i, v.mode, strobe.ErrAuthenticationFailed, err)
+				}
+			}
+		}
+	}
+}
+
+func TestSender(t *testing.T) {
+	skR := mustGenerateKey(t, ecdh.X25519())
+
+	enc, s, err := hpke.NewSender(skR.PublicKey(), nil, nil)
+	if err != nil {
+		t.Fatalf("fail to new sender: %v", err)
+	}
+
+	r, err := hpke.NewReceiver(skR, enc, nil, nil)
+	if err != nil {
+		t.Fatalf("fail to new receiver: %v", err)
+	}
+
+	var ciphertexts [][]byte
+	for i := 0; i < 3; i++ {
+		ciphertext, err := s.Seal(nil, []byte{byte(i)})
+		if err != nil {
+			t.Fatalf("#%d fail to seal: %v", i, err)
+		}
+		ciphertexts = append(ciphertexts, ciphertext)
+	}
+
+	// messages must be opened in order
+	if _, err := r.Open(nil, ciphertexts[1]); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error for reordering: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+	if _, err := r.Open(nil, ciphertexts[0][:hpke.TagLen-1]); err != hpke.ErrMessageTooShort {
+		t.Fatalf("invalid error for short message: expect %v, got %v", hpke.ErrMessageTooShort, err)
+	}
+
+	// failures leave the receiver intact
+	for i, c := range ciphertexts {
+		got, err := r.Open(nil, c)
+		if err != nil {
+			t.Fatalf("#%d fail to open: %v", i, err)
+		} else if !bytes.Equal([]byte{byte(i)}, got) {
+			t.Fatalf("#%d invalid plaintext: expect %x, got %x", i, []byte{byte(i)}, got)
+		}
+	}
+
+	// exports don't depend on messages
+	exportS, err := s.Export([]byte("context"), 32)
+	if err != nil {
+		t.Fatalf("fail to export for sender: %v", err)
+	}
+	exportR, err := r.Export([]byte("context"), 32)
+	if err != nil {
+		t.Fatalf("fail to export for receiver: %v", err)
+	} else if !bytes.Equal(exportS, exportR) {
+		t.Fatalf("exports mismatch: %x != %x", exportS, exportR)
+	}
+
+	short, err := r.Export([]byte("context"), 16)
+	if err != nil {
+		t.Fatalf("fail to export short secret: %v", err)
+	} else if bytes.Equal(short, exportR[:16]) {
+		t.Fatal("exports of different lengths should be independent")
+	}
+
+	if long, err := s.Export([]byte("context"), hpke.MaxExportLen); err != nil || len(long) != hpke.MaxExportLen {
+		t.Fatalf("fail to export secret of the maximum length: %v", err)
+	}
+
+	for i, c := range []int{-1, -1 << 31, hpke.MaxExportLen + 1} {
+		if _, err := s.Export([]byte("context"), c); err != hpke.ErrInvalidExportLength {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, hpke.ErrInvalidExportLength, err)
+		}
+	}
+}
+
+func TestNewSender_Errors(t *testing.T) {
+	skR := mustGenerateKey(t, ecdh.X25519())
+	p384, err := ecdh.P384().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate P-384 key: %v", err)
+	}
+
+	testVector := []struct {
+		pkR    *ecdh.PublicKey
+		opts   *hpke.Options
+		expect error
+	}{
+		{p384.PublicKey(), nil, hpke.ErrUnsupportedCurve},
+		{skR.PublicKey(), &hpke.Options{PSK: make([]byte, hpke.MinPSKLen)}, hpke.ErrInconsistentPSKInputs},
+		{skR.PublicKey(), &hpke.Options{PSKID: []byte("id")}, hpke.ErrInconsistentPSKInputs},
+		{skR.PublicKey(), &hpke.Options{PSK: make([]byte, hpke.MinPSKLen-1), PSKID: []byte("id")}, hpke.ErrInvalidPSK},
+		{skR.PublicKey(), &hpke.Options{SenderKey: mustGenerateKey(t, ecdh.P256())}, hpke.ErrCurveMismatch},
+	}
+
+	for i, c := range testVector {
+		if _, _, err := hpke.NewSender(c.pkR, nil, c.opts); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+
+	if _, err := hpke.NewReceiver(mustGenerateKey(t, ecdh.P256()), make([]byte, 65), nil, nil); err != hpke.ErrInvalidEncapsulation {
+		t.Fatalf("invalid error: expect %v, got %v", hpke.ErrInvalidEncapsulation, err)
+	}
+}
+
+// TestVectors checks outputs produced by this implementation, so as to pin down the key schedule.
+func TestVectors(t *testing.T) {
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []vector
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, c := range testVector {
+		skR := mustNewPrivateKey(t, curveOf(c.KEM), c.RecipientKey)
+
+		enc, s, err := hpke.NewSender(skR.PublicKey(), c.Info, c.senderOptions(t))
+		if err != nil {
+			t.Fatalf("#%d fail to new sender: %v", i, err)
+		} else if !bytes.Equal(c.Enc, enc) {
+			t.Fatalf("#%d invalid enc: expect %x, got %x", i, c.Enc, enc)
+		} else if s.Mode() != c.Mode {
+			t.Fatalf("#%d invalid mode: expect %d, got %d", i, c.Mode, s.Mode())
+		}
+
+		r, err := hpke.NewReceiver(skR, c.Enc, c.Info, c.receiverOptions(t))
+		if err != nil {
+			t.Fatalf("#%d fail to new receiver: %v", i, err)
+		}
+
+		for j, e := range c.Encryptions {
+			ciphertext, err := s.Seal(e.AAD, e.Plaintext)
+			if err != nil {
+				t.Fatalf("#%d-%d fail to seal: %v", i, j, err)
+			} else if !bytes.Equal(e.Ciphertext, ciphertext) {
+				t.Fatalf("#%d-%d invalid ciphertext: expect %x, got %x", i, j, e.Ciphertext, ciphertext)
+			}
+
+			plaintext, err := r.Open(e.AAD, e.Ciphertext)
+			if err != nil {
+				t.Fatalf("#%d-%d fail to open: %v", i, j, err)
+			} else if !bytes.Equal(e.Plaintext, plaintext) {
+				t.Fatalf("#%d-%d invalid plaintext: expect %x, got %x", i, j, e.Plaintext, plaintext)
+			}
+		}
+
+		for j, e := range c.Exports {
+			got, err := r.Export(e.Context, e.Length)
+			if err != nil {
+				t.Fatalf("#%d-%d fail to export: %v", i, j, err)
+			} else if !bytes.Equal(e.Value, got) {
+				t.Fatalf("#%d-%d invalid export: expect %x, got %x", i, j, e.Value, got)
+			}
+		}
+	}
+}
+
+func curveOf(kem hpke.KEM) ecdh.Curve {
+	if kem == hpke.KEMP256 {
+		return ecdh.P256()
+	}
+	return ecdh.X25519()
+}
+
+func mustGenerateKey(t *testing.T, curve ecdh.Curve) *ecdh.PrivateKey {
+	key, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	return key
+}
+
+func mustNewPrivateKey(t *testing.T, curve ecdh.Curve, key []byte) *ecdh.PrivateKey {
+	out, err := curve.NewPrivateKey(key)
+	if err != nil {
+		t.Fatalf("fail to load private key: %v", err)
+	}
+
+	return out
+}
@@ -11,8 +11,9 @@ func (s *Strobe) beginOp(flags Flag) {
 		if s.i0 == Undecided {
 			s.i0 = Initiator << (flags & FlagI)
 		}
+		// a responder flips the I flag, so that both parties hash the same direction
 		if s.i0 == Responder {
-			flags &= 0xfe
+			flags ^= FlagI
 		}
 	}
 
@@ -0,0 +1,11 @@
+package p256
+
+const (
+	// PointSize is the size of compressed points in bytes.
+	PointSize = 33
+	// ScalarSize is the size of canonical scalars in bytes.
+	ScalarSize = 32
+	// WideScalarSize is the size of the wide values reduced by SetWideBytes in bytes, which
+	// makes the bias of the reduction negligible.
+	WideScalarSize = 2 * ScalarSize
+)
@@ -0,0 +1,10 @@
+package p256
+
+import "errors"
+
+var (
+	// ErrInvalidPoint is the error returned when the encoding isn't a compressed point on P-256.
+	ErrInvalidPoint = errors.New("invalid point")
+	// ErrInvalidScalar is the error returned when the encoding isn't a canonical scalar.
+	ErrInvalidScalar = errors.New("invalid scalar")
+)
@@ -0,0 +1,67 @@
+package p256
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// Point is a point on P-256. The zero value is NOT valid, and NewPoint should be used instead.
+type Point struct {
+	// x, y are the affine coordinates, where the identity is (0, 0) as by crypto/elliptic.
+	x, y *big.Int
+}
+
+// NewPoint returns the identity.
+func NewPoint() *Point {
+	return &Point{x: new(big.Int), y: new(big.Int)}
+}
+
+// Add sets p = q + r and returns p.
+func (p *Point) Add(q, r *Point) *Point {
+	p.x, p.y = elliptic.P256().Add(q.x, q.y, r.x, r.y)
+	return p
+}
+
+// BytesCompressed returns the compressed encoding of p in PointSize bytes. p mustn't be the
+// identity, which has no compressed encoding.
+func (p *Point) BytesCompressed() []byte {
+	return elliptic.MarshalCompressed(elliptic.P256(), p.x, p.y)
+}
+
+// Equal reports whether p and q are the same point. It isn't constant time.
+func (p *Point) Equal(q *Point) bool {
+	return p.x.Cmp(q.x) == 0 && p.y.Cmp(q.y) == 0
+}
+
+// IsIdentity reports whether p is the identity. It isn't constant time.
+func (p *Point) IsIdentity() bool {
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+// ScalarBaseMult sets p = k*G, where G is the base point, and returns p.
+func (p *Point) ScalarBaseMult(k *Scalar) *Point {
+	p.x, p.y = elliptic.P256().ScalarBaseMult(k.Bytes())
+	return p
+}
+
+// ScalarMult sets p = k*q and returns p.
+func (p *Point) ScalarMult(q *Point, k *Scalar) *Point {
+	p.x, p.y = elliptic.P256().ScalarMult(q.x, q.y, k.Bytes())
+	return p
+}
+
+// SetBytes sets p to the compressed encoding b of PointSize bytes and returns p. It fails with
+// ErrInvalidPoint if b isn't a compressed point on the curve.
+func (p *Point) SetBytes(b []byte) (*Point, error) {
+	if len(b) != PointSize {
+		return nil, ErrInvalidPoint
+	}
+
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), b)
+	if x == nil {
+		return nil, ErrInvalidPoint
+	}
+	p.x, p.y = x, y
+
+	return p, nil
+}
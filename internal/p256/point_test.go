@@ -0,0 +1,37 @@
+package p256_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sammyne/strobe/internal/p256"
+)
+
+func TestPoint(t *testing.T) {
+	k := mustScalar(t, big.NewInt(7))
+	p := p256.NewPoint().ScalarBaseMult(k)
+
+	q, err := p256.NewPoint().SetBytes(p.BytesCompressed())
+	if err != nil {
+		t.Fatalf("fail to decode point: %v", err)
+	} else if !p.Equal(q) {
+		t.Fatal("point round trip mismatch")
+	}
+
+	// 7G + (-7)G is the identity, and 3*(7G) = 21G
+	negP := p256.NewPoint().ScalarBaseMult(new(p256.Scalar).Negate(k))
+	if !p256.NewPoint().Add(p, negP).IsIdentity() {
+		t.Fatal("P + (-P) isn't the identity")
+	}
+
+	expect := p256.NewPoint().ScalarBaseMult(mustScalar(t, big.NewInt(21)))
+	if got := p256.NewPoint().ScalarMult(p, mustScalar(t, big.NewInt(3))); !expect.Equal(got) {
+		t.Fatal("invalid scalar multiplication")
+	}
+
+	invalid := p.BytesCompressed()
+	invalid[0] = 0x04
+	if _, err := p256.NewPoint().SetBytes(invalid); err != p256.ErrInvalidPoint {
+		t.Fatalf("invalid error: expect %v, got %v", p256.ErrInvalidPoint, err)
+	}
+}
@@ -0,0 +1,204 @@
+// Package p256 implements the scalars and points of P-256 shared by schnorr and spake2.
+//
+// Scalars are integers modulo the order of P-256 whose arithmetic runs in constant time. Points
+// wrap the P-256 curve of crypto/elliptic, whose scalar multiplications run in constant time.
+package p256
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// Scalar is an integer modulo the order of P-256. The zero value is zero.
+type Scalar struct {
+	// l holds the Montgomery form xR mod n in little-endian 64-bit limbs, where R = 2^256.
+	l [4]uint64
+}
+
+var (
+	// n is the order of P-256.
+	n = toLimbs(elliptic.P256().Params().N)
+	// n0 is -n^-1 mod 2^64.
+	n0 = negInverse(n[0])
+	// rr is R^2 mod n, which converts values into the Montgomery form.
+	rr = toLimbs(powR(2))
+	// rrr is R^3 mod n, which converts the high half of wide values into the Montgomery form.
+	rrr = toLimbs(powR(3))
+)
+
+// Add sets s = x + y mod n and returns s.
+func (s *Scalar) Add(x, y *Scalar) *Scalar {
+	var sum [4]uint64
+	var carry uint64
+	for i := range sum {
+		sum[i], carry = bits.Add64(x.l[i], y.l[i], carry)
+	}
+	s.l = reduceOnce(sum, carry)
+
+	return s
+}
+
+// Bytes returns the big-endian canonical encoding of s in ScalarSize bytes.
+func (s *Scalar) Bytes() []byte {
+	// the Montgomery reduction of xR is x
+	x := montMul(&s.l, &[4]uint64{1})
+
+	out := make([]byte, ScalarSize)
+	for i, v := range x {
+		binary.BigEndian.PutUint64(out[ScalarSize-8*(i+1):], v)
+	}
+
+	return out
+}
+
+// IsZero returns 1 if s is zero, and 0 otherwise.
+func (s *Scalar) IsZero() int {
+	v := s.l[0] | s.l[1] | s.l[2] | s.l[3]
+	return int(1 ^ (v|-v)>>63)
+}
+
+// Multiply sets s = x * y mod n and returns s.
+func (s *Scalar) Multiply(x, y *Scalar) *Scalar {
+	s.l = montMul(&x.l, &y.l)
+	return s
+}
+
+// Negate sets s = -x mod n and returns s.
+func (s *Scalar) Negate(x *Scalar) *Scalar {
+	var d [4]uint64
+	var borrow uint64
+	for i := range d {
+		d[i], borrow = bits.Sub64(0, x.l[i], borrow)
+	}
+
+	// adds n back if x isn't zero
+	mask := -borrow
+	var carry uint64
+	for i := range d {
+		d[i], carry = bits.Add64(d[i], n[i]&mask, carry)
+	}
+	s.l = d
+
+	return s
+}
+
+// SetCanonicalBytes sets s to the big-endian encoding x of ScalarSize bytes and returns s. It
+// fails with ErrInvalidScalar if x isn't less than n.
+func (s *Scalar) SetCanonicalBytes(x []byte) (*Scalar, error) {
+	if len(x) != ScalarSize {
+		return nil, ErrInvalidScalar
+	}
+
+	v := fromBytes(x)
+	var borrow uint64
+	for i := range v {
+		_, borrow = bits.Sub64(v[i], n[i], borrow)
+	}
+	if borrow == 0 {
+		return nil, ErrInvalidScalar
+	}
+
+	s.l = montMul(&v, &rr)
+	return s, nil
+}
+
+// SetWideBytes sets s to the big-endian encoding x of WideScalarSize bytes reduced modulo n and
+// returns s. It fails with ErrInvalidScalar if x is of another size.
+func (s *Scalar) SetWideBytes(x []byte) (*Scalar, error) {
+	if len(x) != WideScalarSize {
+		return nil, ErrInvalidScalar
+	}
+
+	// x = hi*R + lo, so xR = hi*R^3/R + lo*R^2/R, where both halves are less than 2n
+	hi, lo := fromBytes(x[:ScalarSize]), fromBytes(x[ScalarSize:])
+	hi, lo = reduceOnce(hi, 0), reduceOnce(lo, 0)
+
+	hi, lo = montMul(&hi, &rrr), montMul(&lo, &rr)
+	return s.Add(&Scalar{l: hi}, &Scalar{l: lo}), nil
+}
+
+func fromBytes(x []byte) [4]uint64 {
+	var out [4]uint64
+	for i := range out {
+		out[i] = binary.BigEndian.Uint64(x[len(x)-8*(i+1):])
+	}
+
+	return out
+}
+
+// montMul returns the Montgomery product x*y/R mod n of x, y less than n in constant time.
+func montMul(x, y *[4]uint64) [4]uint64 {
+	var t [6]uint64
+	for i := range y {
+		// t += x * y[i]
+		var c uint64
+		for j := range x {
+			hi, lo := bits.Mul64(x[j], y[i])
+			lo, c0 := bits.Add64(lo, t[j], 0)
+			hi += c0
+			lo, c0 = bits.Add64(lo, c, 0)
+			hi += c0
+			t[j], c = lo, hi
+		}
+		t[4], c = bits.Add64(t[4], c, 0)
+		t[5] = c
+
+		// t = (t + m*n) / 2^64, where m makes the lowest limb zero
+		m := t[0] * n0
+		hi, lo := bits.Mul64(m, n[0])
+		_, c0 := bits.Add64(lo, t[0], 0)
+		c = hi + c0
+		for j := 1; j < len(n); j++ {
+			hi, lo = bits.Mul64(m, n[j])
+			lo, c0 = bits.Add64(lo, t[j], 0)
+			hi += c0
+			lo, c0 = bits.Add64(lo, c, 0)
+			hi += c0
+			t[j-1], c = lo, hi
+		}
+		t[3], c0 = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + c0
+	}
+
+	return reduceOnce([4]uint64{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+// reduceOnce returns carry*2^256 + x less than 2n reduced modulo n in constant time.
+func reduceOnce(x [4]uint64, carry uint64) [4]uint64 {
+	var d [4]uint64
+	var borrow uint64
+	for i := range d {
+		d[i], borrow = bits.Sub64(x[i], n[i], borrow)
+	}
+	_, borrow = bits.Sub64(carry, 0, borrow)
+
+	// keeps x if the subtraction borrows, i.e. x < n
+	mask := -borrow
+	for i := range x {
+		x[i] = x[i]&mask | d[i]&^mask
+	}
+
+	return x
+}
+
+// negInverse returns -x^-1 mod 2^64 of an odd x by Newton's iteration.
+func negInverse(x uint64) uint64 {
+	inv := x
+	for i := 0; i < 5; i++ {
+		inv *= 2 - x*inv
+	}
+
+	return -inv
+}
+
+// powR returns R^e mod n.
+func powR(e int64) *big.Int {
+	r := new(big.Int).Lsh(big.NewInt(1), 8*ScalarSize)
+	return r.Exp(r, big.NewInt(e), elliptic.P256().Params().N)
+}
+
+func toLimbs(x *big.Int) [4]uint64 {
+	return fromBytes(x.FillBytes(make([]byte, ScalarSize)))
+}
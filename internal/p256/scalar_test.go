@@ -0,0 +1,110 @@
+package p256_test
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/sammyne/strobe/internal/p256"
+)
+
+var order = elliptic.P256().Params().N
+
+func TestScalar(t *testing.T) {
+	nMinus1 := new(big.Int).Sub(order, big.NewInt(1))
+	edges := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), nMinus1}
+
+	values := append([]*big.Int{}, edges...)
+	for i := 0; i < 64; i++ {
+		v, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+
+	for i, x := range values {
+		xs := mustScalar(t, x)
+		if got := new(big.Int).SetBytes(xs.Bytes()); got.Cmp(x) != 0 {
+			t.Fatalf("#%d invalid encoding: expect %x, got %x", i, x, got)
+		}
+
+		if expect := x.Sign() == 0; (xs.IsZero() == 1) != expect {
+			t.Fatalf("#%d invalid IsZero: expect %v", i, expect)
+		}
+
+		neg := new(big.Int).Neg(x)
+		checkScalar(t, i, "Negate", new(p256.Scalar).Negate(xs), neg.Mod(neg, order))
+
+		for j, y := range values[:len(edges)+8] {
+			ys := mustScalar(t, y)
+
+			sum := new(big.Int).Add(x, y)
+			checkScalar(t, i, "Add", new(p256.Scalar).Add(xs, ys), sum.Mod(sum, order))
+
+			product := new(big.Int).Mul(x, y)
+			checkScalar(t, j, "Multiply", new(p256.Scalar).Multiply(xs, ys), product.Mod(product, order))
+		}
+	}
+}
+
+func TestScalar_SetCanonicalBytes(t *testing.T) {
+	testVector := [][]byte{
+		order.Bytes(),
+		bytes.Repeat([]byte{0xff}, p256.ScalarSize),
+		make([]byte, p256.ScalarSize-1),
+		make([]byte, p256.ScalarSize+1),
+	}
+
+	for i, c := range testVector {
+		if _, err := new(p256.Scalar).SetCanonicalBytes(c); err != p256.ErrInvalidScalar {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, p256.ErrInvalidScalar, err)
+		}
+	}
+}
+
+func TestScalar_SetWideBytes(t *testing.T) {
+	testVector := [][]byte{
+		make([]byte, p256.WideScalarSize),
+		bytes.Repeat([]byte{0xff}, p256.WideScalarSize),
+		append(order.Bytes(), order.Bytes()...),
+	}
+	for i := 0; i < 32; i++ {
+		wide := make([]byte, p256.WideScalarSize)
+		if _, err := rand.Read(wide); err != nil {
+			t.Fatal(err)
+		}
+		testVector = append(testVector, wide)
+	}
+
+	for i, c := range testVector {
+		s, err := new(p256.Scalar).SetWideBytes(c)
+		if err != nil {
+			t.Fatalf("#%d fail to set wide bytes: %v", i, err)
+		}
+
+		expect := new(big.Int).SetBytes(c)
+		checkScalar(t, i, "SetWideBytes", s, expect.Mod(expect, order))
+	}
+
+	if _, err := new(p256.Scalar).SetWideBytes(make([]byte, p256.ScalarSize)); err != p256.ErrInvalidScalar {
+		t.Fatalf("invalid error: expect %v, got %v", p256.ErrInvalidScalar, err)
+	}
+}
+
+func checkScalar(t *testing.T, i int, op string, s *p256.Scalar, expect *big.Int) {
+	if got := new(big.Int).SetBytes(s.Bytes()); got.Cmp(expect) != 0 {
+		t.Fatalf("#%d invalid %s: expect %x, got %x", i, op, expect, got)
+	}
+}
+
+func mustScalar(t *testing.T, x *big.Int) *p256.Scalar {
+	out, err := new(p256.Scalar).SetCanonicalBytes(x.FillBytes(make([]byte, p256.ScalarSize)))
+	if err != nil {
+		t.Fatalf("fail to set scalar: %v", err)
+	}
+
+	return out
+}
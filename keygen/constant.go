@@ -0,0 +1,15 @@
+package keygen
+
+// Proto is the STROBE proto of derivers created from seeds.
+const Proto = "github.com/sammyne/strobe/keygen"
+
+// MinSeedSize is the minimum size of seeds in bytes.
+const MinSeedSize = 32
+
+// Labels framing the operations of the transcripts.
+const (
+	labelAlgorithm = "algorithm"
+	labelPurpose   = "purpose"
+	labelScalar    = "scalar"
+	labelSeed      = "seed"
+)
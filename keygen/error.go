@@ -0,0 +1,10 @@
+package keygen
+
+import "errors"
+
+var (
+	// ErrInvalidSeed is the error returned by New when the seed is shorter than MinSeedSize.
+	ErrInvalidSeed = errors.New("invalid seed")
+	// ErrUnsupportedCurve is the error returned by ECDH when the curve isn't supported.
+	ErrUnsupportedCurve = errors.New("unsupported curve")
+)
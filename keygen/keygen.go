@@ -0,0 +1,156 @@
+// Package keygen derives asymmetric keys deterministically from a seed or a STROBE transcript,
+// such as device identity keys from a provisioning secret.
+//
+// Each key is derived from a fork of the keyed transcript, which absorbs the algorithm and the
+// purpose as labeled AD, so that the same seed yields independent keys per algorithm and purpose.
+// Seeds of Ed25519 and X25519 keys are read from PRF directly, while scalars of NIST curves are
+// drawn in [1, N-1] by rejection sampling, without any bias.
+package keygen
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/hashtofield"
+)
+
+// Deriver derives keys from a keyed STROBE instance, which is safe for concurrent use.
+type Deriver struct {
+	// s is only cloned, and so safe for concurrent use
+	s *strobe.Strobe
+}
+
+// New returns the deriver keyed with the seed of at least MinSeedSize bytes.
+func New(seed []byte) (*Deriver, error) {
+	if len(seed) < MinSeedSize {
+		return nil, ErrInvalidSeed
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelSeed), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, seed...), false); err != nil {
+		return nil, err
+	}
+
+	return &Deriver{s: s}, nil
+}
+
+// FromStrobe returns the deriver forking a clone of s, which should already be keyed with a
+// secret. s itself is left untouched.
+func FromStrobe(s *strobe.Strobe) *Deriver {
+	return &Deriver{s: s.Clone()}
+}
+
+// ECDH derives the key of the curve for the purpose, where the curve is one of X25519, P-256,
+// P-384 and P-521.
+func (d *Deriver) ECDH(curve ecdh.Curve, purpose string) (*ecdh.PrivateKey, error) {
+	var c elliptic.Curve
+	switch curve {
+	case ecdh.X25519():
+		s, err := d.fork("ecdh X25519", purpose)
+		if err != nil {
+			return nil, err
+		}
+
+		// all 32-byte strings are valid X25519 keys
+		key := make([]byte, 32)
+		if err := s.PRF(key, false); err != nil {
+			return nil, err
+		}
+
+		return curve.NewPrivateKey(key)
+	case ecdh.P256():
+		c = elliptic.P256()
+	case ecdh.P384():
+		c = elliptic.P384()
+	case ecdh.P521():
+		c = elliptic.P521()
+	default:
+		return nil, ErrUnsupportedCurve
+	}
+
+	k, err := d.scalar("ecdh "+c.Params().Name, purpose, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return curve.NewPrivateKey(k.FillBytes(make([]byte, (c.Params().BitSize+7)/8)))
+}
+
+// ECDSA derives the ECDSA key of the curve for the purpose.
+func (d *Deriver) ECDSA(curve elliptic.Curve, purpose string) (*ecdsa.PrivateKey, error) {
+	k, err := d.scalar("ecdsa "+curve.Params().Name, purpose, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: k}
+	out.X, out.Y = curve.ScalarBaseMult(k.FillBytes(make([]byte, (curve.Params().BitSize+7)/8)))
+
+	return out, nil
+}
+
+// Ed25519 derives the Ed25519 key for the purpose.
+func (d *Deriver) Ed25519(purpose string) (ed25519.PrivateKey, error) {
+	s, err := d.fork("ed25519", purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if err := s.PRF(seed, false); err != nil {
+		return nil, err
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// fork clones the keyed transcript, and absorbs the algorithm and purpose.
+func (d *Deriver) fork(algorithm, purpose string) (*strobe.Strobe, error) {
+	s := d.s.Clone()
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelAlgorithm, []byte(algorithm)},
+		{labelPurpose, []byte(purpose)},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// scalar draws a scalar in [1, N-1] by rejection sampling, where N is the order of the curve.
+func (d *Deriver) scalar(algorithm, purpose string, curve elliptic.Curve) (*big.Int, error) {
+	s, err := d.fork(algorithm, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	one := big.NewInt(1)
+
+	k, err := hashtofield.SampleScalar(s, labelScalar, new(big.Int).Sub(curve.Params().N, one))
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Add(k, one), nil
+}
@@ -0,0 +1,224 @@
+package keygen_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/keygen"
+)
+
+var seed = bytes.Repeat([]byte{0x42}, keygen.MinSeedSize)
+
+var ecdhCurves = map[string]ecdh.Curve{
+	"X25519": ecdh.X25519(),
+	"P-256":  ecdh.P256(),
+	"P-384":  ecdh.P384(),
+	"P-521":  ecdh.P521(),
+}
+
+var ecdsaCurves = map[string]elliptic.Curve{
+	"P-224": elliptic.P224(),
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func TestDeriver_ECDH(t *testing.T) {
+	for name, curve := range ecdhCurves {
+		alice := mustECDH(t, mustNew(t, seed), curve, "alice")
+		if again := mustECDH(t, mustNew(t, seed), curve, "alice"); !alice.Equal(again) {
+			t.Fatalf("%s: non-deterministic key", name)
+		}
+
+		bob := mustECDH(t, mustNew(t, seed), curve, "bob")
+		if alice.Equal(bob) {
+			t.Fatalf("%s: keys of different purposes collide", name)
+		}
+
+		secretA, err := alice.ECDH(bob.PublicKey())
+		if err != nil {
+			t.Fatalf("%s: fail to ECDH for alice: %v", name, err)
+		}
+		secretB, err := bob.ECDH(alice.PublicKey())
+		if err != nil {
+			t.Fatalf("%s: fail to ECDH for bob: %v", name, err)
+		} else if !bytes.Equal(secretA, secretB) {
+			t.Fatalf("%s: shared secrets mismatch: %x != %x", name, secretA, secretB)
+		}
+	}
+}
+
+func TestDeriver_ECDSA(t *testing.T) {
+	digest := sha256.Sum256([]byte("hello world"))
+
+	for name, curve := range ecdsaCurves {
+		priv, err := mustNew(t, seed).ECDSA(curve, "signing")
+		if err != nil {
+			t.Fatalf("%s: fail to derive key: %v", name, err)
+		} else if !curve.IsOnCurve(priv.X, priv.Y) {
+			t.Fatalf("%s: public key not on curve", name)
+		}
+
+		again, err := mustNew(t, seed).ECDSA(curve, "signing")
+		if err != nil {
+			t.Fatalf("%s: fail to derive key again: %v", name, err)
+		} else if !priv.Equal(again) {
+			t.Fatalf("%s: non-deterministic key", name)
+		}
+
+		sig, err := ecdsa.SignASN1(bytes.NewReader(bytes.Repeat([]byte{1}, 1024)), priv, digest[:])
+		if err != nil {
+			t.Fatalf("%s: fail to sign: %v", name, err)
+		} else if !ecdsa.VerifyASN1(&priv.PublicKey, digest[:], sig) {
+			t.Fatalf("%s: fail to verify", name)
+		}
+	}
+
+	// keys of the same curve and purpose are independent across algorithms
+	priv, err := mustNew(t, seed).ECDSA(elliptic.P256(), "purpose")
+	if err != nil {
+		t.Fatalf("fail to derive ECDSA key: %v", err)
+	}
+
+	ecdhKey := mustECDH(t, mustNew(t, seed), ecdh.P256(), "purpose")
+	if bytes.Equal(priv.D.FillBytes(make([]byte, 32)), ecdhKey.Bytes()) {
+		t.Fatal("ECDSA and ECDH keys collide")
+	}
+}
+
+func TestDeriver_Ed25519(t *testing.T) {
+	priv, err := mustNew(t, seed).Ed25519("signing")
+	if err != nil {
+		t.Fatalf("fail to derive key: %v", err)
+	}
+
+	again, err := mustNew(t, seed).Ed25519("signing")
+	if err != nil {
+		t.Fatalf("fail to derive key again: %v", err)
+	} else if !priv.Equal(again) {
+		t.Fatal("non-deterministic key")
+	}
+
+	msg := []byte("hello world")
+	if !ed25519.Verify(priv.Public().(ed25519.PublicKey), msg, ed25519.Sign(priv, msg)) {
+		t.Fatal("fail to verify")
+	}
+
+	x25519 := mustECDH(t, mustNew(t, seed), ecdh.X25519(), "signing")
+	if bytes.Equal(priv.Seed(), x25519.Bytes()) {
+		t.Fatal("Ed25519 and X25519 keys collide")
+	}
+}
+
+func TestFromStrobe(t *testing.T) {
+	s, err := strobe.New("keygen test", strobe.Bit128)
+	if err != nil {
+		t.Fatalf("fail to new STROBE: %v", err)
+	}
+	if err := s.KEY(append([]byte{}, seed...), false); err != nil {
+		t.Fatalf("fail to KEY: %v", err)
+	}
+
+	snapshot := s.Clone()
+
+	d := keygen.FromStrobe(s)
+	expect := mustECDH(t, d, ecdh.X25519(), "purpose")
+
+	// the transcript is left untouched, and further changes to it don't affect the deriver
+	if got := mustECDH(t, keygen.FromStrobe(snapshot), ecdh.X25519(), "purpose"); !expect.Equal(got) {
+		t.Fatal("transcript changed by deriver")
+	}
+
+	if err := s.AD([]byte("more"), &strobe.Options{}); err != nil {
+		t.Fatalf("fail to AD: %v", err)
+	}
+	if got := mustECDH(t, d, ecdh.X25519(), "purpose"); !expect.Equal(got) {
+		t.Fatal("deriver changed by transcript")
+	}
+
+	if got := mustECDH(t, mustNew(t, seed), ecdh.X25519(), "purpose"); expect.Equal(got) {
+		t.Fatal("keys of different transcripts collide")
+	}
+}
+
+func TestNew_InvalidSeed(t *testing.T) {
+	if _, err := keygen.New(seed[1:]); err != keygen.ErrInvalidSeed {
+		t.Fatalf("invalid error: expect %v, got %v", keygen.ErrInvalidSeed, err)
+	}
+}
+
+// TestDeriver_Vectors checks keys produced by this implementation, so as to pin down the
+// derivation.
+func TestDeriver_Vectors(t *testing.T) {
+	type TestCase struct {
+		Seed       []byte
+		Algorithm  string
+		Purpose    string
+		PrivateKey []byte
+	}
+
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []TestCase
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, c := range testVector {
+		d := mustNew(t, c.Seed)
+
+		var got []byte
+		if c.Algorithm == "ed25519" {
+			priv, err := d.Ed25519(c.Purpose)
+			if err != nil {
+				t.Fatalf("#%d fail to derive key: %v", i, err)
+			}
+			got = priv.Seed()
+		} else if name, ok := strings.CutPrefix(c.Algorithm, "ecdh "); ok && ecdhCurves[name] != nil {
+			got = mustECDH(t, d, ecdhCurves[name], c.Purpose).Bytes()
+		} else if name, ok := strings.CutPrefix(c.Algorithm, "ecdsa "); ok && ecdsaCurves[name] != nil {
+			curve := ecdsaCurves[name]
+			priv, err := d.ECDSA(curve, c.Purpose)
+			if err != nil {
+				t.Fatalf("#%d fail to derive key: %v", i, err)
+			}
+			got = priv.D.FillBytes(make([]byte, (curve.Params().BitSize+7)/8))
+		} else {
+			t.Fatalf("#%d unknown algorithm: %s", i, c.Algorithm)
+		}
+
+		if !bytes.Equal(c.PrivateKey, got) {
+			t.Fatalf("#%d invalid key: expect %x, got %x", i, c.PrivateKey, got)
+		}
+	}
+}
+
+func mustECDH(t *testing.T, d *keygen.Deriver, curve ecdh.Curve, purpose string) *ecdh.PrivateKey {
+	out, err := d.ECDH(curve, purpose)
+	if err != nil {
+		t.Fatalf("fail to derive ECDH key: %v", err)
+	}
+
+	return out
+}
+
+func mustNew(t *testing.T, seed []byte) *keygen.Deriver {
+	out, err := keygen.New(seed)
+	if err != nil {
+		t.Fatalf("fail to new deriver: %v", err)
+	}
+
+	return out
+}
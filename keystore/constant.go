@@ -0,0 +1,26 @@
+package keystore
+
+// KDFName identifies the key derivation function in the file.
+const KDFName = "strobe-balloon"
+
+// Proto is the STROBE proto encrypting the key material.
+const Proto = "github.com/sammyne/strobe/keystore/v1"
+
+const (
+	// SaltLen is the length of the random salt of the KDF in bytes.
+	SaltLen = 32
+	// TagLen is the length of the MAC of the key material in bytes.
+	TagLen = 32
+)
+
+// Version is the version of the file format.
+const Version = 1
+
+// wrappingKeyLen is the length of the key derived from the passphrase in bytes.
+const wrappingKeyLen = 32
+
+// Labels framing the operations of the transcript.
+const (
+	labelKDF = "kdf"
+	labelKey = "key"
+)
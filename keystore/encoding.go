@@ -0,0 +1,55 @@
+package keystore
+
+import (
+	"encoding/json"
+
+	"github.com/sammyne/strobe/balloon"
+)
+
+// file is the JSON layout of a keystore file, where byte slices are encoded in base64.
+type file struct {
+	Version int        `json:"version"`
+	KDF     kdfJSON    `json:"kdf"`
+	Cipher  cipherJSON `json:"cipher"`
+}
+
+type kdfJSON struct {
+	Name        string `json:"name"`
+	SpaceCost   uint32 `json:"spaceCost"`
+	TimeCost    uint32 `json:"timeCost"`
+	Parallelism uint32 `json:"parallelism"`
+	Salt        []byte `json:"salt"`
+}
+
+type cipherJSON struct {
+	Proto      string `json:"proto"`
+	Ciphertext []byte `json:"ciphertext"`
+	MAC        []byte `json:"mac"`
+}
+
+func (k *kdfJSON) params() *balloon.Params {
+	return &balloon.Params{SpaceCost: k.SpaceCost, TimeCost: k.TimeCost, Parallelism: k.Parallelism}
+}
+
+func decode(data []byte) (*file, error) {
+	var out file
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	if out.Version != Version || out.KDF.Name != KDFName || out.Cipher.Proto != Proto {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if len(out.KDF.Salt) == 0 || len(out.Cipher.MAC) != TagLen {
+		return nil, ErrInvalidFile
+	}
+
+	// bound the KDF parameters before deriving, so that a forged file can't exhaust the memory and
+	// CPU of the caller
+	if err := out.KDF.params().Validate(); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	return &out, nil
+}
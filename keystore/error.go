@@ -0,0 +1,13 @@
+package keystore
+
+import "errors"
+
+var (
+	// ErrInvalidFile is the error returned when the file is malformed.
+	ErrInvalidFile = errors.New("invalid keystore file")
+	// ErrUnsupportedVersion is the error returned when the file is of an unknown version or KDF.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	// ErrWrongPassphrase is the error returned when the key material fails authentication, which
+	// means a wrong passphrase or a corrupted file.
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted file")
+)
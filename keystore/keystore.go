@@ -0,0 +1,163 @@
+// Package keystore implements a passphrase-protected file format for long-term keys.
+//
+// The wrapping key is derived from the passphrase with Balloon hashing, and the key material is
+// encrypted by SendENC and authenticated by SendMAC on a STROBE transcript which also absorbs the
+// KDF parameters. The file is a versioned JSON document, so that the passphrase and KDF parameters
+// can be changed without regenerating the key.
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/balloon"
+)
+
+// ChangePassphrase decrypts the file with the old passphrase, and re-encrypts the same key
+// material under the new passphrase with the given KDF parameters. A nil params means
+// balloon.DefaultParams.
+func ChangePassphrase(data, oldPassphrase, newPassphrase []byte, params *balloon.Params) ([]byte,
+	error) {
+	key, err := Decrypt(data, oldPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(key)
+
+	return Encrypt(key, newPassphrase, params)
+}
+
+// Decrypt decrypts the key material of the file with the passphrase. The key material is released
+// only after being authenticated.
+func Decrypt(data, passphrase []byte) ([]byte, error) {
+	f, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newTranscript(passphrase, &f.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &strobe.Options{}
+
+	key, err := s.RecvENC(append([]byte{}, f.Cipher.Ciphertext...), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.RecvMAC(f.Cipher.MAC, opts); err != nil {
+		zero(key)
+		return nil, ErrWrongPassphrase
+	}
+
+	return key, nil
+}
+
+// Encrypt encrypts the key material under the passphrase with the given KDF parameters, and
+// returns the content of the file. A nil params means balloon.DefaultParams.
+func Encrypt(key, passphrase []byte, params *balloon.Params) ([]byte, error) {
+	if params == nil {
+		params = &balloon.DefaultParams
+	}
+
+	f := file{
+		Version: Version,
+		KDF: kdfJSON{
+			Name:        KDFName,
+			SpaceCost:   params.SpaceCost,
+			TimeCost:    params.TimeCost,
+			Parallelism: params.Parallelism,
+			Salt:        make([]byte, SaltLen),
+		},
+		Cipher: cipherJSON{Proto: Proto, MAC: make([]byte, TagLen)},
+	}
+	if _, err := rand.Read(f.KDF.Salt); err != nil {
+		return nil, err
+	}
+
+	s, err := newTranscript(passphrase, &f.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &strobe.Options{}
+	if f.Cipher.Ciphertext, err = s.SendENC(append([]byte{}, key...), opts); err != nil {
+		return nil, err
+	}
+
+	if err := s.SendMAC(f.Cipher.MAC, opts); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// NeedsUpgrade reports whether the file uses KDF parameters other than the given ones, in which
+// case it should be re-encrypted by ChangePassphrase with the same passphrase. A nil params means
+// balloon.DefaultParams.
+func NeedsUpgrade(data []byte, params *balloon.Params) (bool, error) {
+	if params == nil {
+		params = &balloon.DefaultParams
+	}
+
+	f, err := decode(data)
+	if err != nil {
+		return false, err
+	}
+
+	return *f.KDF.params() != *params, nil
+}
+
+// newTranscript derives the wrapping key from the passphrase, and keys the transcript with it
+// after absorbing the KDF parameters.
+func newTranscript(passphrase []byte, kdf *kdfJSON) (*strobe.Strobe, error) {
+	params := kdf.params()
+
+	wrappingKey, err := balloon.Key(passphrase, kdf.Salt, params, wrappingKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	kdfID := fmt.Sprintf("%s$s=%d,t=%d,p=%d", kdf.Name, params.SpaceCost, params.TimeCost,
+		params.Parallelism)
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelKDF, []byte(kdfID)},
+		{labelKDF, kdf.Salt},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.AD([]byte(labelKey), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	// KEY works in place, which is fine since the wrapping key isn't used any more
+	if err := s.KEY(wrappingKey, false); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,145 @@
+package keystore_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sammyne/strobe/balloon"
+	"github.com/sammyne/strobe/keystore"
+)
+
+var testParams = balloon.Params{SpaceCost: 32, TimeCost: 1, Parallelism: 1}
+
+func TestEncrypt(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	passphrase := []byte("correct horse battery staple")
+
+	data, err := keystore.Encrypt(key, passphrase, &testParams)
+	if err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	if bytes.Contains(data, key) {
+		t.Fatal("key material leaks into the file")
+	}
+
+	got, err := keystore.Decrypt(data, passphrase)
+	if err != nil {
+		t.Fatalf("fail to decrypt: %v", err)
+	} else if !bytes.Equal(key, got) {
+		t.Fatalf("invalid key: expect %x, got %x", key, got)
+	}
+
+	if _, err := keystore.Decrypt(data, []byte("wrong passphrase")); err != keystore.ErrWrongPassphrase {
+		t.Fatalf("invalid error: expect %v, got %v", keystore.ErrWrongPassphrase, err)
+	}
+}
+
+func TestDecrypt_Tampered(t *testing.T) {
+	passphrase := []byte("passphrase")
+
+	data, err := keystore.Encrypt([]byte("secret key"), passphrase, &testParams)
+	if err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	testVector := []struct {
+		name   string
+		field  string
+		key    string
+		value  interface{}
+		expect error
+	}{
+		{"weakened KDF", "kdf", "timeCost", 2, keystore.ErrWrongPassphrase},
+		{"other KDF", "kdf", "name", "scrypt", keystore.ErrUnsupportedVersion},
+		{"other proto", "cipher", "proto", "another", keystore.ErrUnsupportedVersion},
+		{"missing MAC", "cipher", "mac", nil, keystore.ErrInvalidFile},
+		{"zero space cost", "kdf", "spaceCost", 0, keystore.ErrInvalidFile},
+		{"huge space cost", "kdf", "spaceCost", 1 << 30, keystore.ErrInvalidFile},
+		{"huge time cost", "kdf", "timeCost", 1 << 30, keystore.ErrInvalidFile},
+		{"huge parallelism", "kdf", "parallelism", 1 << 30, keystore.ErrInvalidFile},
+	}
+
+	for _, c := range testVector {
+		var f map[string]interface{}
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatalf("%s: fail to unmarshal: %v", c.name, err)
+		}
+		f[c.field].(map[string]interface{})[c.key] = c.value
+
+		tampered, err := json.Marshal(f)
+		if err != nil {
+			t.Fatalf("%s: fail to marshal: %v", c.name, err)
+		}
+
+		if _, err := keystore.Decrypt(tampered, passphrase); err != c.expect {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, c.expect, err)
+		}
+	}
+
+	if _, err := keystore.Decrypt([]byte("not json"), passphrase); err != keystore.ErrInvalidFile {
+		t.Fatalf("invalid error: expect %v, got %v", keystore.ErrInvalidFile, err)
+	}
+}
+
+func TestNeedsUpgrade_DefaultParams(t *testing.T) {
+	data, err := keystore.Encrypt([]byte("secret key"), []byte("passphrase"), nil)
+	if err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	if yes, err := keystore.NeedsUpgrade(data, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if yes {
+		t.Fatal("upgrade is required by the same default params")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	key := []byte("secret key")
+	oldPassphrase, newPassphrase := []byte("old"), []byte("new")
+
+	data, err := keystore.Encrypt(key, oldPassphrase, &testParams)
+	if err != nil {
+		t.Fatalf("fail to encrypt: %v", err)
+	}
+
+	upgraded := testParams
+	upgraded.SpaceCost *= 2
+
+	if yes, err := keystore.NeedsUpgrade(data, &upgraded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !yes {
+		t.Fatal("upgrade isn't required by stronger params")
+	}
+
+	data, err = keystore.ChangePassphrase(data, oldPassphrase, newPassphrase, &upgraded)
+	if err != nil {
+		t.Fatalf("fail to change passphrase: %v", err)
+	}
+
+	if yes, err := keystore.NeedsUpgrade(data, &upgraded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if yes {
+		t.Fatal("upgrade is still required after upgrade")
+	}
+
+	// a nil params means the default one as by Encrypt
+	if yes, err := keystore.NeedsUpgrade(data, nil); err != nil {
+		t.Fatalf("unexpected error for nil params: %v", err)
+	} else if !yes {
+		t.Fatal("upgrade isn't required by default params")
+	}
+
+	if _, err := keystore.Decrypt(data, oldPassphrase); err != keystore.ErrWrongPassphrase {
+		t.Fatalf("invalid error for old passphrase: expect %v, got %v", keystore.ErrWrongPassphrase, err)
+	}
+
+	got, err := keystore.Decrypt(data, newPassphrase)
+	if err != nil {
+		t.Fatalf("fail to decrypt: %v", err)
+	} else if !bytes.Equal(key, got) {
+		t.Fatalf("key changes: expect %x, got %x", key, got)
+	}
+}
@@ -0,0 +1,27 @@
+package keytree
+
+// Proto is the STROBE proto of key trees.
+const Proto = "github.com/sammyne/strobe/keytree"
+
+const (
+	// CacheSize is the maximum number of nodes cached by a tree. Nodes derived once the cache is
+	// full aren't cached.
+	CacheSize = 1024
+	// MinSeedSize is the minimum size of root seeds in bytes.
+	MinSeedSize = 32
+	// RatchetLen is the number of bytes ratcheted after absorbing each path component.
+	RatchetLen = 32
+)
+
+const (
+	// HardenedSuffix marks a path component as hardened.
+	HardenedSuffix = "'"
+	// Separator separates the components of a path.
+	Separator = "/"
+)
+
+// Labels framing the operations of the transcripts.
+const (
+	labelExport = "export"
+	labelSeed   = "seed"
+)
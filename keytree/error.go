@@ -0,0 +1,14 @@
+package keytree
+
+import "errors"
+
+var (
+	// ErrInvalidKeySize is the error returned by Key when the requested size isn't positive.
+	ErrInvalidKeySize = errors.New("invalid key size")
+	// ErrInvalidPath is the error returned when a path is empty or has an empty component.
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrInvalidSeed is the error returned by New when the seed is shorter than MinSeedSize.
+	ErrInvalidSeed = errors.New("invalid seed")
+	// ErrNotExportable is the error returned by Key on a hardened node.
+	ErrNotExportable = errors.New("node not exportable")
+)
@@ -0,0 +1,153 @@
+// Package keytree implements hierarchical deterministic key derivation, where keys for tenants,
+// services and purposes are derived from a single root seed along paths like
+// "tenant/42/db/encryption".
+//
+// The root is a STROBE instance keyed with the seed. Each path component is absorbed as meta-AD
+// followed by a RATCHET, so a node reveals nothing about its parent or siblings, and key bytes are
+// exported by PRF after absorbing the requested size. A component with HardenedSuffix derives a
+// hardened node, which can only be derived further but never exported, so that intermediate nodes
+// like "tenant/42'" can't leak as keys of their own. The root is hardened as well.
+//
+// Nodes of a tree share a cache of intermediate nodes, so deriving many keys under the same prefix
+// only absorbs the prefix once.
+package keytree
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/sammyne/strobe"
+)
+
+// Node is a node of a key tree, which is safe for concurrent use.
+type Node struct {
+	// s is only cloned, and so safe for concurrent use
+	s        *strobe.Strobe
+	path     string
+	hardened bool
+	cache    *cache
+}
+
+// New returns the root of the key tree derived from the seed, which must be at least MinSeedSize
+// bytes.
+func New(seed []byte) (*Node, error) {
+	if len(seed) < MinSeedSize {
+		return nil, ErrInvalidSeed
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelSeed), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, seed...), false); err != nil {
+		return nil, err
+	}
+
+	out := &Node{s: s, hardened: true, cache: &cache{nodes: make(map[string]*Node)}}
+	return out, nil
+}
+
+// Derive derives the descendant along the path relative to the node, as parsed by ParsePath.
+// Deriving "a/b" is the same as deriving "a" and then "b".
+func (n *Node) Derive(path string) (*Node, error) {
+	components, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := n
+	for _, v := range components {
+		if out, err = out.child(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// Exportable reports whether key bytes can be exported from the node, i.e. it isn't hardened.
+func (n *Node) Exportable() bool {
+	return !n.hardened
+}
+
+// Key exports size bytes of key from the node. Keys of different sizes are independent, rather
+// than prefixes of each other.
+func (n *Node) Key(size int) ([]byte, error) {
+	if n.hardened {
+		return nil, ErrNotExportable
+	} else if size <= 0 {
+		return nil, ErrInvalidKeySize
+	}
+
+	s := n.s.Clone()
+	if err := s.AD([]byte(labelExport), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(binary.LittleEndian.AppendUint64(nil, uint64(size)), &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, size)
+	if err := s.PRF(out, false); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Path returns the canonical path of the node from the root, which is empty for the root.
+func (n *Node) Path() string {
+	return n.path
+}
+
+// child returns the cached child, or derives and caches it.
+func (n *Node) child(c Component) (*Node, error) {
+	path := c.String()
+	if n.path != "" {
+		path = n.path + Separator + path
+	}
+
+	if out, ok := n.cache.get(path); ok {
+		return out, nil
+	}
+
+	s := n.s.Clone()
+	if err := s.AD([]byte(c.String()), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.RATCHET(RatchetLen); err != nil {
+		return nil, err
+	}
+
+	out := &Node{s: s, path: path, hardened: c.Hardened, cache: n.cache}
+	n.cache.put(path, out)
+
+	return out, nil
+}
+
+// cache maps canonical paths to derived nodes, holding at most CacheSize nodes.
+type cache struct {
+	mu    sync.Mutex
+	nodes map[string]*Node
+}
+
+func (c *cache) get(path string) (*Node, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out, ok := c.nodes[path]
+	return out, ok
+}
+
+func (c *cache) put(path string, n *Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.nodes) < CacheSize {
+		c.nodes[path] = n
+	}
+}
@@ -0,0 +1,218 @@
+package keytree_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/sammyne/strobe/keytree"
+)
+
+var seed = bytes.Repeat([]byte{0x42}, keytree.MinSeedSize)
+
+func TestNode_Derive(t *testing.T) {
+	root := mustNew(t, seed)
+
+	key := mustKey(t, root, "tenant/42/db/encryption")
+
+	// derivation is stepwise, and doesn't depend on the cache
+	n := root
+	for _, v := range []string{"tenant", "42", "db/encryption"} {
+		var err error
+		if n, err = n.Derive(v); err != nil {
+			t.Fatalf("fail to derive %q: %v", v, err)
+		}
+	}
+	if n.Path() != "tenant/42/db/encryption" {
+		t.Fatalf("invalid path: expect %q, got %q", "tenant/42/db/encryption", n.Path())
+	}
+	if got := mustKey(t, n, ""); !bytes.Equal(key, got) {
+		t.Fatalf("stepwise derivation mismatch: expect %x, got %x", key, got)
+	}
+	if got := mustKey(t, mustNew(t, seed), "tenant/42/db/encryption"); !bytes.Equal(key, got) {
+		t.Fatalf("uncached derivation mismatch: expect %x, got %x", key, got)
+	}
+
+	anotherSeed := append([]byte{}, seed...)
+	anotherSeed[0] ^= 1
+
+	testVector := []struct {
+		seed []byte
+		path string
+	}{
+		{anotherSeed, "tenant/42/db/encryption"},
+		{seed, "tenant/43/db/encryption"},
+		{seed, "tenant/42/db/signing"},
+		{seed, "tenant/42/db"},
+		{seed, "tenant/42'/db/encryption"},
+		{seed, "tenant/42db/encryption"},
+		{seed, "tenant/42/db/encryption/v2"},
+	}
+
+	for i, c := range testVector {
+		if got := mustKey(t, mustNew(t, c.seed), c.path); bytes.Equal(key, got) {
+			t.Fatalf("#%d key collides with %q", i, c.path)
+		}
+	}
+}
+
+func TestNode_Derive_Concurrent(t *testing.T) {
+	root := mustNew(t, seed)
+	expect := mustKey(t, mustNew(t, seed), "tenant/42/db/encryption")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			n, err := root.Derive("tenant/42/db/encryption")
+			if err != nil {
+				t.Errorf("fail to derive: %v", err)
+				return
+			}
+
+			if got, err := n.Key(32); err != nil {
+				t.Errorf("fail to export key: %v", err)
+			} else if !bytes.Equal(expect, got) {
+				t.Errorf("invalid key: expect %x, got %x", expect, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNode_Key(t *testing.T) {
+	root := mustNew(t, seed)
+
+	if root.Exportable() {
+		t.Fatal("root is exportable")
+	} else if _, err := root.Key(32); err != keytree.ErrNotExportable {
+		t.Fatalf("invalid error for root: expect %v, got %v", keytree.ErrNotExportable, err)
+	}
+
+	hardened, err := root.Derive("tenant/42'")
+	if err != nil {
+		t.Fatalf("fail to derive hardened node: %v", err)
+	} else if hardened.Exportable() {
+		t.Fatal("hardened node is exportable")
+	} else if _, err := hardened.Key(32); err != keytree.ErrNotExportable {
+		t.Fatalf("invalid error for hardened node: expect %v, got %v", keytree.ErrNotExportable, err)
+	}
+
+	// children of hardened nodes are exportable unless hardened themselves
+	child, err := hardened.Derive("db")
+	if err != nil {
+		t.Fatalf("fail to derive child of hardened node: %v", err)
+	} else if !child.Exportable() {
+		t.Fatal("child of hardened node isn't exportable")
+	}
+
+	if _, err := child.Key(0); err != keytree.ErrInvalidKeySize {
+		t.Fatalf("invalid error for zero size: expect %v, got %v", keytree.ErrInvalidKeySize, err)
+	}
+
+	short, err := child.Key(16)
+	if err != nil {
+		t.Fatalf("fail to export short key: %v", err)
+	}
+	long, err := child.Key(32)
+	if err != nil {
+		t.Fatalf("fail to export long key: %v", err)
+	} else if bytes.HasPrefix(long, short) {
+		t.Fatal("keys of different sizes are related")
+	}
+}
+
+func TestNew_InvalidSeed(t *testing.T) {
+	if _, err := keytree.New(seed[1:]); err != keytree.ErrInvalidSeed {
+		t.Fatalf("invalid error: expect %v, got %v", keytree.ErrInvalidSeed, err)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	components, err := keytree.ParsePath("tenant/42'/db")
+	if err != nil {
+		t.Fatalf("fail to parse path: %v", err)
+	}
+
+	expect := []keytree.Component{{Name: "tenant"}, {Name: "42", Hardened: true}, {Name: "db"}}
+	if len(components) != len(expect) {
+		t.Fatalf("invalid #(components): expect %d, got %d", len(expect), len(components))
+	}
+	for i, v := range expect {
+		if components[i] != v {
+			t.Fatalf("#%d invalid component: expect %+v, got %+v", i, v, components[i])
+		}
+	}
+
+	testVector := []string{"", "/", "/a", "a/", "a//b", "'", "a/'", "a''"}
+	for i, c := range testVector {
+		if _, err := keytree.ParsePath(c); !errors.Is(err, keytree.ErrInvalidPath) {
+			t.Fatalf("#%d invalid error for %q: expect %v, got %v", i, c, keytree.ErrInvalidPath, err)
+		}
+	}
+}
+
+// TestNode_Key_Vectors checks keys produced by this implementation, so as to pin down the
+// derivation.
+func TestNode_Key_Vectors(t *testing.T) {
+	type TestCase struct {
+		Seed []byte
+		Path string
+		Size int
+		Key  []byte
+	}
+
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []TestCase
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, c := range testVector {
+		n, err := mustNew(t, c.Seed).Derive(c.Path)
+		if err != nil {
+			t.Fatalf("#%d fail to derive: %v", i, err)
+		}
+
+		if got, err := n.Key(c.Size); err != nil {
+			t.Fatalf("#%d fail to export key: %v", i, err)
+		} else if !bytes.Equal(c.Key, got) {
+			t.Fatalf("#%d invalid key: expect %x, got %x", i, c.Key, got)
+		}
+	}
+}
+
+// mustKey exports a 32-byte key at the path relative to n, or of n itself if path is empty.
+func mustKey(t *testing.T, n *keytree.Node, path string) []byte {
+	if path != "" {
+		var err error
+		if n, err = n.Derive(path); err != nil {
+			t.Fatalf("fail to derive %q: %v", path, err)
+		}
+	}
+
+	out, err := n.Key(32)
+	if err != nil {
+		t.Fatalf("fail to export key of %q: %v", n.Path(), err)
+	}
+
+	return out
+}
+
+func mustNew(t *testing.T, seed []byte) *keytree.Node {
+	out, err := keytree.New(seed)
+	if err != nil {
+		t.Fatalf("fail to new tree: %v", err)
+	}
+
+	return out
+}
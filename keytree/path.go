@@ -0,0 +1,49 @@
+package keytree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Component is a component of a derivation path.
+type Component struct {
+	// Name is the name of the component without HardenedSuffix.
+	Name string
+	// Hardened specifies whether the derived node is hardened, i.e. only usable for further
+	// derivation.
+	Hardened bool
+}
+
+// String encodes the component as it appears in paths, which is also what gets absorbed.
+func (c Component) String() string {
+	if c.Hardened {
+		return c.Name + HardenedSuffix
+	}
+	return c.Name
+}
+
+// ParsePath splits the path like "tenant/42/db'/encryption" into components, where a component
+// with HardenedSuffix is hardened. Paths are relative, so they must neither start nor end with
+// Separator, and all components must be non-empty.
+func ParsePath(path string) ([]Component, error) {
+	if path == "" {
+		return nil, ErrInvalidPath
+	}
+
+	parts := strings.Split(path, Separator)
+	out := make([]Component, len(parts))
+	for i, v := range parts {
+		c := Component{Name: strings.TrimSuffix(v, HardenedSuffix)}
+		c.Hardened = len(c.Name) < len(v)
+
+		if c.Name == "" {
+			return nil, fmt.Errorf("%w: empty component #%d of %q", ErrInvalidPath, i, path)
+		} else if strings.HasSuffix(c.Name, HardenedSuffix) {
+			return nil, fmt.Errorf("%w: component %q hardened twice", ErrInvalidPath, v)
+		}
+
+		out[i] = c
+	}
+
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package keywrap
+
+import (
+	"strconv"
+
+	"github.com/sammyne/strobe/siv"
+)
+
+// Proto is the proto absorbed ahead of the header of wrapped keys as additional data.
+const Proto = "github.com/sammyne/strobe/keywrap"
+
+// Version is the version of the format.
+const Version = 1
+
+const (
+	// KEKSize is the size of key-encryption keys in bytes.
+	KEKSize = siv.KeySize
+	// MaxKEKIDLen is the maximum length of KEK identifiers in bytes.
+	MaxKEKIDLen = 255
+	// MaxKeySize is the maximum size of wrapped keys in bytes.
+	MaxKeySize = 1024
+	// TagSize is the size of the MAC, i.e. the synthetic IV, of wrapped keys in bytes.
+	TagSize = siv.TagSize
+)
+
+// PEMType is the type of PEM blocks of wrapped keys.
+const PEMType = "STROBE WRAPPED KEY"
+
+// KeyType is the type of wrapped keys, which tells receivers what the key is for.
+type KeyType uint8
+
+// Types of wrapped keys.
+const (
+	KeyTypeGeneric KeyType = iota + 1
+	KeyTypeAES
+	KeyTypeChaCha20Poly1305
+	KeyTypeHMAC
+	KeyTypeStrobe
+)
+
+var keyTypeNames = map[KeyType]string{
+	KeyTypeGeneric:          "generic",
+	KeyTypeAES:              "AES",
+	KeyTypeChaCha20Poly1305: "ChaCha20-Poly1305",
+	KeyTypeHMAC:             "HMAC",
+	KeyTypeStrobe:           "STROBE",
+}
+
+// String returns the name of the key type.
+func (t KeyType) String() string {
+	if v, ok := keyTypeNames[t]; ok {
+		return v
+	}
+	return "KeyType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// headerLen is the length of the fixed part of the header, i.e. the 1-byte version, 1-byte key
+// type, 2-byte little-endian key size and 1-byte length of the KEK identifier.
+const headerLen = 5
@@ -0,0 +1,20 @@
+package keywrap
+
+import "errors"
+
+var (
+	// ErrInvalidKEK is the error returned when the key-encryption key isn't KEKSize bytes.
+	ErrInvalidKEK = errors.New("invalid key-encryption key")
+	// ErrInvalidKEKID is the error returned by Wrap when the KEK identifier exceeds MaxKEKIDLen.
+	ErrInvalidKEKID = errors.New("invalid KEK identifier")
+	// ErrInvalidKeySize is the error returned by Wrap when the key is empty or exceeds MaxKeySize.
+	ErrInvalidKeySize = errors.New("invalid key size")
+	// ErrInvalidKeyType is the error returned when the key type is unknown.
+	ErrInvalidKeyType = errors.New("invalid key type")
+	// ErrInvalidPEM is the error returned by DecodePEM when the data isn't a PEM block of PEMType.
+	ErrInvalidPEM = errors.New("invalid PEM block")
+	// ErrMalformed is the error returned when the wrapped key can't be decoded.
+	ErrMalformed = errors.New("malformed wrapped key")
+	// ErrUnsupportedVersion is the error returned when the wrapped key is of an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+)
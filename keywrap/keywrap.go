@@ -0,0 +1,138 @@
+// Package keywrap implements a deterministic key wrapping format for shipping data-encryption keys
+// encrypted under key-encryption keys (KEKs), built on the SIV mode of package siv, so no nonce is
+// required.
+//
+// A wrapped key is laid out as
+//
+//	version (1) || key type (1) || key size (2) || KEK ID length (1) || KEK ID || tag (TagSize) || ciphertext
+//
+// where the tag is the synthetic IV over the key, with Proto and the header as additional data.
+// Unwrap authenticates the header and the key before releasing any key bytes. Wrapped keys may be
+// further encoded as PEM blocks of PEMType.
+package keywrap
+
+import (
+	"encoding/binary"
+	"encoding/pem"
+
+	"github.com/sammyne/strobe/siv"
+)
+
+// Key is an unwrapped key.
+type Key struct {
+	// Type is the type of the key.
+	Type KeyType
+	// KEKID identifies the key-encryption key the key was wrapped with.
+	KEKID string
+	// Material is the key itself.
+	Material []byte
+}
+
+// DecodePEM decodes the wrapped key from the PEM block of PEMType, ignoring data around it.
+func DecodePEM(data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PEMType {
+		return nil, ErrInvalidPEM
+	}
+
+	return block.Bytes, nil
+}
+
+// EncodePEM encodes the wrapped key as a PEM block of PEMType.
+func EncodePEM(wrapped []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: PEMType, Bytes: wrapped})
+}
+
+// KEKID returns the identifier of the KEK the key is wrapped with, without verifying it, so that
+// receivers can pick the KEK to unwrap the key with.
+func KEKID(wrapped []byte) (string, error) {
+	h, _, err := parse(wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	return h.kekID, nil
+}
+
+// Unwrap decrypts the wrapped key with the KEK. No key bytes are released unless the wrapped key
+// is authentic.
+func Unwrap(kek, wrapped []byte) (*Key, error) {
+	if len(kek) != KEKSize {
+		return nil, ErrInvalidKEK
+	}
+
+	h, body, err := parse(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := siv.NewDeterministic(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	material, err := aead.Open(nil, nil, body, additionalData(wrapped[:len(wrapped)-len(body)]))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Type: h.typ, KEKID: h.kekID, Material: material}, nil
+}
+
+// Wrap encrypts the key of the given type with the KEK identified by kekID. Wrapping the same key
+// under the same KEK and identifier always produces the same output.
+func Wrap(kek []byte, kekID string, typ KeyType, key []byte) ([]byte, error) {
+	if len(kek) != KEKSize {
+		return nil, ErrInvalidKEK
+	} else if len(kekID) > MaxKEKIDLen {
+		return nil, ErrInvalidKEKID
+	} else if _, ok := keyTypeNames[typ]; !ok {
+		return nil, ErrInvalidKeyType
+	} else if len(key) == 0 || len(key) > MaxKeySize {
+		return nil, ErrInvalidKeySize
+	}
+
+	out := make([]byte, headerLen, headerLen+len(kekID)+TagSize+len(key))
+	out[0], out[1] = Version, byte(typ)
+	binary.LittleEndian.PutUint16(out[2:4], uint16(len(key)))
+	out[4] = byte(len(kekID))
+	out = append(out, kekID...)
+
+	aead, err := siv.NewDeterministic(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(out, nil, key, additionalData(out)), nil
+}
+
+type header struct {
+	typ   KeyType
+	kekID string
+}
+
+// parse decodes the header of the wrapped key, and returns the rest, i.e. tag || ciphertext.
+func parse(wrapped []byte) (*header, []byte, error) {
+	if len(wrapped) < headerLen {
+		return nil, nil, ErrMalformed
+	} else if wrapped[0] != Version {
+		return nil, nil, ErrUnsupportedVersion
+	}
+
+	h := &header{typ: KeyType(wrapped[1])}
+	if _, ok := keyTypeNames[h.typ]; !ok {
+		return nil, nil, ErrInvalidKeyType
+	}
+
+	size, idLen := int(binary.LittleEndian.Uint16(wrapped[2:4])), int(wrapped[4])
+	if size == 0 || size > MaxKeySize || len(wrapped) != headerLen+idLen+TagSize+size {
+		return nil, nil, ErrMalformed
+	}
+	h.kekID = string(wrapped[headerLen : headerLen+idLen])
+
+	return h, wrapped[headerLen+idLen:], nil
+}
+
+func additionalData(header []byte) []byte {
+	return append([]byte(Proto), header...)
+}
@@ -0,0 +1,56 @@
+package merlin
+
+import (
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// TranscriptRngBuilder constructs a TranscriptRng by rekeying a forked transcript with the
+// prover's secrets and an external source of randomness.
+type TranscriptRngBuilder struct {
+	s *strobe.Strobe
+}
+
+// TranscriptRng is an RNG providing synthetic randomness to the prover, which is bound to the
+// transcript, the witnesses and the external randomness.
+type TranscriptRng struct {
+	s *strobe.Strobe
+}
+
+// Finalize rekeys the builder with 32 bytes read from rng, and returns the resultant
+// TranscriptRng. The builder shouldn't be used any more afterwards.
+func (b *TranscriptRngBuilder) Finalize(rng io.Reader) (*TranscriptRng, error) {
+	var randomBytes [32]byte
+	if _, err := io.ReadFull(rng, randomBytes[:]); err != nil {
+		return nil, err
+	}
+
+	metaAD(b.s, []byte("rng"), false)
+	if err := b.s.KEY(randomBytes[:], false); err != nil {
+		return nil, err
+	}
+
+	return &TranscriptRng{s: b.s}, nil
+}
+
+// RekeyWithWitnessBytes rekeys the transcript using the provided witness data, with the label
+// as metadata about the witness.
+func (b *TranscriptRngBuilder) RekeyWithWitnessBytes(label string, witness []byte) *TranscriptRngBuilder {
+	metaAD(b.s, []byte(label), false)
+	metaAD(b.s, encodeLen(len(witness)), true)
+	// KEY works in place, so copy to keep the caller's witness intact
+	must(b.s.KEY(append([]byte{}, witness...), false))
+
+	return b
+}
+
+// Read fills p with pseudorandom bytes, which implements io.Reader and never fails.
+func (r *TranscriptRng) Read(p []byte) (int, error) {
+	metaAD(r.s, encodeLen(len(p)), false)
+	if err := r.s.PRF(p, false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,69 @@
+package merlin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sammyne/strobe/merlin"
+)
+
+func TestTranscriptRng_Bound(t *testing.T) {
+	newRng := func(protocol, witness string, seed byte) []byte {
+		tr := merlin.NewTranscript(protocol)
+		tr.AppendU64("step", 1)
+
+		rng, err := tr.BuildRng().
+			RekeyWithWitnessBytes("witness", []byte(witness)).
+			Finalize(bytes.NewReader(bytes.Repeat([]byte{seed}, 32)))
+		if err != nil {
+			t.Fatalf("fail to finalize rng: %v", err)
+		}
+
+		out := make([]byte, 32)
+		if _, err := rng.Read(out); err != nil {
+			t.Fatalf("fail to read rng: %v", err)
+		}
+
+		return out
+	}
+
+	expect := newRng("protocol", "secret", 0)
+	if got := newRng("protocol", "secret", 0); !bytes.Equal(expect, got) {
+		t.Fatalf("non-deterministic rng: expect %x, got %x", expect, got)
+	}
+
+	testVector := []struct {
+		protocol string
+		witness  string
+		seed     byte
+	}{
+		{"another protocol", "secret", 0},
+		{"protocol", "another secret", 0},
+		{"protocol", "secret", 1},
+	}
+
+	for i, c := range testVector {
+		if got := newRng(c.protocol, c.witness, c.seed); bytes.Equal(expect, got) {
+			t.Fatalf("#%d rng isn't bound to inputs", i)
+		}
+	}
+}
+
+func TestTranscriptRngBuilder_Finalize(t *testing.T) {
+	tr := merlin.NewTranscript("protocol")
+
+	if _, err := tr.BuildRng().Finalize(bytes.NewReader(nil)); err == nil {
+		t.Fatal("expect error for exhausted randomness")
+	}
+}
+
+func TestTranscriptRngBuilder_RekeyWithWitnessBytes(t *testing.T) {
+	tr := merlin.NewTranscript("protocol")
+
+	witness := []byte("secret")
+	tr.BuildRng().RekeyWithWitnessBytes("witness", witness)
+
+	if string(witness) != "secret" {
+		t.Fatalf("witness is modified: %q", witness)
+	}
+}
@@ -0,0 +1,94 @@
+// Package merlin implements Merlin transcripts, compatible with the Rust merlin crate
+// (https://merlin.cool).
+//
+// A Merlin transcript is a STROBE-128 instance with the proto "Merlin v1.0", where every message
+// is framed by a meta-AD of its label and little-endian 32-bit length.
+package merlin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/sammyne/strobe"
+)
+
+// MerlinProtocolLabel is the STROBE proto of all Merlin transcripts.
+const MerlinProtocolLabel = "Merlin v1.0"
+
+// Transcript is a public-coin argument transcript, which can be used to make interactive proofs
+// non-interactive via the Fiat-Shamir transform.
+type Transcript struct {
+	s *strobe.Strobe
+}
+
+// AppendMessage appends a prover's message to the transcript. The label parameter is metadata
+// about the message, and is also appended to the transcript.
+func (t *Transcript) AppendMessage(label string, message []byte) {
+	metaAD(t.s, []byte(label), false)
+	metaAD(t.s, encodeLen(len(message)), true)
+	must(t.s.AD(message, &strobe.Options{}))
+}
+
+// AppendU64 is a convenience method for appending a uint64 encoded in little-endian to the
+// transcript.
+func (t *Transcript) AppendU64(label string, x uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	t.AppendMessage(label, buf[:])
+}
+
+// BuildRng forks the current state of the transcript to construct an RNG whose output is bound to
+// the current transcript state as well as prover's secrets.
+func (t *Transcript) BuildRng() *TranscriptRngBuilder {
+	return &TranscriptRngBuilder{s: t.s.Clone()}
+}
+
+// ChallengeBytes fills dest with the verifier's challenge bytes. The label parameter is metadata
+// about the challenge, and is also appended to the transcript.
+func (t *Transcript) ChallengeBytes(label string, dest []byte) {
+	metaAD(t.s, []byte(label), false)
+	metaAD(t.s, encodeLen(len(dest)), true)
+	must(t.s.PRF(dest, false))
+}
+
+// Clone returns a deep copy of the transcript.
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{s: t.s.Clone()}
+}
+
+// NewTranscript initializes a new transcript with the supplied label, which is used as a domain
+// separator.
+func NewTranscript(label string) *Transcript {
+	s, err := strobe.New(MerlinProtocolLabel, strobe.Bit128)
+	must(err)
+
+	out := &Transcript{s: s}
+	out.AppendMessage("dom-sep", []byte(label))
+
+	return out
+}
+
+// encodeLen encodes the length as little-endian uint32, which panics as the merlin crate does if
+// the length overflows.
+func encodeLen(n int) []byte {
+	if uint64(n) > math.MaxUint32 {
+		panic(fmt.Sprintf("message length %d overflows uint32", n))
+	}
+
+	var out [4]byte
+	binary.LittleEndian.PutUint32(out[:], uint32(n))
+	return out[:]
+}
+
+func metaAD(s *strobe.Strobe, data []byte, more bool) {
+	must(s.AD(data, &strobe.Options{Meta: true, Streaming: more}))
+}
+
+// must panics on errors, which never happens for operations used by merlin but guards against
+// misuse.
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
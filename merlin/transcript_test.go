@@ -0,0 +1,44 @@
+package merlin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sammyne/strobe/merlin"
+)
+
+// Test vectors are borrowed from the merlin crate and its Go port.
+func TestTranscript_Simple(t *testing.T) {
+	const expect = "d5a21972d0d5fe320c0d263fac7fffb8145aa640af6e9bca177c03c7efcf0615"
+
+	tr := merlin.NewTranscript("test protocol")
+	tr.AppendMessage("some label", []byte("some data"))
+
+	var challenge [32]byte
+	tr.ChallengeBytes("challenge", challenge[:])
+
+	if got := hex.EncodeToString(challenge[:]); expect != got {
+		t.Fatalf("invalid challenge: expect %s, got %s", expect, got)
+	}
+}
+
+func TestTranscript_Complex(t *testing.T) {
+	const expect = "a8c933f54fae76e3f9bea93648c1308e7dfa2152dd51674ff3ca438351cf003c"
+
+	tr := merlin.NewTranscript("test protocol")
+	tr.AppendMessage("step1", []byte("some data"))
+
+	data := bytes.Repeat([]byte{99}, 1024)
+
+	var challenge [32]byte
+	for i := 0; i < 32; i++ {
+		tr.ChallengeBytes("challenge", challenge[:])
+		tr.AppendMessage("bigdata", data)
+		tr.AppendMessage("challengedata", challenge[:])
+	}
+
+	if got := hex.EncodeToString(challenge[:]); expect != got {
+		t.Fatalf("invalid challenge: expect %s, got %s", expect, got)
+	}
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import "strconv"
+
+// Op is the kind of STROBE operation run by a step.
+type Op uint8
+
+// Operations of STROBE, where the transport operations CLR, ENC and MAC are sent by one role and
+// received by the other.
+const (
+	OpAD Op = iota + 1
+	OpKEY
+	OpCLR
+	OpENC
+	OpMAC
+	OpPRF
+	OpRATCHET
+)
+
+var opNames = map[Op]string{
+	OpAD:      "AD",
+	OpKEY:     "KEY",
+	OpCLR:     "CLR",
+	OpENC:     "ENC",
+	OpMAC:     "MAC",
+	OpPRF:     "PRF",
+	OpRATCHET: "RATCHET",
+}
+
+// String returns the name of the operation as in the STROBE specification.
+func (op Op) String() string {
+	if v, ok := opNames[op]; ok {
+		return v
+	}
+	return "Op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// isOutput tells whether the operation produces a fixed-length output instead of taking data.
+func (op Op) isOutput() bool {
+	return op == OpMAC || op == OpPRF || op == OpRATCHET
+}
+
+// isTransport tells whether the operation is sent by one role and received by the other.
+func (op Op) isTransport() bool {
+	return op == OpCLR || op == OpENC || op == OpMAC
+}
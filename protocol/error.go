@@ -0,0 +1,27 @@
+package protocol
+
+import "errors"
+
+var (
+	// ErrInvalidLength is the error returned when the data of a step violates its length
+	// constraint.
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrInvalidProtocol is the error returned when a protocol definition is malformed.
+	ErrInvalidProtocol = errors.New("invalid protocol")
+	// ErrInvalidRole is the error returned when the role is neither strobe.Initiator nor
+	// strobe.Responder.
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrProtocolComplete is the error returned when an operation is called after all steps have
+	// run.
+	ErrProtocolComplete = errors.New("protocol complete")
+	// ErrProtocolIncomplete is the error returned by Runtime.Strobe before all steps have run.
+	ErrProtocolIncomplete = errors.New("protocol incomplete")
+	// ErrUnexpectedLabel is the error returned when an operation is called with another label than
+	// that of the next step.
+	ErrUnexpectedLabel = errors.New("unexpected label")
+	// ErrUnexpectedOperation is the error returned when an operation isn't that of the next step.
+	ErrUnexpectedOperation = errors.New("unexpected operation")
+	// ErrWrongDirection is the error returned when a role sends what it should receive, or vice
+	// versa.
+	ErrWrongDirection = errors.New("wrong direction")
+)
@@ -0,0 +1,142 @@
+// Package protocol declares STROBE protocols as sequences of steps, and runs them for either role
+// with the order of operations enforced.
+//
+// A Protocol lists every operation both parties apply to their STROBE instances, with transport
+// operations attributed to the sending role. A Runtime wraps a STROBE instance for one role, and
+// rejects any call out of order, in the wrong direction, with another label or with data of
+// invalid length before touching the state. Each step with a label is framed by a meta-AD of the
+// label, as suggested by the STROBE specification.
+//
+// The String method renders a protocol as text for review.
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sammyne/strobe"
+)
+
+// Protocol is a declared STROBE protocol.
+type Protocol struct {
+	// Name is the proto to initialize STROBE instances with.
+	Name string
+	// Level is the security level, which defaults to strobe.Bit128 if zero.
+	Level strobe.SecurityLevel
+	// Steps are the operations of the protocol in order.
+	Steps []Step
+}
+
+// Step is a single operation of a protocol.
+type Step struct {
+	// Op is the kind of operation.
+	Op Op
+	// From is the sending role of transport operations, i.e. CLR, ENC and MAC, and must be
+	// strobe.Undecided for the others.
+	From strobe.Role
+	// Label is absorbed by a meta-AD before the operation unless empty, and callers must pass it
+	// to Runtime, which catches steps mixed up with each other.
+	Label string
+	// Len is the exact length of data, which is required for MAC, PRF and RATCHET.
+	Len int
+	// MaxLen is the maximum length of data for AD, KEY, CLR and ENC, which is unlimited if zero.
+	MaxLen int
+}
+
+// String renders the protocol with one step per line.
+func (p *Protocol) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "protocol %q (%d-bit)\n", p.Name, p.level())
+	for i, v := range p.Steps {
+		fmt.Fprintf(&b, "%3d. %s\n", i+1, v.String())
+	}
+
+	return b.String()
+}
+
+// Validate checks whether the protocol is well-formed, which fails with ErrInvalidProtocol
+// otherwise.
+func (p *Protocol) Validate() error {
+	if level := p.level(); level != strobe.Bit128 && level != strobe.Bit256 {
+		return fmt.Errorf("%w: unsupported security level %d", ErrInvalidProtocol, level)
+	}
+
+	for i, v := range p.Steps {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("%w: step %d: %s", ErrInvalidProtocol, i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Protocol) level() strobe.SecurityLevel {
+	if p.Level == 0 {
+		return strobe.Bit128
+	}
+	return p.Level
+}
+
+// String renders the step as the direction, operation, label and length constraint.
+func (s Step) String() string {
+	direction := "      "
+	switch s.From {
+	case strobe.Initiator:
+		direction = "I -> R"
+	case strobe.Responder:
+		direction = "R -> I"
+	}
+
+	out := fmt.Sprintf("%s %-7s %q", direction, s.Op, s.Label)
+	switch {
+	case s.Len > 0:
+		out += fmt.Sprintf(" len=%d", s.Len)
+	case s.MaxLen > 0:
+		out += fmt.Sprintf(" len<=%d", s.MaxLen)
+	}
+
+	return out
+}
+
+// checkLen checks the length of data against the constraint of the step.
+func (s *Step) checkLen(n int) error {
+	if (s.Len > 0 && n != s.Len) || (s.MaxLen > 0 && n > s.MaxLen) {
+		return fmt.Errorf("%w: step %q expects %s, got %d bytes", ErrInvalidLength, s.Label, s.lenString(), n)
+	}
+
+	return nil
+}
+
+func (s *Step) lenString() string {
+	if s.Len > 0 {
+		return fmt.Sprintf("%d bytes", s.Len)
+	}
+	return fmt.Sprintf("at most %d bytes", s.MaxLen)
+}
+
+func (s *Step) validate() error {
+	if _, ok := opNames[s.Op]; !ok {
+		return fmt.Errorf("unknown operation %d", s.Op)
+	}
+
+	if s.Op.isTransport() {
+		if s.From != strobe.Initiator && s.From != strobe.Responder {
+			return fmt.Errorf("%s must be sent by initiator or responder", s.Op)
+		}
+	} else if s.From != strobe.Undecided {
+		return fmt.Errorf("%s isn't sent", s.Op)
+	}
+
+	switch {
+	case s.Len < 0 || s.MaxLen < 0:
+		return errors.New("negative length")
+	case s.Op.isOutput() && (s.Len == 0 || s.MaxLen != 0):
+		return fmt.Errorf("%s requires an exact length", s.Op)
+	case s.Len > 0 && s.MaxLen > 0:
+		return errors.New("both exact and maximum lengths are set")
+	}
+
+	return nil
+}
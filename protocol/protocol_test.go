@@ -0,0 +1,246 @@
+package protocol_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/protocol"
+)
+
+var hello = &protocol.Protocol{
+	Name: "hello protocol",
+	Steps: []protocol.Step{
+		{Op: protocol.OpAD, Label: "version", MaxLen: 8},
+		{Op: protocol.OpKEY, Label: "psk", Len: 32},
+		{Op: protocol.OpCLR, From: strobe.Initiator, Label: "nonce", Len: 16},
+		{Op: protocol.OpENC, From: strobe.Initiator, Label: "greeting", MaxLen: 64},
+		{Op: protocol.OpMAC, From: strobe.Initiator, Label: "greeting tag", Len: 16},
+		{Op: protocol.OpENC, From: strobe.Responder, Label: "reply"},
+		{Op: protocol.OpMAC, From: strobe.Responder, Label: "reply tag", Len: 16},
+		{Op: protocol.OpRATCHET, Label: "ratchet", Len: 32},
+		{Op: protocol.OpPRF, Label: "session id", Len: 32},
+	},
+}
+
+var psk = bytes.Repeat([]byte{0x42}, 32)
+
+func TestRuntime(t *testing.T) {
+	i, r := mustNewRuntimePair(t, hello)
+
+	for _, v := range []*protocol.Runtime{i, r} {
+		if err := v.AD("version", []byte("v1")); err != nil {
+			t.Fatalf("%d fail to AD: %v", v.Role(), err)
+		}
+		if err := v.KEY("psk", psk); err != nil {
+			t.Fatalf("%d fail to KEY: %v", v.Role(), err)
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if err := i.SendCLR("nonce", nonce); err != nil {
+		t.Fatalf("fail to SendCLR: %v", err)
+	}
+	if err := r.RecvCLR("nonce", nonce); err != nil {
+		t.Fatalf("fail to RecvCLR: %v", err)
+	}
+
+	greeting := mustTransfer(t, i, r, "greeting", []byte("hello"))
+	if string(greeting) != "hello" {
+		t.Fatalf("invalid greeting: expect hello, got %s", greeting)
+	}
+
+	if _, err := r.Strobe(); err != protocol.ErrProtocolIncomplete {
+		t.Fatalf("invalid error: expect %v, got %v", protocol.ErrProtocolIncomplete, err)
+	}
+
+	reply := mustTransfer(t, r, i, "reply", []byte("hi"))
+	if string(reply) != "hi" {
+		t.Fatalf("invalid reply: expect hi, got %s", reply)
+	}
+
+	var ids [][]byte
+	for _, v := range []*protocol.Runtime{i, r} {
+		if err := v.RATCHET("ratchet"); err != nil {
+			t.Fatalf("%d fail to RATCHET: %v", v.Role(), err)
+		}
+
+		id, err := v.PRF("session id")
+		if err != nil {
+			t.Fatalf("%d fail to PRF: %v", v.Role(), err)
+		}
+		ids = append(ids, id)
+
+		if !v.Done() {
+			t.Fatalf("%d should be done", v.Role())
+		}
+		if err := v.AD("version", nil); err != protocol.ErrProtocolComplete {
+			t.Fatalf("invalid error: expect %v, got %v", protocol.ErrProtocolComplete, err)
+		}
+		if _, err := v.Strobe(); err != nil {
+			t.Fatalf("fail to get STROBE: %v", err)
+		}
+	}
+
+	if !bytes.Equal(ids[0], ids[1]) {
+		t.Fatalf("session ids mismatch: %x != %x", ids[0], ids[1])
+	}
+}
+
+func TestRuntime_Misuse(t *testing.T) {
+	i, _ := mustNewRuntimePair(t, hello)
+
+	testVector := []struct {
+		call   func() error
+		expect error
+	}{
+		{func() error { return i.KEY("psk", psk) }, protocol.ErrUnexpectedOperation},
+		{func() error { return i.AD("psk", nil) }, protocol.ErrUnexpectedLabel},
+		{func() error { return i.AD("version", []byte("too long version")) }, protocol.ErrInvalidLength},
+		{func() error { return i.AD("version", []byte("v1")) }, nil},
+		{func() error { return i.KEY("psk", psk[1:]) }, protocol.ErrInvalidLength},
+		{func() error { return i.KEY("psk", psk) }, nil},
+		{func() error { return i.RecvCLR("nonce", make([]byte, 16)) }, protocol.ErrWrongDirection},
+		{func() error { _, err := i.PRF("nonce"); return err }, protocol.ErrUnexpectedOperation},
+		{func() error { return i.SendCLR("nonce", make([]byte, 16)) }, nil},
+	}
+
+	for j, c := range testVector {
+		if err := c.call(); !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", j, c.expect, err)
+		}
+	}
+
+	// rejected calls leave the runtime intact
+	if step, ok := i.Next(); !ok || step.Label != "greeting" {
+		t.Fatalf("invalid next step: %v", step)
+	}
+}
+
+func TestRuntime_Aborted(t *testing.T) {
+	i, r := mustNewRuntimePair(t, hello)
+
+	for _, v := range []*protocol.Runtime{i, r} {
+		if err := v.AD("version", nil); err != nil {
+			t.Fatalf("fail to AD: %v", err)
+		}
+	}
+	if err := i.KEY("psk", psk); err != nil {
+		t.Fatalf("fail to KEY: %v", err)
+	}
+	if err := r.KEY("psk", bytes.Repeat([]byte{0x43}, 32)); err != nil {
+		t.Fatalf("fail to KEY: %v", err)
+	}
+
+	nonce := make([]byte, 16)
+	if err := i.SendCLR("nonce", nonce); err != nil {
+		t.Fatalf("fail to SendCLR: %v", err)
+	}
+	if err := r.RecvCLR("nonce", nonce); err != nil {
+		t.Fatalf("fail to RecvCLR: %v", err)
+	}
+
+	ciphertext, err := i.SendENC("greeting", []byte("hello"))
+	if err != nil {
+		t.Fatalf("fail to SendENC: %v", err)
+	}
+	mac, err := i.SendMAC("greeting tag")
+	if err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+
+	if _, err := r.RecvENC("greeting", ciphertext); err != nil {
+		t.Fatalf("fail to RecvENC: %v", err)
+	}
+	if err := r.RecvMAC("greeting tag", mac); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+
+	// failures stick
+	if _, err := r.SendENC("reply", nil); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+}
+
+func TestProtocol_Validate(t *testing.T) {
+	testVector := []protocol.Step{
+		{Op: 0},
+		{Op: protocol.OpAD, From: strobe.Initiator},
+		{Op: protocol.OpCLR},
+		{Op: protocol.OpMAC, From: strobe.Initiator},
+		{Op: protocol.OpPRF, Len: 16, MaxLen: 16},
+		{Op: protocol.OpAD, Len: 16, MaxLen: 16},
+		{Op: protocol.OpKEY, Len: -1},
+	}
+
+	for i, c := range testVector {
+		p := &protocol.Protocol{Name: "invalid", Steps: []protocol.Step{c}}
+		if err := p.Validate(); !errors.Is(err, protocol.ErrInvalidProtocol) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, protocol.ErrInvalidProtocol, err)
+		}
+	}
+
+	p := &protocol.Protocol{Name: "invalid", Level: 64}
+	if err := p.Validate(); !errors.Is(err, protocol.ErrInvalidProtocol) {
+		t.Fatalf("invalid error for security level: expect %v, got %v", protocol.ErrInvalidProtocol, err)
+	}
+
+	if _, err := protocol.NewRuntime(hello, strobe.Undecided); err != protocol.ErrInvalidRole {
+		t.Fatalf("invalid error for role: expect %v, got %v", protocol.ErrInvalidRole, err)
+	}
+}
+
+func TestProtocol_String(t *testing.T) {
+	const expect = `protocol "hello protocol" (128-bit)
+  1.        AD      "version" len<=8
+  2.        KEY     "psk" len=32
+  3. I -> R CLR     "nonce" len=16
+  4. I -> R ENC     "greeting" len<=64
+  5. I -> R MAC     "greeting tag" len=16
+  6. R -> I ENC     "reply"
+  7. R -> I MAC     "reply tag" len=16
+  8.        RATCHET "ratchet" len=32
+  9.        PRF     "session id" len=32
+`
+
+	if got := hello.String(); got != expect {
+		t.Fatalf("invalid rendering: expect\n%s\ngot\n%s", expect, got)
+	}
+}
+
+func mustNewRuntimePair(t *testing.T, p *protocol.Protocol) (*protocol.Runtime, *protocol.Runtime) {
+	i, err := protocol.NewRuntime(p, strobe.Initiator)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	r, err := protocol.NewRuntime(p, strobe.Responder)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return i, r
+}
+
+// mustTransfer runs the ENC step with label and the MAC step after it from sender to receiver.
+func mustTransfer(t *testing.T, sender, receiver *protocol.Runtime, label string, msg []byte) []byte {
+	ciphertext, err := sender.SendENC(label, msg)
+	if err != nil {
+		t.Fatalf("fail to SendENC %s: %v", label, err)
+	}
+	mac, err := sender.SendMAC(label + " tag")
+	if err != nil {
+		t.Fatalf("fail to SendMAC %s: %v", label, err)
+	}
+
+	plaintext, err := receiver.RecvENC(label, ciphertext)
+	if err != nil {
+		t.Fatalf("fail to RecvENC %s: %v", label, err)
+	}
+	if err := receiver.RecvMAC(label+" tag", mac); err != nil {
+		t.Fatalf("fail to RecvMAC %s: %v", label, err)
+	}
+
+	return plaintext
+}
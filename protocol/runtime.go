@@ -0,0 +1,244 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/sammyne/strobe"
+)
+
+// Runtime runs a protocol for one role over a STROBE instance, which isn't safe for concurrent
+// use.
+//
+// Calls that don't match the next step fail with ErrUnexpectedOperation, ErrWrongDirection,
+// ErrUnexpectedLabel or ErrInvalidLength, wrapped with details of the step, and leave the runtime
+// intact. Once the underlying STROBE operation fails, e.g. with strobe.ErrAuthenticationFailed,
+// the runtime is aborted and all further calls fail with that error.
+type Runtime struct {
+	p    Protocol
+	role strobe.Role
+	s    *strobe.Strobe
+	next int
+	err  error
+}
+
+// NewRuntime validates the protocol and starts running it as the given role, which is either
+// strobe.Initiator or strobe.Responder.
+func NewRuntime(p *Protocol, role strobe.Role) (*Runtime, error) {
+	if role != strobe.Initiator && role != strobe.Responder {
+		return nil, ErrInvalidRole
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	s, err := strobe.New(p.Name, p.level())
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Runtime{p: *p, role: role, s: s}
+	out.p.Steps = append([]Step{}, p.Steps...)
+
+	return out, nil
+}
+
+// AD runs an AD step with data.
+func (r *Runtime) AD(label string, data []byte) error {
+	if err := r.begin(OpAD, false, label, len(data)); err != nil {
+		return err
+	}
+
+	return r.end(r.s.AD(data, &strobe.Options{}))
+}
+
+// Done reports whether all steps have run.
+func (r *Runtime) Done() bool {
+	return r.next >= len(r.p.Steps)
+}
+
+// KEY runs a KEY step with key, which is left unmodified.
+func (r *Runtime) KEY(label string, key []byte) error {
+	if err := r.begin(OpKEY, false, label, len(key)); err != nil {
+		return err
+	}
+
+	return r.end(r.s.KEY(append([]byte{}, key...), false))
+}
+
+// Next returns the next step to run, which is false if all steps have run.
+func (r *Runtime) Next() (Step, bool) {
+	if r.Done() {
+		return Step{}, false
+	}
+	return r.p.Steps[r.next], true
+}
+
+// PRF runs a PRF step, returning the output.
+func (r *Runtime) PRF(label string) ([]byte, error) {
+	if err := r.begin(OpPRF, false, label, -1); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, r.p.Steps[r.next].Len)
+	if err := r.end(r.s.PRF(out, false)); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RATCHET runs a RATCHET step.
+func (r *Runtime) RATCHET(label string) error {
+	if err := r.begin(OpRATCHET, false, label, -1); err != nil {
+		return err
+	}
+
+	return r.end(r.s.RATCHET(r.p.Steps[r.next].Len))
+}
+
+// RecvCLR runs a CLR step sent by the remote party with the received data.
+func (r *Runtime) RecvCLR(label string, data []byte) error {
+	if err := r.begin(OpCLR, false, label, len(data)); err != nil {
+		return err
+	}
+
+	return r.end(r.s.RecvCLR(append([]byte{}, data...), &strobe.Options{}))
+}
+
+// RecvENC runs an ENC step sent by the remote party, returning the decrypted plaintext. The
+// plaintext mustn't be trusted before a following RecvMAC step succeeds.
+func (r *Runtime) RecvENC(label string, ciphertext []byte) ([]byte, error) {
+	if err := r.begin(OpENC, false, label, len(ciphertext)); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := r.s.RecvENC(append([]byte{}, ciphertext...), &strobe.Options{})
+	if err := r.end(err); err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+// RecvMAC runs a MAC step sent by the remote party, checking the received MAC.
+func (r *Runtime) RecvMAC(label string, mac []byte) error {
+	if err := r.begin(OpMAC, false, label, len(mac)); err != nil {
+		return err
+	}
+
+	return r.end(r.s.RecvMAC(append([]byte{}, mac...), &strobe.Options{}))
+}
+
+// Role returns the role run by r.
+func (r *Runtime) Role() strobe.Role {
+	return r.role
+}
+
+// SendCLR runs a CLR step sent by the local party with data.
+func (r *Runtime) SendCLR(label string, data []byte) error {
+	if err := r.begin(OpCLR, true, label, len(data)); err != nil {
+		return err
+	}
+
+	return r.end(r.s.SendCLR(append([]byte{}, data...), &strobe.Options{}))
+}
+
+// SendENC runs an ENC step sent by the local party, returning the ciphertext.
+func (r *Runtime) SendENC(label string, plaintext []byte) ([]byte, error) {
+	if err := r.begin(OpENC, true, label, len(plaintext)); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := r.s.SendENC(append([]byte{}, plaintext...), &strobe.Options{})
+	if err := r.end(err); err != nil {
+		return nil, err
+	}
+
+	return ciphertext, nil
+}
+
+// SendMAC runs a MAC step sent by the local party, returning the MAC.
+func (r *Runtime) SendMAC(label string) ([]byte, error) {
+	if err := r.begin(OpMAC, true, label, -1); err != nil {
+		return nil, err
+	}
+
+	mac := make([]byte, r.p.Steps[r.next].Len)
+	if err := r.end(r.s.SendMAC(mac, &strobe.Options{})); err != nil {
+		return nil, err
+	}
+
+	return mac, nil
+}
+
+// Strobe returns the STROBE instance once all steps have run, so as to go on beyond the
+// protocol. The runtime shouldn't be used any more afterwards.
+func (r *Runtime) Strobe() (*strobe.Strobe, error) {
+	if r.err != nil {
+		return nil, r.err
+	} else if !r.Done() {
+		return nil, ErrProtocolIncomplete
+	}
+
+	return r.s, nil
+}
+
+// begin checks the call against the next step, and frames the step with its label. send is
+// ignored for operations other than CLR, ENC and MAC, and n is the length of data, or negative if
+// the operation takes no data.
+func (r *Runtime) begin(op Op, send bool, label string, n int) error {
+	if r.err != nil {
+		return r.err
+	} else if r.Done() {
+		return ErrProtocolComplete
+	}
+
+	step := &r.p.Steps[r.next]
+	if op != step.Op {
+		return fmt.Errorf("%w: step %d expects %s %q, got %s", ErrUnexpectedOperation, r.next+1, step.Op,
+			step.Label, op)
+	}
+
+	if op.isTransport() && send != (step.From == r.role) {
+		verb := "received"
+		if step.From == r.role {
+			verb = "sent"
+		}
+		return fmt.Errorf("%w: step %d expects %s %q to be %s", ErrWrongDirection, r.next+1, op, step.Label,
+			verb)
+	}
+
+	if label != step.Label {
+		return fmt.Errorf("%w: step %d expects %s %q, got %q", ErrUnexpectedLabel, r.next+1, op, step.Label,
+			label)
+	}
+
+	if n >= 0 {
+		if err := step.checkLen(n); err != nil {
+			return err
+		}
+	}
+
+	if step.Label == "" {
+		return nil
+	}
+
+	if err := r.s.AD([]byte(step.Label), &strobe.Options{Meta: true}); err != nil {
+		r.err = err
+		return err
+	}
+
+	return nil
+}
+
+// end aborts the runtime on err, or advances to the next step otherwise.
+func (r *Runtime) end(err error) error {
+	if err != nil {
+		r.err = err
+		return err
+	}
+
+	r.next++
+	return nil
+}
@@ -0,0 +1,48 @@
+package pskauth
+
+import "sync"
+
+// NonceCache records the nonces seen so far, so as to reject replayed handshakes. Implementations
+// must be safe for concurrent use if shared by concurrent handshakes.
+type NonceCache interface {
+	// Add records the nonce, and reports whether it was absent before.
+	Add(nonce []byte) bool
+}
+
+// memoryCache is a NonceCache in memory, which evicts the oldest nonces beyond its capacity.
+type memoryCache struct {
+	mu     sync.Mutex
+	seen   map[string]struct{}
+	queue  []string
+	oldest int
+}
+
+// NewNonceCache makes a NonceCache in memory, which remembers the last capacity nonces and is safe
+// for concurrent use.
+func NewNonceCache(capacity int) NonceCache {
+	return &memoryCache{seen: make(map[string]struct{}, capacity), queue: make([]string, 0, capacity)}
+}
+
+func (c *memoryCache) Add(nonce []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := string(nonce)
+	if _, ok := c.seen[k]; ok {
+		return false
+	}
+
+	if cap(c.queue) == 0 {
+		return true
+	} else if len(c.queue) < cap(c.queue) {
+		c.queue = append(c.queue, k)
+	} else {
+		// the queue is a ring buffer once full
+		delete(c.seen, c.queue[c.oldest])
+		c.queue[c.oldest] = k
+		c.oldest = (c.oldest + 1) % len(c.queue)
+	}
+	c.seen[k] = struct{}{}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package pskauth
+
+// Proto is the STROBE proto of the handshake transcript.
+const Proto = "github.com/sammyne/strobe/pskauth"
+
+const (
+	// MACLen is the length of proofs in bytes.
+	MACLen = 32
+	// MinPSKLen is the minimum length of pre-shared keys in bytes.
+	MinPSKLen = 16
+	// NonceLen is the length of nonces in bytes.
+	NonceLen = 32
+	// SessionKeyLen is the length of the key ratcheted into the output session in bytes.
+	SessionKeyLen = 32
+)
+
+// Lengths of the messages of the handshake.
+const (
+	challengeLen    = NonceLen
+	responseLen     = NonceLen + MACLen
+	confirmationLen = MACLen
+)
+
+// Labels framing the operations of the transcript.
+const (
+	labelInitiatorIdentity = "initiator identity"
+	labelInitiatorNonce    = "initiator nonce"
+	labelInitiatorProof    = "initiator proof"
+	labelPSK               = "psk"
+	labelResponderIdentity = "responder identity"
+	labelResponderNonce    = "responder nonce"
+	labelResponderProof    = "responder proof"
+	labelSession           = "session"
+)
+
+// Steps of the handshake, counted by messages processed so far.
+const (
+	stepChallenge = iota
+	stepResponse
+	stepConfirmation
+	stepDone
+)
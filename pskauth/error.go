@@ -0,0 +1,33 @@
+package pskauth
+
+import "errors"
+
+var (
+	// ErrHandshakeComplete is the error returned when a message is written or read after the
+	// handshake completes.
+	ErrHandshakeComplete = errors.New("handshake complete")
+	// ErrHandshakeIncomplete is the error returned by Session before the handshake completes.
+	ErrHandshakeIncomplete = errors.New("handshake incomplete")
+	// ErrInitiatorAuthFailed is the error returned by the responder when the proof of the
+	// initiator in the confirmation is invalid.
+	ErrInitiatorAuthFailed = errors.New("initiator authentication failed")
+	// ErrInvalidChallenge is the error returned when the challenge, i.e. the first message, is
+	// malformed.
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	// ErrInvalidConfirmation is the error returned when the confirmation, i.e. the last message,
+	// is malformed.
+	ErrInvalidConfirmation = errors.New("invalid confirmation")
+	// ErrInvalidPSK is the error returned when the pre-shared key is shorter than MinPSKLen.
+	ErrInvalidPSK = errors.New("invalid pre-shared key")
+	// ErrInvalidResponse is the error returned when the response, i.e. the second message, is
+	// malformed.
+	ErrInvalidResponse = errors.New("invalid response")
+	// ErrOutOfTurn is the error returned when a party writes a message it should read, or vice
+	// versa.
+	ErrOutOfTurn = errors.New("out of turn")
+	// ErrReplayedNonce is the error returned when a nonce has been seen before.
+	ErrReplayedNonce = errors.New("replayed nonce")
+	// ErrResponderAuthFailed is the error returned by the initiator when the proof of the
+	// responder in the response is invalid.
+	ErrResponderAuthFailed = errors.New("responder authentication failed")
+)
@@ -0,0 +1,311 @@
+// Package pskauth implements mutual challenge-response authentication with a pre-shared key, for
+// service-to-service authentication without PKI.
+//
+// Both parties key a STROBE instance with the pre-shared key and their identities, then run three
+// messages:
+//
+//	initiator -> responder: challenge    = nonce_I
+//	responder -> initiator: response     = nonce_R || proof_R
+//	initiator -> responder: confirmation = proof_I
+//
+// Nonces go through SendCLR/RecvCLR, and proofs through SendMAC/RecvMAC, which binds each proof to
+// the role of its sender and to both nonces. Once the handshake completes, the transcript is
+// ratcheted into the output session shared by both parties.
+//
+// The handshake is a state machine which only produces and consumes messages, leaving the
+// transport to callers. Nonces of the remote party are recorded in a NonceCache, if any, once the
+// proof of the remote party verifies, so that replayed handshakes are rejected without forged
+// messages filling the cache. A nonce reflected back to its sender is always rejected.
+package pskauth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Config specifies the handshake to run.
+type Config struct {
+	// Initiator specifies whether the local party is the initiator.
+	Initiator bool
+	// PSK is the pre-shared key of at least MinPSKLen bytes.
+	PSK []byte
+	// Identity is the identity of the local party, which may be empty.
+	Identity []byte
+	// PeerIdentity is the expected identity of the remote party, which may be empty.
+	PeerIdentity []byte
+	// NonceCache records the nonces of remote parties, and should be shared by all handshakes with
+	// the same PSK. Replays across handshakes are not detected if nil.
+	NonceCache NonceCache
+	// Rand is the source of randomness for nonces, which defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Handshake tracks the progress of a handshake for one party, which isn't safe for concurrent use.
+//
+// Once an error occurs other than ErrHandshakeComplete, ErrHandshakeIncomplete and ErrOutOfTurn,
+// the handshake is aborted and all further operations fail with that error.
+type Handshake struct {
+	s         *strobe.Strobe
+	initiator bool
+	step      int
+	nonce     []byte
+	peerNonce []byte
+
+	cache NonceCache
+	rand  io.Reader
+	err   error
+}
+
+// NewHandshake starts a handshake as specified by config.
+func NewHandshake(config *Config) (*Handshake, error) {
+	if len(config.PSK) < MinPSKLen {
+		return nil, ErrInvalidPSK
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	initiatorID, responderID := config.Identity, config.PeerIdentity
+	if !config.Initiator {
+		initiatorID, responderID = responderID, initiatorID
+	}
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelInitiatorIdentity, initiatorID},
+		{labelResponderIdentity, responderID},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.AD([]byte(labelPSK), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, config.PSK...), false); err != nil {
+		return nil, err
+	}
+
+	h := &Handshake{s: s, initiator: config.Initiator, cache: config.NonceCache, rand: config.Rand}
+	if h.rand == nil {
+		h.rand = rand.Reader
+	}
+
+	return h, nil
+}
+
+// Finished reports whether all messages of the handshake have been processed.
+func (h *Handshake) Finished() bool {
+	return h.step == stepDone
+}
+
+// ReadMessage processes the next message from the remote party.
+func (h *Handshake) ReadMessage(msg []byte) error {
+	if h.err != nil {
+		return h.err
+	} else if h.Finished() {
+		return ErrHandshakeComplete
+	} else if h.isMyTurn() {
+		return ErrOutOfTurn
+	}
+
+	var err error
+	switch h.step {
+	case stepChallenge:
+		err = h.readChallenge(msg)
+	case stepResponse:
+		err = h.readResponse(msg)
+	case stepConfirmation:
+		err = h.readConfirmation(msg)
+	}
+
+	if err != nil {
+		h.abort(err)
+		return err
+	}
+	h.step++
+
+	return nil
+}
+
+// Session returns the STROBE instance shared with the remote party once the handshake completes,
+// where both parties keep their roles of the handshake. The handshake shouldn't be used any more
+// afterwards.
+func (h *Handshake) Session() (*strobe.Strobe, error) {
+	if h.err != nil {
+		return nil, h.err
+	} else if !h.Finished() {
+		return nil, ErrHandshakeIncomplete
+	} else if h.s == nil {
+		return nil, ErrHandshakeComplete
+	}
+
+	s := h.s
+	h.s = nil
+
+	if err := s.AD([]byte(labelSession), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.RATCHET(SessionKeyLen); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// WriteMessage produces the next message to the remote party.
+func (h *Handshake) WriteMessage() ([]byte, error) {
+	if h.err != nil {
+		return nil, h.err
+	} else if h.Finished() {
+		return nil, ErrHandshakeComplete
+	} else if !h.isMyTurn() {
+		return nil, ErrOutOfTurn
+	}
+
+	var (
+		out []byte
+		err error
+	)
+	switch h.step {
+	case stepChallenge:
+		out, err = h.writeNonce(labelInitiatorNonce)
+	case stepResponse:
+		if out, err = h.writeNonce(labelResponderNonce); err == nil {
+			out, err = h.writeProof(out, labelResponderProof)
+		}
+	case stepConfirmation:
+		out, err = h.writeProof(nil, labelInitiatorProof)
+	}
+
+	if err != nil {
+		h.abort(err)
+		return nil, err
+	}
+	h.step++
+
+	return out, nil
+}
+
+func (h *Handshake) abort(err error) {
+	h.s, h.err = nil, err
+}
+
+// isMyTurn tells whether the local party writes the message of the current step, where the
+// initiator writes the challenge and the confirmation.
+func (h *Handshake) isMyTurn() bool {
+	return h.initiator == (h.step%2 == 0)
+}
+
+func (h *Handshake) readChallenge(msg []byte) error {
+	if len(msg) != challengeLen {
+		return ErrInvalidChallenge
+	}
+
+	return h.readNonce(labelInitiatorNonce, msg)
+}
+
+func (h *Handshake) readConfirmation(msg []byte) error {
+	if len(msg) != confirmationLen {
+		return ErrInvalidConfirmation
+	}
+
+	if err := h.readProof(labelInitiatorProof, msg); err == strobe.ErrAuthenticationFailed {
+		return ErrInitiatorAuthFailed
+	} else if err != nil {
+		return err
+	}
+
+	return h.recordPeerNonce()
+}
+
+// readNonce absorbs the nonce of the remote party, which is rejected if it is the local nonce
+// reflected back. The nonce is checked against the cache by recordPeerNonce only once the proof of
+// the remote party verifies.
+func (h *Handshake) readNonce(label string, nonce []byte) error {
+	if bytes.Equal(nonce, h.nonce) {
+		return ErrReplayedNonce
+	}
+	h.peerNonce = append([]byte{}, nonce...)
+
+	if err := h.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+	return h.s.RecvCLR(append([]byte{}, nonce...), &strobe.Options{})
+}
+
+func (h *Handshake) readProof(label string, proof []byte) error {
+	if err := h.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+	return h.s.RecvMAC(append([]byte{}, proof...), &strobe.Options{})
+}
+
+func (h *Handshake) readResponse(msg []byte) error {
+	if len(msg) != responseLen {
+		return ErrInvalidResponse
+	}
+
+	if err := h.readNonce(labelResponderNonce, msg[:NonceLen]); err != nil {
+		return err
+	}
+
+	if err := h.readProof(labelResponderProof, msg[NonceLen:]); err == strobe.ErrAuthenticationFailed {
+		return ErrResponderAuthFailed
+	} else if err != nil {
+		return err
+	}
+
+	return h.recordPeerNonce()
+}
+
+// recordPeerNonce records the nonce of the remote party in the cache, if any, and rejects it if
+// seen before.
+func (h *Handshake) recordPeerNonce() error {
+	if h.cache != nil && !h.cache.Add(h.peerNonce) {
+		return ErrReplayedNonce
+	}
+
+	return nil
+}
+
+func (h *Handshake) writeNonce(label string) ([]byte, error) {
+	h.nonce = make([]byte, NonceLen)
+	if _, err := io.ReadFull(h.rand, h.nonce); err != nil {
+		return nil, err
+	}
+
+	if err := h.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := h.s.SendCLR(append([]byte{}, h.nonce...), &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, h.nonce...), nil
+}
+
+func (h *Handshake) writeProof(out []byte, label string) ([]byte, error) {
+	if err := h.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	proof := make([]byte, MACLen)
+	if err := h.s.SendMAC(proof, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return append(out, proof...), nil
+}
@@ -0,0 +1,253 @@
+package pskauth_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/pskauth"
+)
+
+var psk = bytes.Repeat([]byte{0x42}, 32)
+
+func TestHandshake(t *testing.T) {
+	cache := pskauth.NewNonceCache(16)
+	i, r := mustNewHandshakePair(t, newConfig(true, psk, cache), newConfig(false, psk, cache))
+
+	if _, err := r.WriteMessage(); err != pskauth.ErrOutOfTurn {
+		t.Fatalf("invalid error: expect %v, got %v", pskauth.ErrOutOfTurn, err)
+	}
+	if _, err := i.Session(); err != pskauth.ErrHandshakeIncomplete {
+		t.Fatalf("invalid error: expect %v, got %v", pskauth.ErrHandshakeIncomplete, err)
+	}
+
+	for step, c := range []struct{ from, to *pskauth.Handshake }{{i, r}, {r, i}, {i, r}} {
+		msg, err := c.from.WriteMessage()
+		if err != nil {
+			t.Fatalf("#%d fail to write: %v", step, err)
+		}
+		if err := c.to.ReadMessage(msg); err != nil {
+			t.Fatalf("#%d fail to read: %v", step, err)
+		}
+	}
+
+	if !i.Finished() || !r.Finished() {
+		t.Fatal("handshake should be finished")
+	}
+	if _, err := i.WriteMessage(); err != pskauth.ErrHandshakeComplete {
+		t.Fatalf("invalid error: expect %v, got %v", pskauth.ErrHandshakeComplete, err)
+	}
+
+	si, err := i.Session()
+	if err != nil {
+		t.Fatalf("fail to get session of initiator: %v", err)
+	}
+	sr, err := r.Session()
+	if err != nil {
+		t.Fatalf("fail to get session of responder: %v", err)
+	}
+
+	mac := make([]byte, 16)
+	if err := sr.SendMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+	if err := si.RecvMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("sessions mismatch: %v", err)
+	}
+}
+
+func TestHandshake_Failures(t *testing.T) {
+	otherIdentity := newConfig(false, psk, nil)
+	otherIdentity.Identity = []byte("mallory")
+
+	truncate := func(msg []byte) []byte { return msg[:len(msg)-1] }
+
+	testVector := []struct {
+		name      string
+		responder *pskauth.Config
+		tamper    []func([]byte) []byte
+		expect    error
+	}{
+		{"wrong psk", newConfig(false, bytes.Repeat([]byte{0x43}, 32), nil), nil, pskauth.ErrResponderAuthFailed},
+		{"wrong identity", otherIdentity, nil, pskauth.ErrResponderAuthFailed},
+		{"tampered challenge", newConfig(false, psk, nil), []func([]byte) []byte{flipLast}, pskauth.ErrResponderAuthFailed},
+		{"tampered response", newConfig(false, psk, nil), []func([]byte) []byte{nil, flipLast}, pskauth.ErrResponderAuthFailed},
+		{"tampered confirmation", newConfig(false, psk, nil), []func([]byte) []byte{nil, nil, flipLast}, pskauth.ErrInitiatorAuthFailed},
+		{"short challenge", newConfig(false, psk, nil), []func([]byte) []byte{truncate}, pskauth.ErrInvalidChallenge},
+		{"short response", newConfig(false, psk, nil), []func([]byte) []byte{nil, truncate}, pskauth.ErrInvalidResponse},
+		{"short confirmation", newConfig(false, psk, nil), []func([]byte) []byte{nil, nil, truncate}, pskauth.ErrInvalidConfirmation},
+	}
+
+	for _, c := range testVector {
+		i, r := mustNewHandshakePair(t, newConfig(true, psk, nil), c.responder)
+
+		var err error
+		for step, p := range []struct{ from, to *pskauth.Handshake }{{i, r}, {r, i}, {i, r}} {
+			var msg []byte
+			if msg, err = p.from.WriteMessage(); err != nil {
+				t.Fatalf("%s: #%d fail to write: %v", c.name, step, err)
+			}
+			if step < len(c.tamper) && c.tamper[step] != nil {
+				msg = c.tamper[step](msg)
+			}
+
+			if err = p.to.ReadMessage(msg); err != nil {
+				// failures stick
+				if _, err2 := p.to.Session(); err2 != err {
+					t.Fatalf("%s: error isn't sticky: expect %v, got %v", c.name, err, err2)
+				}
+				break
+			}
+		}
+
+		if err != c.expect {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, c.expect, err)
+		}
+	}
+}
+
+func TestHandshake_Replay(t *testing.T) {
+	testVector := []struct {
+		name string
+		// replayer tells whether the initiator or the responder reuses its nonce, whose peer shares
+		// the cache
+		initiator bool
+		// forged tampers the message carrying the proof of the replayer
+		forged []func([]byte) []byte
+		failed error
+	}{
+		{"initiator", true, []func([]byte) []byte{nil, nil, flipLast}, pskauth.ErrInitiatorAuthFailed},
+		{"responder", false, []func([]byte) []byte{nil, flipLast}, pskauth.ErrResponderAuthFailed},
+	}
+
+	for _, c := range testVector {
+		cache := pskauth.NewNonceCache(16)
+		nonce := bytes.Repeat([]byte{0x01}, pskauth.NonceLen)
+
+		// the replayer draws the same nonce every time, as by a broken source of randomness
+		newPair := func() (*pskauth.Handshake, *pskauth.Handshake) {
+			ci, cr := newConfig(true, psk, nil), newConfig(false, psk, nil)
+			replayer, peer := ci, cr
+			if !c.initiator {
+				replayer, peer = cr, ci
+			}
+			replayer.Rand, peer.NonceCache = bytes.NewReader(nonce), cache
+
+			return mustNewHandshakePair(t, ci, cr)
+		}
+
+		// forged proofs don't consume the nonce
+		i, r := newPair()
+		if err := runHandshake(i, r, c.forged); err != c.failed {
+			t.Fatalf("%s: invalid error for forgery: expect %v, got %v", c.name, c.failed, err)
+		}
+
+		i, r = newPair()
+		if err := runHandshake(i, r, nil); err != nil {
+			t.Fatalf("%s: fail to run handshake: %v", c.name, err)
+		}
+
+		i, r = newPair()
+		if err := runHandshake(i, r, nil); err != pskauth.ErrReplayedNonce {
+			t.Fatalf("%s: invalid error for replay: expect %v, got %v", c.name, pskauth.ErrReplayedNonce, err)
+		}
+	}
+
+	// the challenge is reflected back to the initiator as a response
+	i, _ := mustNewHandshakePair(t, newConfig(true, psk, nil), newConfig(false, psk, nil))
+	challenge, err := i.WriteMessage()
+	if err != nil {
+		t.Fatalf("fail to write challenge: %v", err)
+	}
+	response := append(challenge, make([]byte, pskauth.MACLen)...)
+	if err := i.ReadMessage(response); err != pskauth.ErrReplayedNonce {
+		t.Fatalf("invalid error for reflection: expect %v, got %v", pskauth.ErrReplayedNonce, err)
+	}
+}
+
+func TestNewHandshake_InvalidPSK(t *testing.T) {
+	config := newConfig(true, make([]byte, pskauth.MinPSKLen-1), nil)
+	if _, err := pskauth.NewHandshake(config); err != pskauth.ErrInvalidPSK {
+		t.Fatalf("invalid error: expect %v, got %v", pskauth.ErrInvalidPSK, err)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	cache := pskauth.NewNonceCache(2)
+
+	testVector := []struct {
+		nonce  string
+		expect bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", false},
+		{"c", true}, // evicts a
+		{"b", false},
+		{"a", true}, // evicts b
+		{"b", true}, // evicts c
+		{"c", true},
+	}
+
+	for i, c := range testVector {
+		if got := cache.Add([]byte(c.nonce)); got != c.expect {
+			t.Fatalf("#%d invalid result for %s: expect %v, got %v", i, c.nonce, c.expect, got)
+		}
+	}
+}
+
+// flipLast returns a copy of msg with the last bit flipped.
+func flipLast(msg []byte) []byte {
+	out := append([]byte{}, msg...)
+	out[len(out)-1] ^= 1
+	return out
+}
+
+func mustNewHandshakePair(t *testing.T, ci, cr *pskauth.Config) (*pskauth.Handshake, *pskauth.Handshake) {
+	i, err := pskauth.NewHandshake(ci)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	r, err := pskauth.NewHandshake(cr)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return i, r
+}
+
+func newConfig(initiator bool, psk []byte, cache pskauth.NonceCache) *pskauth.Config {
+	config := &pskauth.Config{
+		Initiator:    initiator,
+		PSK:          psk,
+		Identity:     []byte("service-a"),
+		PeerIdentity: []byte("service-b"),
+		NonceCache:   cache,
+	}
+	if !initiator {
+		config.Identity, config.PeerIdentity = config.PeerIdentity, config.Identity
+	}
+
+	return config
+}
+
+// runHandshake runs all messages from i to r and back, where tamper[step], if any, tampers the
+// message of the step, and returns the first error.
+func runHandshake(i, r *pskauth.Handshake, tamper []func([]byte) []byte) error {
+	for step, p := range []struct{ from, to *pskauth.Handshake }{{i, r}, {r, i}, {i, r}} {
+		msg, err := p.from.WriteMessage()
+		if err != nil {
+			return err
+		}
+		if step < len(tamper) && tamper[step] != nil {
+			msg = tamper[step](msg)
+		}
+
+		if err := p.to.ReadMessage(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package schnorr
+
+import "github.com/sammyne/strobe/internal/p256"
+
+// Proto is the STROBE proto of the signature transcript.
+const Proto = "github.com/sammyne/strobe/schnorr/P-256"
+
+const (
+	// PrivateKeySize is the size of private keys in bytes.
+	PrivateKeySize = p256.ScalarSize
+	// PublicKeySize is the size of compressed public keys in bytes.
+	PublicKeySize = p256.PointSize
+	// ScalarSize is the size of scalars in bytes.
+	ScalarSize = p256.ScalarSize
+	// SignatureSize is the size of signatures in bytes.
+	SignatureSize = PublicKeySize + ScalarSize
+)
+
+// Labels framing the operations of the transcript.
+const (
+	labelChallenge  = "challenge"
+	labelCommitment = "commitment"
+	labelHash       = "hash"
+	labelMessage    = "message"
+	labelNonce      = "nonce"
+	labelPublicKey  = "public-key"
+)
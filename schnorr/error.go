@@ -0,0 +1,15 @@
+package schnorr
+
+import "errors"
+
+var (
+	// ErrInvalidDigest is the error returned when the message to sign or verify isn't a digest of
+	// the hash function specified by the options.
+	ErrInvalidDigest = errors.New("invalid digest")
+	// ErrInvalidPrivateKey is the error returned when the private key is malformed.
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+	// ErrInvalidPublicKey is the error returned when the public key isn't a valid point.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	// ErrInvalidSignature is the error returned when the signature fails verification.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
@@ -0,0 +1,272 @@
+// Package schnorr implements Schnorr signatures over P-256, where a STROBE transcript derives both
+// the nonce and the challenge as sketched by the STROBE specification.
+//
+// The signer absorbs the public key and message, forks the transcript keyed with the private key
+// to derive the nonce k, sends the commitment R = kG in clear, and extracts the challenge c with
+// PRF. The signature is R || s where s = k + c*x. Signing is deterministic.
+//
+// Operations involving the private key or nonce run in constant time.
+package schnorr
+
+import (
+	"crypto"
+	"io"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/internal/p256"
+)
+
+// PublicKey is a point on P-256.
+type PublicKey struct {
+	p *p256.Point
+}
+
+// PrivateKey is a scalar modulo the order of P-256 along with its public key.
+type PrivateKey struct {
+	PublicKey
+	d *p256.Scalar
+}
+
+// Bytes encodes the public key as a compressed point of PublicKeySize bytes.
+func (pub *PublicKey) Bytes() []byte {
+	return pub.p.BytesCompressed()
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	return ok && pub.p.Equal(xx.p)
+}
+
+// Bytes encodes the private key as a big-endian scalar of PrivateKeySize bytes.
+func (priv *PrivateKey) Bytes() []byte {
+	return priv.d.Bytes()
+}
+
+// Public returns the public key corresponding to priv, which implements crypto.Signer.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// Sign signs the message with priv, which implements crypto.Signer. rand is ignored since the
+// nonce is derived deterministically.
+//
+// If opts.HashFunc() is zero, message is signed as is. Otherwise, message must be a digest of the
+// given hash function, whose identity is bound to the signature.
+func (priv *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	h, err := hashOf(opts, message)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newTranscript(&priv.PublicKey, h, message)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := t.nonce(priv.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	commitment := p256.NewPoint().ScalarBaseMult(k).BytesCompressed()
+
+	c, err := t.challenge(commitment, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// s = k + c*x
+	s := c.Multiply(c, priv.d).Add(c, k)
+
+	return append(commitment, s.Bytes()...), nil
+}
+
+// GenerateKey generates a key pair reading randomness from rand.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	for {
+		var seed [p256.WideScalarSize]byte
+		if _, err := io.ReadFull(rand, seed[:]); err != nil {
+			return nil, err
+		}
+
+		// reduce the wide seed for negligible bias
+		d, err := new(p256.Scalar).SetWideBytes(seed[:])
+		if err != nil {
+			return nil, err
+		} else if d.IsZero() == 0 {
+			return newPrivateKey(d), nil
+		}
+	}
+}
+
+// NewPrivateKey loads a private key encoded by PrivateKey.Bytes.
+func NewPrivateKey(key []byte) (*PrivateKey, error) {
+	if len(key) != PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	d, err := new(p256.Scalar).SetCanonicalBytes(key)
+	if err != nil || d.IsZero() == 1 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return newPrivateKey(d), nil
+}
+
+// ParsePublicKey loads a public key encoded by PublicKey.Bytes.
+func ParsePublicKey(key []byte) (*PublicKey, error) {
+	p, err := p256.NewPoint().SetBytes(key)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return &PublicKey{p: p}, nil
+}
+
+// Verify reports whether sig is a valid signature of message by pub, where message is signed as
+// is.
+func Verify(pub *PublicKey, message, sig []byte) bool {
+	return VerifyWithOptions(pub, message, sig, crypto.Hash(0)) == nil
+}
+
+// VerifyWithOptions checks whether sig is a valid signature of message by pub, with the same opts
+// passed to Sign.
+func VerifyWithOptions(pub *PublicKey, message, sig []byte, opts crypto.SignerOpts) error {
+	h, err := hashOf(opts, message)
+	if err != nil {
+		return err
+	}
+
+	if len(sig) != SignatureSize {
+		return ErrInvalidSignature
+	}
+	commitment, sBytes := sig[:PublicKeySize], sig[PublicKeySize:]
+
+	r, err := p256.NewPoint().SetBytes(commitment)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	s, err := new(p256.Scalar).SetCanonicalBytes(sBytes)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	t, err := newTranscript(pub, h, message)
+	if err != nil {
+		return err
+	}
+
+	c, err := t.challenge(commitment, true)
+	if err != nil {
+		return err
+	}
+
+	// check sG == R + cP
+	l := p256.NewPoint().ScalarBaseMult(s)
+	cp := p256.NewPoint().ScalarMult(pub.p, c)
+	if !l.Equal(r.Add(r, cp)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// transcript is the STROBE transcript shared by signer and verifier.
+type transcript struct {
+	s *strobe.Strobe
+}
+
+// challenge absorbs the commitment, as sent by the signer or received by the verifier, and
+// extracts the challenge.
+func (t *transcript) challenge(commitment []byte, verifier bool) (*p256.Scalar, error) {
+	if err := t.s.AD([]byte(labelCommitment), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	r := append([]byte{}, commitment...)
+	send := t.s.SendCLR
+	if verifier {
+		send = t.s.RecvCLR
+	}
+	if err := send(r, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return t.scalar(labelChallenge)
+}
+
+// nonce derives the nonce from a fork of the transcript keyed with the private key.
+func (t *transcript) nonce(key []byte) (*p256.Scalar, error) {
+	fork := &transcript{s: t.s.Clone()}
+
+	if err := fork.s.AD([]byte(labelNonce), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := fork.s.KEY(key, false); err != nil {
+		return nil, err
+	}
+
+	for {
+		k, err := fork.scalar(labelNonce)
+		if err != nil {
+			return nil, err
+		} else if k.IsZero() == 0 {
+			return k, nil
+		}
+	}
+}
+
+// scalar extracts a scalar with wide output of PRF for negligible bias.
+func (t *transcript) scalar(label string) (*p256.Scalar, error) {
+	if err := t.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+
+	var wide [p256.WideScalarSize]byte
+	if err := t.s.PRF(wide[:], false); err != nil {
+		return nil, err
+	}
+
+	return new(p256.Scalar).SetWideBytes(wide[:])
+}
+
+func hashOf(opts crypto.SignerOpts, message []byte) (crypto.Hash, error) {
+	h := opts.HashFunc()
+	if h != 0 && len(message) != h.Size() {
+		return 0, ErrInvalidDigest
+	}
+
+	return h, nil
+}
+
+func newPrivateKey(d *p256.Scalar) *PrivateKey {
+	return &PrivateKey{PublicKey: PublicKey{p: p256.NewPoint().ScalarBaseMult(d)}, d: d}
+}
+
+func newTranscript(pub *PublicKey, h crypto.Hash, message []byte) (*transcript, error) {
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelPublicKey, pub.Bytes()},
+		{labelHash, []byte{byte(h)}},
+		{labelMessage, message},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &transcript{s: s}, nil
+}
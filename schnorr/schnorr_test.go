@@ -0,0 +1,197 @@
+package schnorr_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sammyne/strobe/schnorr"
+)
+
+var _ crypto.Signer = (*schnorr.PrivateKey)(nil)
+
+var order = elliptic.P256().Params().N.Bytes()
+
+func TestPrivateKey_Sign(t *testing.T) {
+	priv, err := schnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	msg := []byte("hello world")
+
+	sig, err := priv.Sign(nil, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("fail to sign: %v", err)
+	}
+
+	if !schnorr.Verify(&priv.PublicKey, msg, sig) {
+		t.Fatal("fail to verify")
+	}
+
+	sig2, err := priv.Sign(rand.Reader, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("fail to sign again: %v", err)
+	} else if !bytes.Equal(sig, sig2) {
+		t.Fatalf("non-deterministic signature: %x != %x", sig, sig2)
+	}
+
+	another, err := schnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate another key: %v", err)
+	}
+
+	testVector := []struct {
+		pub *schnorr.PublicKey
+		msg []byte
+		sig []byte
+	}{
+		{&another.PublicKey, msg, sig},
+		{&priv.PublicKey, []byte("hello world!"), sig},
+		{&priv.PublicKey, msg, flip(sig, 0)},
+		{&priv.PublicKey, msg, flip(sig, 5)},
+		{&priv.PublicKey, msg, flip(sig, schnorr.SignatureSize-1)},
+		{&priv.PublicKey, msg, sig[:schnorr.SignatureSize-1]},
+	}
+
+	for i, c := range testVector {
+		if schnorr.Verify(c.pub, c.msg, c.sig) {
+			t.Fatalf("#%d invalid signature passes verification", i)
+		}
+	}
+}
+
+func TestPrivateKey_Sign_Prehashed(t *testing.T) {
+	priv, err := schnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("hello world"))
+
+	sig, err := priv.Sign(nil, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("fail to sign: %v", err)
+	}
+
+	if err := schnorr.VerifyWithOptions(&priv.PublicKey, digest[:], sig, crypto.SHA256); err != nil {
+		t.Fatalf("fail to verify: %v", err)
+	}
+
+	// the hash function is bound to the signature
+	if schnorr.Verify(&priv.PublicKey, digest[:], sig) {
+		t.Fatal("signature of digest passes verification as plain message")
+	}
+
+	if _, err := priv.Sign(nil, digest[:20], crypto.SHA256); err != schnorr.ErrInvalidDigest {
+		t.Fatalf("invalid error for bad digest: expect %v, got %v", schnorr.ErrInvalidDigest, err)
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	priv, err := schnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	priv2, err := schnorr.NewPrivateKey(priv.Bytes())
+	if err != nil {
+		t.Fatalf("fail to load private key: %v", err)
+	} else if !priv.PublicKey.Equal(&priv2.PublicKey) {
+		t.Fatal("private key changes after round trip")
+	}
+
+	pub, err := schnorr.ParsePublicKey(priv.PublicKey.Bytes())
+	if err != nil {
+		t.Fatalf("fail to parse public key: %v", err)
+	} else if !priv.PublicKey.Equal(pub) {
+		t.Fatal("public key changes after round trip")
+	}
+
+	if _, err := schnorr.NewPrivateKey(make([]byte, schnorr.PrivateKeySize)); err != schnorr.ErrInvalidPrivateKey {
+		t.Fatalf("invalid error for zero key: expect %v, got %v", schnorr.ErrInvalidPrivateKey, err)
+	}
+
+	if _, err := schnorr.NewPrivateKey(order); err != schnorr.ErrInvalidPrivateKey {
+		t.Fatalf("invalid error for key of the order: expect %v, got %v", schnorr.ErrInvalidPrivateKey, err)
+	}
+
+	if _, err := schnorr.ParsePublicKey(make([]byte, schnorr.PublicKeySize)); err != schnorr.ErrInvalidPublicKey {
+		t.Fatalf("invalid error for bad point: expect %v, got %v", schnorr.ErrInvalidPublicKey, err)
+	}
+
+	uncompressed := elliptic.Marshal(elliptic.P256(), elliptic.P256().Params().Gx, elliptic.P256().Params().Gy)
+	if _, err := schnorr.ParsePublicKey(uncompressed); err != schnorr.ErrInvalidPublicKey {
+		t.Fatalf("invalid error for uncompressed point: expect %v, got %v", schnorr.ErrInvalidPublicKey, err)
+	}
+}
+
+func TestVerify_ScalarOutOfRange(t *testing.T) {
+	priv, err := schnorr.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key: %v", err)
+	}
+
+	msg := []byte("hello world")
+	sig, err := priv.Sign(nil, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("fail to sign: %v", err)
+	}
+
+	forged := append(sig[:schnorr.PublicKeySize:schnorr.PublicKeySize], order...)
+	if err := schnorr.VerifyWithOptions(&priv.PublicKey, msg, forged, crypto.Hash(0)); err != schnorr.ErrInvalidSignature {
+		t.Fatalf("invalid error: expect %v, got %v", schnorr.ErrInvalidSignature, err)
+	}
+}
+
+// TestVerify_Vectors checks signatures produced by this implementation, so as to pin down the
+// transcript.
+func TestVerify_Vectors(t *testing.T) {
+	type TestCase struct {
+		PrivateKey []byte
+		PublicKey  []byte
+		Message    []byte
+		Signature  []byte
+	}
+
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []TestCase
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, c := range testVector {
+		priv, err := schnorr.NewPrivateKey(c.PrivateKey)
+		if err != nil {
+			t.Fatalf("#%d fail to load private key: %v", i, err)
+		} else if !bytes.Equal(c.PublicKey, priv.PublicKey.Bytes()) {
+			t.Fatalf("#%d invalid public key: expect %x, got %x", i, c.PublicKey, priv.PublicKey.Bytes())
+		}
+
+		sig, err := priv.Sign(nil, c.Message, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("#%d fail to sign: %v", i, err)
+		} else if !bytes.Equal(c.Signature, sig) {
+			t.Fatalf("#%d invalid signature: expect %x, got %x", i, c.Signature, sig)
+		}
+
+		if !schnorr.Verify(&priv.PublicKey, c.Message, c.Signature) {
+			t.Fatalf("#%d fail to verify", i)
+		}
+	}
+}
+
+func flip(b []byte, i int) []byte {
+	out := append([]byte{}, b...)
+	out[i] ^= 1
+	return out
+}
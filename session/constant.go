@@ -0,0 +1,17 @@
+package session
+
+// RatchetLen is the number of bytes zeroed by the RATCHET of each rekey, which suffices for the
+// 256-bit security level.
+const RatchetLen = 32
+
+// TagLen is the length of the MAC of each record in bytes.
+const TagLen = 16
+
+// headerLen is the length of the record header in bytes.
+const headerLen = 1
+
+// flagKeyUpdate marks a record after which both parties rekey.
+const flagKeyUpdate = 1 << 0
+
+// labelKeyUpdate frames the epoch absorbed on rekey.
+const labelKeyUpdate = "key update"
@@ -0,0 +1,14 @@
+package session
+
+import "errors"
+
+var (
+	// ErrKeyUsageExceeded is the error returned when a hard limit of the current epoch would be
+	// exceeded, typically because the remote party doesn't rekey in time.
+	ErrKeyUsageExceeded = errors.New("key usage limit exceeded")
+	// ErrRecordTooShort is the error returned by Open when the record is truncated.
+	ErrRecordTooShort = errors.New("record too short")
+	// ErrSessionExpired is the error returned when a rekey would exceed the maximum number of
+	// epochs.
+	ErrSessionExpired = errors.New("session expired")
+)
@@ -0,0 +1,232 @@
+// Package session implements a record layer over STROBE for long-lived sessions, which ratchets
+// the key after a configurable usage so as to provide forward secrecy within the session.
+//
+// Each record is framed as a meta-CLR of a 1-byte header, followed by the SendENC of the payload
+// and a SendMAC tag. The sender raises the key-update flag in the header once the soft limits of
+// the current epoch are reached, after which both parties absorb a meta-AD of the new epoch and
+// RATCHET. Since the header is authenticated, the rekey is coordinated without extra messages.
+package session
+
+import (
+	"encoding/binary"
+
+	"github.com/sammyne/strobe"
+)
+
+// Limits specifies the key usage limits per epoch. A zero limit is unlimited.
+type Limits struct {
+	// RekeyAfterRecords is the number of records after which the sender triggers a rekey.
+	RekeyAfterRecords uint64
+	// RekeyAfterBytes is the number of payload bytes after which the sender triggers a rekey.
+	RekeyAfterBytes uint64
+	// MaxRecords is the hard limit on the number of records, beyond which ErrKeyUsageExceeded is
+	// returned.
+	MaxRecords uint64
+	// MaxBytes is the hard limit on the number of payload bytes, beyond which ErrKeyUsageExceeded
+	// is returned.
+	MaxBytes uint64
+	// MaxEpochs is the hard limit on the number of rekeys over the lifetime of the session, beyond
+	// which ErrSessionExpired is returned.
+	MaxEpochs uint64
+}
+
+// DefaultLimits rekeys after 1Mi records or 1GiB, and tolerates a peer lagging behind by 16 times
+// of that.
+var DefaultLimits = Limits{
+	RekeyAfterRecords: 1 << 20,
+	RekeyAfterBytes:   1 << 30,
+	MaxRecords:        1 << 24,
+	MaxBytes:          1 << 34,
+}
+
+// Session seals and opens records over a STROBE instance shared with the remote party, which
+// isn't safe for concurrent use.
+//
+// Once an error other than ErrKeyUsageExceeded and ErrSessionExpired occurs, the session is
+// broken and all further operations fail with that error.
+type Session struct {
+	s      *strobe.Strobe
+	limits Limits
+
+	epoch   uint64
+	records uint64
+	bytes   uint64
+
+	rekeyRequested bool
+	err            error
+}
+
+// Epoch returns the number of rekeys so far.
+func (s *Session) Epoch() uint64 {
+	return s.epoch
+}
+
+// Open verifies and decrypts a record produced by the remote party's Seal.
+func (s *Session) Open(record []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if len(record) < headerLen+TagLen {
+		return nil, ErrRecordTooShort
+	}
+
+	n := len(record) - TagLen - headerLen
+	if err := s.checkUsage(n); err != nil {
+		return nil, err
+	}
+	if record[0]&flagKeyUpdate != 0 {
+		if err := s.checkEpoch(); err != nil {
+			return nil, err
+		}
+	}
+
+	r := append([]byte{}, record...)
+
+	opts := &strobe.Options{}
+	if err := s.s.RecvCLR(r[:headerLen], &strobe.Options{Meta: true}); err != nil {
+		return nil, s.fail(err)
+	}
+
+	plaintext, err := s.s.RecvENC(r[headerLen:headerLen+n], opts)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+
+	if err := s.s.RecvMAC(r[headerLen+n:], opts); err != nil {
+		return nil, s.fail(err)
+	}
+
+	s.records, s.bytes = s.records+1, s.bytes+uint64(n)
+
+	if r[0]&flagKeyUpdate != 0 {
+		if err := s.ratchet(); err != nil {
+			return nil, s.fail(err)
+		}
+	}
+
+	return plaintext, nil
+}
+
+// Rekey makes the next record produced by Seal trigger a rekey regardless of the usage.
+func (s *Session) Rekey() {
+	s.rekeyRequested = true
+}
+
+// Seal encrypts and authenticates the plaintext into a record for the remote party. If the usage
+// reaches the soft limits, the record triggers a rekey.
+func (s *Session) Seal(plaintext []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if err := s.checkUsage(len(plaintext)); err != nil {
+		return nil, err
+	}
+
+	records, bytes := s.records+1, s.bytes+uint64(len(plaintext))
+	update := s.rekeyRequested || reached(records, s.limits.RekeyAfterRecords) ||
+		reached(bytes, s.limits.RekeyAfterBytes)
+	if update {
+		if err := s.checkEpoch(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, headerLen+len(plaintext)+TagLen)
+	if update {
+		out[0] |= flagKeyUpdate
+	}
+
+	opts := &strobe.Options{}
+	if err := s.s.SendCLR(out[:headerLen], &strobe.Options{Meta: true}); err != nil {
+		return nil, s.fail(err)
+	}
+
+	n := headerLen + len(plaintext)
+	copy(out[headerLen:], plaintext)
+	if _, err := s.s.SendENC(out[headerLen:n], opts); err != nil {
+		return nil, s.fail(err)
+	}
+
+	if err := s.s.SendMAC(out[n:], opts); err != nil {
+		return nil, s.fail(err)
+	}
+
+	s.records, s.bytes = records, bytes
+
+	if update {
+		if err := s.ratchet(); err != nil {
+			return nil, s.fail(err)
+		}
+	}
+
+	return out, nil
+}
+
+// Usage returns the number of records and payload bytes processed in the current epoch.
+func (s *Session) Usage() (records, bytes uint64) {
+	return s.records, s.bytes
+}
+
+// New wraps the STROBE instance, which should have been keyed identically by both parties, into a
+// session enforcing the given limits. A nil limits means DefaultLimits.
+func New(s *strobe.Strobe, limits *Limits) *Session {
+	if limits == nil {
+		limits = &DefaultLimits
+	}
+
+	return &Session{s: s, limits: *limits}
+}
+
+func (s *Session) checkEpoch() error {
+	if s.limits.MaxEpochs > 0 && s.epoch >= s.limits.MaxEpochs {
+		return ErrSessionExpired
+	}
+
+	return nil
+}
+
+func (s *Session) checkUsage(n int) error {
+	if exceeded(s.records+1, s.limits.MaxRecords) || exceeded(s.bytes+uint64(n), s.limits.MaxBytes) {
+		return ErrKeyUsageExceeded
+	}
+
+	return nil
+}
+
+func (s *Session) fail(err error) error {
+	s.err = err
+	return err
+}
+
+// ratchet starts a new epoch, where the state is ratcheted so that earlier records can't be
+// recovered from the new state.
+func (s *Session) ratchet() error {
+	s.epoch++
+
+	var epoch [8]byte
+	binary.LittleEndian.PutUint64(epoch[:], s.epoch)
+
+	if err := s.s.AD([]byte(labelKeyUpdate), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+	if err := s.s.AD(epoch[:], &strobe.Options{Meta: true, Streaming: true}); err != nil {
+		return err
+	}
+	if err := s.s.RATCHET(RatchetLen); err != nil {
+		return err
+	}
+
+	s.records, s.bytes, s.rekeyRequested = 0, 0, false
+
+	return nil
+}
+
+func exceeded(v, limit uint64) bool {
+	return limit > 0 && v > limit
+}
+
+func reached(v, limit uint64) bool {
+	return limit > 0 && v >= limit
+}
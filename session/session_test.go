@@ -0,0 +1,139 @@
+package session_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/session"
+)
+
+func TestSession_Rekey(t *testing.T) {
+	testVector := []struct {
+		limits session.Limits
+		sizes  []int
+		epochs []uint64 // epoch after each record
+	}{
+		{session.Limits{RekeyAfterRecords: 2}, []int{1, 1, 1, 1, 1}, []uint64{0, 1, 1, 2, 2}},
+		{session.Limits{RekeyAfterBytes: 10}, []int{4, 4, 4, 12, 0}, []uint64{0, 0, 1, 2, 2}},
+		{session.Limits{}, []int{1, 1, 1}, []uint64{0, 0, 0}},
+	}
+
+	for i, c := range testVector {
+		alice, bob := mustNewSessionPair(t, &c.limits, &c.limits)
+
+		for j, n := range c.sizes {
+			mustTransfer(t, alice, bob, make([]byte, n))
+
+			if alice.Epoch() != c.epochs[j] || bob.Epoch() != c.epochs[j] {
+				t.Fatalf("#%d-%d invalid epoch: expect %d, got %d and %d", i, j, c.epochs[j],
+					alice.Epoch(), bob.Epoch())
+			}
+		}
+	}
+}
+
+func TestSession_Rekey_Manual(t *testing.T) {
+	alice, bob := mustNewSessionPair(t, nil, nil)
+
+	mustTransfer(t, alice, bob, []byte("hello"))
+	alice.Rekey()
+	mustTransfer(t, alice, bob, []byte("world"))
+
+	if alice.Epoch() != 1 || bob.Epoch() != 1 {
+		t.Fatalf("invalid epoch: expect 1, got %d and %d", alice.Epoch(), bob.Epoch())
+	}
+
+	if records, bytes := bob.Usage(); records != 0 || bytes != 0 {
+		t.Fatalf("usage isn't reset: %d records, %d bytes", records, bytes)
+	}
+
+	// the other direction of the same transcript keeps working
+	mustTransfer(t, bob, alice, []byte("bye"))
+}
+
+func TestSession_Limits(t *testing.T) {
+	// the receiver refuses a sender not rekeying in time
+	alice, bob := mustNewSessionPair(t, &session.Limits{}, &session.Limits{MaxRecords: 2})
+	mustTransfer(t, alice, bob, nil)
+	mustTransfer(t, alice, bob, nil)
+
+	record, err := alice.Seal(nil)
+	if err != nil {
+		t.Fatalf("fail to seal: %v", err)
+	}
+	if _, err := bob.Open(record); err != session.ErrKeyUsageExceeded {
+		t.Fatalf("invalid error: expect %v, got %v", session.ErrKeyUsageExceeded, err)
+	}
+
+	// the sender refuses to exceed its own limits
+	alice, _ = mustNewSessionPair(t, &session.Limits{MaxBytes: 8}, nil)
+	if _, err := alice.Seal(make([]byte, 9)); err != session.ErrKeyUsageExceeded {
+		t.Fatalf("invalid error: expect %v, got %v", session.ErrKeyUsageExceeded, err)
+	}
+
+	// the session expires after the maximum number of epochs
+	limits := &session.Limits{RekeyAfterRecords: 1, MaxEpochs: 2}
+	alice, bob = mustNewSessionPair(t, limits, limits)
+	mustTransfer(t, alice, bob, nil)
+	mustTransfer(t, alice, bob, nil)
+	if _, err := alice.Seal(nil); err != session.ErrSessionExpired {
+		t.Fatalf("invalid error: expect %v, got %v", session.ErrSessionExpired, err)
+	}
+}
+
+func TestSession_Open_Tampered(t *testing.T) {
+	alice, bob := mustNewSessionPair(t, nil, nil)
+
+	record, err := alice.Seal([]byte("hello"))
+	if err != nil {
+		t.Fatalf("fail to seal: %v", err)
+	}
+
+	if _, err := bob.Open(record[:session.TagLen]); err != session.ErrRecordTooShort {
+		t.Fatalf("invalid error: expect %v, got %v", session.ErrRecordTooShort, err)
+	}
+
+	// forge the key-update flag
+	record[0] ^= 1
+	if _, err := bob.Open(record); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("invalid error: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+
+	record[0] ^= 1
+	if _, err := bob.Open(record); err != strobe.ErrAuthenticationFailed {
+		t.Fatalf("broken session isn't permanent: expect %v, got %v", strobe.ErrAuthenticationFailed, err)
+	}
+}
+
+func mustNewSessionPair(t *testing.T, aliceLimits, bobLimits *session.Limits) (*session.Session,
+	*session.Session) {
+	newStrobe := func() *strobe.Strobe {
+		s, err := strobe.New("session test", strobe.Bit128)
+		if err != nil {
+			t.Fatalf("fail to new strobe: %v", err)
+		}
+
+		if err := s.KEY([]byte("shared secret"), false); err != nil {
+			t.Fatalf("fail to KEY: %v", err)
+		}
+
+		return s
+	}
+
+	return session.New(newStrobe(), aliceLimits), session.New(newStrobe(), bobLimits)
+}
+
+func mustTransfer(t *testing.T, sender, receiver *session.Session, msg []byte) {
+	record, err := sender.Seal(msg)
+	if err != nil {
+		t.Fatalf("fail to seal: %v", err)
+	}
+
+	got, err := receiver.Open(record)
+	if err != nil {
+		t.Fatalf("fail to open: %v", err)
+	} else if fmt.Sprintf("%x", msg) != fmt.Sprintf("%x", got) {
+		t.Fatalf("invalid plaintext: expect %x, got %x", msg, got)
+	}
+}
@@ -0,0 +1,23 @@
+package siv
+
+// Proto is the STROBE proto of the construction.
+const Proto = "github.com/sammyne/strobe/siv"
+
+const (
+	// KeySize is the size of keys in bytes.
+	KeySize = 32
+	// NonceSize is the size of nonces of the AEAD returned by New in bytes.
+	NonceSize = 16
+	// TagSize is the size of the synthetic IV prepended to ciphertexts in bytes, which is large
+	// enough to make IV collisions of distinct messages negligible.
+	TagSize = 32
+)
+
+// Labels framing the operations of the transcripts.
+const (
+	labelAD        = "ad"
+	labelEncrypt   = "encrypt"
+	labelKey       = "key"
+	labelNonce     = "nonce"
+	labelPlaintext = "plaintext"
+)
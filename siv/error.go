@@ -0,0 +1,6 @@
+package siv
+
+import "errors"
+
+// ErrInvalidKeySize is the error returned when the key isn't KeySize bytes.
+var ErrInvalidKeySize = errors.New("invalid key size")
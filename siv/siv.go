@@ -0,0 +1,169 @@
+// Package siv implements a nonce-misuse-resistant deterministic AEAD in the SIV mode over STROBE,
+// as a cipher.AEAD.
+//
+// The synthetic IV is the SendMAC output of a transcript keyed with the key, after absorbing the
+// nonce, additional data and plaintext with AD. The plaintext is then encrypted by SendENC of a
+// fork of the keyed transcript bound to the IV, and the IV is prepended to the ciphertext. Open
+// decrypts with the IV, and recomputes the IV over the recovered plaintext to verify it with
+// RecvMAC.
+//
+// Since the IV depends on all inputs, repeating a nonce leaks nothing but whether the same
+// plaintext and additional data are sealed again, unlike stream-cipher-based AEADs whose
+// confidentiality breaks down totally. The AEAD returned by NewDeterministic takes no nonce at all,
+// which is suitable for deterministic encryption of keys, where equality of messages is meant to
+// leak.
+package siv
+
+import (
+	"crypto/cipher"
+
+	"github.com/sammyne/strobe"
+)
+
+type siv struct {
+	// keyed is the transcript keyed with the key, which is only cloned, and so safe for concurrent
+	// use
+	keyed     *strobe.Strobe
+	nonceSize int
+}
+
+// New returns the AEAD with nonces of NonceSize bytes, which may repeat at the cost of leaking
+// equality of messages under the same nonce.
+func New(key []byte) (cipher.AEAD, error) {
+	return newSIV(key, NonceSize)
+}
+
+// NewDeterministic returns the AEAD with zero-length nonces, which encrypts the same plaintext
+// and additional data into the same ciphertext.
+func NewDeterministic(key []byte) (cipher.AEAD, error) {
+	return newSIV(key, 0)
+}
+
+func (c *siv) NonceSize() int {
+	return c.nonceSize
+}
+
+func (c *siv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		panic("siv: incorrect nonce length given to Open")
+	}
+
+	if len(ciphertext) < TagSize {
+		return nil, strobe.ErrAuthenticationFailed
+	}
+	iv := append([]byte{}, ciphertext[:TagSize]...)
+
+	dec, err := c.fork(labelEncrypt, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := dec.RecvENC(append([]byte{}, ciphertext[TagSize:]...), &strobe.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := c.transcript(nonce, additionalData, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mac.RecvMAC(iv, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return append(dst, plaintext...), nil
+}
+
+func (c *siv) Overhead() int {
+	return TagSize
+}
+
+func (c *siv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("siv: incorrect nonce length given to Seal")
+	}
+
+	mac, err := c.transcript(nonce, additionalData, plaintext)
+	if err != nil {
+		panic(err)
+	}
+
+	iv := make([]byte, TagSize)
+	if err := mac.SendMAC(iv, &strobe.Options{}); err != nil {
+		panic(err)
+	}
+
+	enc, err := c.fork(labelEncrypt, iv)
+	if err != nil {
+		panic(err)
+	}
+
+	ciphertext, err := enc.SendENC(append([]byte{}, plaintext...), &strobe.Options{})
+	if err != nil {
+		panic(err)
+	}
+
+	return append(append(dst, iv...), ciphertext...)
+}
+
+// fork clones the keyed transcript and absorbs the labeled data.
+func (c *siv) fork(label string, data []byte) (*strobe.Strobe, error) {
+	s := c.keyed.Clone()
+
+	if err := s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(data, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// transcript absorbs all inputs into a fork of the keyed transcript, which is ready to send or
+// receive the IV as MAC.
+func (c *siv) transcript(nonce, additionalData, plaintext []byte) (*strobe.Strobe, error) {
+	s, err := c.fork(labelNonce, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelAD, additionalData},
+		{labelPlaintext, plaintext},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func newSIV(key []byte, nonceSize int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKeySize
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelKey), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, key...), false); err != nil {
+		return nil, err
+	}
+
+	return &siv{keyed: s, nonceSize: nonceSize}, nil
+}
@@ -0,0 +1,132 @@
+package siv_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/siv"
+)
+
+var key = bytes.Repeat([]byte{0x42}, siv.KeySize)
+
+func TestAEAD(t *testing.T) {
+	aead := mustNew(t, siv.New)
+	nonce := make([]byte, aead.NonceSize())
+	plaintext, ad := []byte("hello world"), []byte("additional data")
+
+	prefix := []byte("prefix")
+	sealed := aead.Seal(append([]byte{}, prefix...), nonce, plaintext, ad)
+	if !bytes.HasPrefix(sealed, prefix) {
+		t.Fatalf("Seal should append to dst")
+	} else if len(sealed) != len(prefix)+len(plaintext)+aead.Overhead() {
+		t.Fatalf("invalid length: expect %d, got %d", len(prefix)+len(plaintext)+aead.Overhead(), len(sealed))
+	}
+	ciphertext := sealed[len(prefix):]
+
+	got, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		t.Fatalf("fail to open: %v", err)
+	} else if !bytes.Equal(plaintext, got) {
+		t.Fatalf("invalid plaintext: expect %s, got %s", plaintext, got)
+	}
+
+	otherNonce := append([]byte{}, nonce...)
+	otherNonce[0] ^= 1
+
+	testVector := []struct {
+		nonce, ciphertext, ad []byte
+	}{
+		{nonce, flip(ciphertext, 0), ad},
+		{nonce, flip(ciphertext, siv.TagSize), ad},
+		{nonce, flip(ciphertext, len(ciphertext)-1), ad},
+		{nonce, ciphertext[:len(ciphertext)-1], ad},
+		{nonce, ciphertext[:siv.TagSize-1], ad},
+		{nonce, ciphertext, []byte("other data")},
+		{otherNonce, ciphertext, ad},
+	}
+
+	for i, c := range testVector {
+		if _, err := aead.Open(nil, c.nonce, c.ciphertext, c.ad); err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+// TestAEAD_RepeatedNonce checks that sealing under a repeated nonce leaks only the equality of
+// messages.
+func TestAEAD_RepeatedNonce(t *testing.T) {
+	for _, newAEAD := range []func([]byte) (cipher.AEAD, error){siv.New, siv.NewDeterministic} {
+		aead := mustNew(t, newAEAD)
+		nonce := make([]byte, aead.NonceSize())
+
+		p1, p2 := []byte("attack at dawn!"), []byte("attack at dusk!")
+
+		c1 := aead.Seal(nil, nonce, p1, nil)
+		if again := aead.Seal(nil, nonce, p1, nil); !bytes.Equal(c1, again) {
+			t.Fatalf("same inputs should yield the same ciphertext: %x != %x", c1, again)
+		}
+
+		if c1b := aead.Seal(nil, nonce, p1, []byte("ad")); bytes.Equal(c1, c1b) {
+			t.Fatal("ciphertexts should differ for different additional data")
+		}
+
+		c2 := aead.Seal(nil, nonce, p2, nil)
+		if bytes.Equal(c1[:siv.TagSize], c2[:siv.TagSize]) {
+			t.Fatal("IVs should differ for different plaintexts")
+		}
+
+		// the keystreams differ, so the XOR of ciphertexts isn't that of plaintexts
+		var x1, x2 []byte
+		for i := range p1 {
+			x1 = append(x1, p1[i]^p2[i])
+			x2 = append(x2, c1[siv.TagSize+i]^c2[siv.TagSize+i])
+		}
+		if bytes.Equal(x1, x2) {
+			t.Fatal("keystream should not be reused across plaintexts")
+		}
+	}
+}
+
+func TestNewDeterministic(t *testing.T) {
+	aead := mustNew(t, siv.NewDeterministic)
+	if aead.NonceSize() != 0 {
+		t.Fatalf("invalid nonce size: expect 0, got %d", aead.NonceSize())
+	}
+
+	ciphertext := aead.Seal(nil, nil, []byte("key"), nil)
+	if got, err := aead.Open(nil, nil, ciphertext, nil); err != nil {
+		t.Fatalf("fail to open: %v", err)
+	} else if string(got) != "key" {
+		t.Fatalf("invalid plaintext: expect key, got %s", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Seal should panic for wrong nonce size")
+		}
+	}()
+	aead.Seal(nil, make([]byte, siv.NonceSize), nil, nil)
+}
+
+func TestNew_InvalidKeySize(t *testing.T) {
+	if _, err := siv.New(key[1:]); err != siv.ErrInvalidKeySize {
+		t.Fatalf("invalid error: expect %v, got %v", siv.ErrInvalidKeySize, err)
+	}
+}
+
+func flip(b []byte, i int) []byte {
+	out := append([]byte{}, b...)
+	out[i] ^= 1
+	return out
+}
+
+func mustNew(t *testing.T, newAEAD func([]byte) (cipher.AEAD, error)) cipher.AEAD {
+	aead, err := newAEAD(key)
+	if err != nil {
+		t.Fatalf("fail to new AEAD: %v", err)
+	}
+
+	return aead
+}
@@ -0,0 +1,57 @@
+package spake2
+
+import (
+	"encoding/hex"
+
+	"github.com/sammyne/strobe/internal/p256"
+)
+
+// Proto is the STROBE proto of the protocol transcript.
+const Proto = "github.com/sammyne/strobe/spake2/P-256"
+
+const (
+	// ConfirmationSize is the size of key confirmation MACs in bytes.
+	ConfirmationSize = 32
+	// ShareSize is the size of shares, i.e. compressed points, in bytes.
+	ShareSize = p256.PointSize
+	// SessionKeySize is the size of the key ratcheted into the output session in bytes.
+	SessionKeySize = 32
+)
+
+// Labels framing the operations of the transcript.
+const (
+	labelConfirmA  = "confirm A"
+	labelConfirmB  = "confirm B"
+	labelIdentityA = "identity A"
+	labelIdentityB = "identity B"
+	labelPassword  = "password"
+	labelSession   = "session"
+	labelShareA    = "share A"
+	labelShareB    = "share B"
+	labelSharedKey = "shared key"
+)
+
+// The M and N points for P-256 as specified by RFC 9382, whose discrete logarithms are unknown.
+const (
+	encodedM = "02886e2f97ace46e55ba9dd7242579f2993b64e16ef3dcab95afd497333d8fa12f"
+	encodedN = "03d8bbd6c639c62937b04d997f38c3770719c629d7014d49a24b4f98baa1292b49"
+)
+
+var (
+	pointM = mustParsePoint(encodedM)
+	pointN = mustParsePoint(encodedN)
+)
+
+func mustParsePoint(s string) *p256.Point {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	out, err := p256.NewPoint().SetBytes(data)
+	if err != nil {
+		panic("spake2: invalid point " + s)
+	}
+
+	return out
+}
@@ -0,0 +1,16 @@
+package spake2
+
+import "errors"
+
+var (
+	// ErrInvalidMessage is the error returned when a message from the peer is of wrong length.
+	ErrInvalidMessage = errors.New("invalid message")
+	// ErrInvalidShare is the error returned when the share of the peer isn't a valid point, or
+	// yields the identity point as the shared key.
+	ErrInvalidShare = errors.New("invalid share")
+	// ErrMissingPassword is the error returned when the password is empty.
+	ErrMissingPassword = errors.New("missing password")
+	// ErrOutOfTurn is the error returned when a step is run after the exchange has finished or
+	// failed.
+	ErrOutOfTurn = errors.New("out of turn")
+)
@@ -0,0 +1,343 @@
+// Package spake2 implements the SPAKE2 password-authenticated key exchange over P-256, with the M
+// and N points of RFC 9382 and the protocol transcript absorbed into a STROBE instance.
+//
+// Party A (the initiator) and party B (the responder) share a password and agree on their
+// identities. The exchange takes three messages:
+//
+//	A -> B: pA = xG + wM
+//	B -> A: pB = yG + wN || MAC_B
+//	A -> B: MAC_A
+//
+// where w is derived from the password and identities. Both parties absorb the identities, the
+// shares, the shared point K = x*(pB - wN) = y*(pA - wM) and w into the transcript, and exchange
+// key confirmation MACs with SendMAC and RecvMAC. The transcript is then ratcheted into the output
+// session.
+//
+// The password is never exposed to offline guessing by passive eavesdroppers, and an active
+// attacker learns at most whether a single guess is right per exchange. Stretch the password with
+// a memory-hard function, e.g. balloon, if it's stored on a server.
+//
+// This package isn't interoperable with RFC 9382, whose transcript is hashed instead. Operations
+// involving w or the ephemeral scalars run in constant time.
+package spake2
+
+import (
+	cryptorand "crypto/rand"
+	"io"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/internal/p256"
+)
+
+// Config configures either party of the exchange.
+type Config struct {
+	// Password is the secret shared by both parties.
+	Password []byte
+	// IdentityA is the identity of party A, which may be empty.
+	IdentityA []byte
+	// IdentityB is the identity of party B, which may be empty.
+	IdentityB []byte
+	// Rand is the source of randomness for the ephemeral scalar, which is crypto/rand.Reader if
+	// nil.
+	Rand io.Reader
+}
+
+// Initiator is party A of the exchange.
+type Initiator struct {
+	t     *transcript
+	x     *p256.Scalar
+	share []byte
+}
+
+// Responder is party B of the exchange.
+type Responder struct {
+	t *transcript
+	// responded tells whether Respond has succeeded
+	responded bool
+}
+
+// NewInitiator starts the exchange as party A, whose share is then available via Message.
+func NewInitiator(config *Config) (*Initiator, error) {
+	t, err := newTranscript(config)
+	if err != nil {
+		return nil, err
+	}
+
+	x, share, err := t.newShare(pointM)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.clr(labelShareA, share, false); err != nil {
+		return nil, err
+	}
+
+	return &Initiator{t: t, x: x, share: share}, nil
+}
+
+// Message returns the first message of the exchange to send to party B.
+func (a *Initiator) Message() []byte {
+	return append([]byte{}, a.share...)
+}
+
+// Finish consumes the message of party B, returning the key confirmation to send back and the
+// output session, where A plays the STROBE initiator.
+//
+// A wrong password or tampered transcript fails the key confirmation of party B with
+// strobe.ErrAuthenticationFailed. Finish runs at most once.
+func (a *Initiator) Finish(msg []byte) (confirmation []byte, session *strobe.Strobe, err error) {
+	if a.t == nil {
+		return nil, nil, ErrOutOfTurn
+	}
+	t, x := a.t, a.x
+	a.t, a.x = nil, nil
+
+	if len(msg) != ShareSize+ConfirmationSize {
+		return nil, nil, ErrInvalidMessage
+	}
+	share, mac := msg[:ShareSize], msg[ShareSize:]
+
+	if err := t.clr(labelShareB, share, true); err != nil {
+		return nil, nil, err
+	}
+
+	if err := t.absorbSharedKey(x, share, pointN); err != nil {
+		return nil, nil, err
+	}
+
+	if err := t.mac(labelConfirmB, mac, true); err != nil {
+		return nil, nil, err
+	}
+
+	confirmation = make([]byte, ConfirmationSize)
+	if err := t.mac(labelConfirmA, confirmation, false); err != nil {
+		return nil, nil, err
+	}
+
+	if session, err = t.session(); err != nil {
+		return nil, nil, err
+	}
+
+	return confirmation, session, nil
+}
+
+// NewResponder prepares party B of the exchange.
+func NewResponder(config *Config) (*Responder, error) {
+	t, err := newTranscript(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Responder{t: t}, nil
+}
+
+// Respond consumes the message of party A, returning the share and key confirmation of party B.
+// Respond runs at most once.
+func (b *Responder) Respond(msg []byte) ([]byte, error) {
+	if b.t == nil || b.responded {
+		return nil, ErrOutOfTurn
+	}
+	t := b.t
+	b.t = nil
+
+	if len(msg) != ShareSize {
+		return nil, ErrInvalidMessage
+	}
+
+	y, share, err := t.newShare(pointN)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.clr(labelShareA, msg, true); err != nil {
+		return nil, err
+	}
+	if err := t.clr(labelShareB, share, false); err != nil {
+		return nil, err
+	}
+
+	if err := t.absorbSharedKey(y, msg, pointM); err != nil {
+		return nil, err
+	}
+
+	mac := make([]byte, ConfirmationSize)
+	if err := t.mac(labelConfirmB, mac, false); err != nil {
+		return nil, err
+	}
+
+	b.t, b.responded = t, true
+	return append(share, mac...), nil
+}
+
+// Finish checks the key confirmation of party A, returning the output session, where B plays the
+// STROBE responder.
+//
+// A wrong password or tampered transcript fails with strobe.ErrAuthenticationFailed. Finish runs at
+// most once.
+func (b *Responder) Finish(confirmation []byte) (*strobe.Strobe, error) {
+	if b.t == nil || !b.responded {
+		return nil, ErrOutOfTurn
+	}
+	t := b.t
+	b.t = nil
+
+	if len(confirmation) != ConfirmationSize {
+		return nil, ErrInvalidMessage
+	}
+
+	if err := t.mac(labelConfirmA, confirmation, true); err != nil {
+		return nil, err
+	}
+
+	return t.session()
+}
+
+// transcript is the STROBE transcript shared by both parties.
+type transcript struct {
+	s    *strobe.Strobe
+	w    *p256.Scalar
+	rand io.Reader
+}
+
+// absorbSharedKey computes K = k*(share - w*p) with the ephemeral scalar k, given that the cofactor
+// of P-256 is 1, and absorbs K then w.
+func (t *transcript) absorbSharedKey(k *p256.Scalar, share []byte, p *p256.Point) error {
+	s, err := p256.NewPoint().SetBytes(share)
+	if err != nil {
+		return ErrInvalidShare
+	}
+
+	// -w*p
+	wp := p256.NewPoint().ScalarMult(p, new(p256.Scalar).Negate(t.w))
+
+	sharedKey := s.ScalarMult(s.Add(s, wp), k)
+	if sharedKey.IsIdentity() {
+		return ErrInvalidShare
+	}
+
+	if err := t.s.AD([]byte(labelSharedKey), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+	if err := t.s.KEY(sharedKey.BytesCompressed(), false); err != nil {
+		return err
+	}
+
+	if err := t.s.AD([]byte(labelPassword), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+	return t.s.KEY(t.w.Bytes(), false)
+}
+
+// clr sends or receives the share in clear.
+func (t *transcript) clr(label string, share []byte, recv bool) error {
+	if err := t.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	op := t.s.SendCLR
+	if recv {
+		op = t.s.RecvCLR
+	}
+
+	return op(append([]byte{}, share...), &strobe.Options{})
+}
+
+// mac sends the key confirmation into mac, or checks the received one.
+func (t *transcript) mac(label string, mac []byte, recv bool) error {
+	if err := t.s.AD([]byte(label), &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	if recv {
+		return t.s.RecvMAC(append([]byte{}, mac...), &strobe.Options{})
+	}
+
+	return t.s.SendMAC(mac, &strobe.Options{})
+}
+
+// newShare generates the ephemeral scalar k and the share k*G + w*p.
+func (t *transcript) newShare(p *p256.Point) (*p256.Scalar, []byte, error) {
+	k := new(p256.Scalar)
+	for k.IsZero() == 1 {
+		var seed [p256.WideScalarSize]byte
+		if _, err := io.ReadFull(t.rand, seed[:]); err != nil {
+			return nil, nil, err
+		}
+
+		// reduce the wide seed for negligible bias
+		if _, err := k.SetWideBytes(seed[:]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	kg := p256.NewPoint().ScalarBaseMult(k)
+	wp := p256.NewPoint().ScalarMult(p, t.w)
+
+	return k, kg.Add(kg, wp).BytesCompressed(), nil
+}
+
+// session ratchets the transcript into the output session, so that the confirmation MACs can't be
+// related to later outputs.
+func (t *transcript) session() (*strobe.Strobe, error) {
+	if err := t.s.AD([]byte(labelSession), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := t.s.RATCHET(SessionKeySize); err != nil {
+		return nil, err
+	}
+
+	return t.s, nil
+}
+
+// newTranscript absorbs the identities and derives w from the password on a fork of the
+// transcript, which binds w to the identities.
+func newTranscript(config *Config) (*transcript, error) {
+	if len(config.Password) == 0 {
+		return nil, ErrMissingPassword
+	}
+
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelIdentityA, config.IdentityA},
+		{labelIdentityB, config.IdentityB},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	fork := s.Clone()
+	if err := fork.AD([]byte(labelPassword), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := fork.KEY(append([]byte{}, config.Password...), false); err != nil {
+		return nil, err
+	}
+
+	var wide [p256.WideScalarSize]byte
+	if err := fork.PRF(wide[:], false); err != nil {
+		return nil, err
+	}
+	w, err := new(p256.Scalar).SetWideBytes(wide[:])
+	if err != nil {
+		return nil, err
+	}
+
+	rand := config.Rand
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	return &transcript{s: s, w: w, rand: rand}, nil
+}
@@ -0,0 +1,165 @@
+package spake2_test
+
+import (
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/spake2"
+)
+
+func TestExchange(t *testing.T) {
+	a, b := mustNewParties(t, newConfig("1234"), newConfig("1234"))
+
+	msgB, err := b.Respond(a.Message())
+	if err != nil {
+		t.Fatalf("fail to respond: %v", err)
+	}
+
+	confirmation, sessionA, err := a.Finish(msgB)
+	if err != nil {
+		t.Fatalf("fail to finish A: %v", err)
+	}
+
+	sessionB, err := b.Finish(confirmation)
+	if err != nil {
+		t.Fatalf("fail to finish B: %v", err)
+	}
+
+	// the output sessions agree, with A being the initiator
+	const msg = "hello world"
+	buf := []byte(msg)
+	if _, err := sessionA.SendENC(buf, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to SendENC: %v", err)
+	}
+	if _, err := sessionB.RecvENC(buf, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to RecvENC: %v", err)
+	} else if string(buf) != msg {
+		t.Fatalf("invalid plaintext: expect %s, got %s", msg, buf)
+	}
+
+	mac := make([]byte, 16)
+	if err := sessionB.SendMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to SendMAC: %v", err)
+	}
+	if err := sessionA.RecvMAC(mac, &strobe.Options{}); err != nil {
+		t.Fatalf("fail to RecvMAC: %v", err)
+	}
+
+	// steps run once only
+	if _, err := b.Respond(a.Message()); err != spake2.ErrOutOfTurn {
+		t.Fatalf("invalid error for Respond: expect %v, got %v", spake2.ErrOutOfTurn, err)
+	}
+	if _, _, err := a.Finish(msgB); err != spake2.ErrOutOfTurn {
+		t.Fatalf("invalid error for Finish: expect %v, got %v", spake2.ErrOutOfTurn, err)
+	}
+}
+
+func TestExchange_Mismatched(t *testing.T) {
+	otherIdentity := newConfig("1234")
+	otherIdentity.IdentityB = []byte("mallory")
+
+	testVector := []struct {
+		name string
+		a, b *spake2.Config
+	}{
+		{"wrong password", newConfig("1234"), newConfig("1235")},
+		{"wrong identity", newConfig("1234"), otherIdentity},
+	}
+
+	for _, c := range testVector {
+		a, b := mustNewParties(t, c.a, c.b)
+
+		msgB, err := b.Respond(a.Message())
+		if err != nil {
+			t.Fatalf("%s: fail to respond: %v", c.name, err)
+		}
+
+		if _, _, err := a.Finish(msgB); err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestExchange_Tampered(t *testing.T) {
+	flip := func(b []byte, i int) []byte {
+		out := append([]byte{}, b...)
+		out[i] ^= 1
+		return out
+	}
+
+	testVector := []struct {
+		name    string
+		tamperA func([]byte) []byte
+		tamperB func([]byte) []byte
+		tamperC func([]byte) []byte
+		expectB error
+		expectA error
+	}{
+		// flipping the prefix of a share negates the point, which stays valid
+		{"share A", func(m []byte) []byte { return flip(m, 0) }, nil, nil, nil, strobe.ErrAuthenticationFailed},
+		{"share B", nil, func(m []byte) []byte { return flip(m, 0) }, nil, nil, strobe.ErrAuthenticationFailed},
+		{"MAC B", nil, func(m []byte) []byte { return flip(m, len(m)-1) }, nil, nil, strobe.ErrAuthenticationFailed},
+		{"MAC A", nil, nil, func(m []byte) []byte { return flip(m, 0) }, nil, nil},
+		{"short share A", func(m []byte) []byte { return m[:1] }, nil, nil, spake2.ErrInvalidMessage, nil},
+		{"invalid point A", func(m []byte) []byte { m = append([]byte{}, m...); m[0] = 0x04; return m }, nil, nil, spake2.ErrInvalidShare, nil},
+		{"invalid point B", nil, func(m []byte) []byte { m = append([]byte{}, m...); m[0] = 0x04; return m }, nil, nil, spake2.ErrInvalidShare},
+	}
+
+	for _, c := range testVector {
+		a, b := mustNewParties(t, newConfig("1234"), newConfig("1234"))
+
+		msgA := a.Message()
+		if c.tamperA != nil {
+			msgA = c.tamperA(msgA)
+		}
+
+		msgB, err := b.Respond(msgA)
+		if err != c.expectB {
+			t.Fatalf("%s: invalid error for Respond: expect %v, got %v", c.name, c.expectB, err)
+		} else if err != nil {
+			continue
+		}
+		if c.tamperB != nil {
+			msgB = c.tamperB(msgB)
+		}
+
+		confirmation, _, err := a.Finish(msgB)
+		if err != c.expectA {
+			t.Fatalf("%s: invalid error for A.Finish: expect %v, got %v", c.name, c.expectA, err)
+		} else if err != nil {
+			continue
+		}
+
+		if _, err := b.Finish(c.tamperC(confirmation)); err != strobe.ErrAuthenticationFailed {
+			t.Fatalf("%s: invalid error for B.Finish: expect %v, got %v", c.name, strobe.ErrAuthenticationFailed, err)
+		}
+	}
+}
+
+func TestNewInitiator_MissingPassword(t *testing.T) {
+	if _, err := spake2.NewInitiator(newConfig("")); err != spake2.ErrMissingPassword {
+		t.Fatalf("invalid error: expect %v, got %v", spake2.ErrMissingPassword, err)
+	}
+}
+
+func mustNewParties(t *testing.T, configA, configB *spake2.Config) (*spake2.Initiator, *spake2.Responder) {
+	a, err := spake2.NewInitiator(configA)
+	if err != nil {
+		t.Fatalf("fail to new initiator: %v", err)
+	}
+
+	b, err := spake2.NewResponder(configB)
+	if err != nil {
+		t.Fatalf("fail to new responder: %v", err)
+	}
+
+	return a, b
+}
+
+func newConfig(password string) *spake2.Config {
+	return &spake2.Config{
+		Password:  []byte(password),
+		IdentityA: []byte("device"),
+		IdentityB: []byte("server"),
+	}
+}
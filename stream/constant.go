@@ -0,0 +1,37 @@
+package stream
+
+const (
+	// DefaultChunkSize is the default size of plaintext chunks in bytes.
+	DefaultChunkSize = 64 << 10
+	// MaxChunkSize is the maximum size of plaintext chunks in bytes.
+	MaxChunkSize = 16 << 20
+)
+
+// MinKeyLen is the minimum length of keys in bytes.
+const MinKeyLen = 32
+
+// DefaultProto is the default STROBE proto of the stream.
+const DefaultProto = "github.com/sammyne/strobe/stream/v1"
+
+const (
+	// SaltLen is the length of the random salt diversifying each stream in bytes.
+	SaltLen = 32
+	// TagLen is the length of the MAC of the header and each chunk in bytes.
+	TagLen = 16
+)
+
+// Version is the version of the stream format.
+const Version = 1
+
+// chunkHeaderLen is the length of the chunk header, i.e. 1-byte flags followed by the 4-byte
+// little-endian length of the chunk.
+const chunkHeaderLen = 5
+
+// flagFinal marks the last chunk of the stream.
+const flagFinal = 1 << 0
+
+// labelHeader frames the stream header absorbed as AD.
+const labelHeader = "header"
+
+// magic leads the stream header.
+var magic = [4]byte{'S', 'T', 'R', 'B'}
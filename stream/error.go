@@ -0,0 +1,23 @@
+package stream
+
+import "errors"
+
+var (
+	// ErrClosed is the error returned when writing to a closed Writer.
+	ErrClosed = errors.New("writer closed")
+	// ErrInvalidChunk is the error returned when a chunk header is malformed.
+	ErrInvalidChunk = errors.New("invalid chunk")
+	// ErrInvalidConfig is the error returned when the config is unsupported.
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrInvalidKey is the error returned when the key is shorter than MinKeyLen.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrInvalidHeader is the error returned when the stream header is malformed or of another
+	// proto.
+	ErrInvalidHeader = errors.New("invalid header")
+	// ErrTrailingData is the error returned when data follows the final chunk.
+	ErrTrailingData = errors.New("trailing data after final chunk")
+	// ErrTruncated is the error returned when the stream ends before the final chunk.
+	ErrTruncated = errors.New("stream truncated")
+	// ErrUnsupportedVersion is the error returned when the stream is of an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+)
@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// header is the plaintext header of a stream, laid out as
+//	magic (4) | version (1) | security level in bytes (1) | chunk size (4, little-endian) |
+//	proto length (1) | proto | salt (SaltLen)
+// and followed by a TagLen-byte MAC.
+type header struct {
+	level     strobe.SecurityLevel
+	chunkSize int
+	proto     string
+	salt      []byte
+}
+
+func (h *header) marshal() []byte {
+	out := make([]byte, 0, 11+len(h.proto)+SaltLen)
+
+	out = append(out, magic[:]...)
+	out = append(out, Version, byte(h.level/8))
+	out = binary.LittleEndian.AppendUint32(out, uint32(h.chunkSize))
+	out = append(out, byte(len(h.proto)))
+	out = append(out, h.proto...)
+	out = append(out, h.salt...)
+
+	return out
+}
+
+// newStrobe initializes the transcript with the header and key. The header MAC is left to the
+// caller.
+func (h *header) newStrobe(raw, key []byte) (*strobe.Strobe, error) {
+	s, err := strobe.New(h.proto, h.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelHeader), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.AD(raw, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	if err := s.KEY(append([]byte{}, key...), false); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// readHeader reads the header except its MAC, and returns the raw bytes read along with the
+// parsed header.
+func readHeader(r io.Reader) ([]byte, *header, error) {
+	raw := make([]byte, 11)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, nil, ErrInvalidHeader
+	}
+
+	if [4]byte(raw[:4]) != magic {
+		return nil, nil, ErrInvalidHeader
+	} else if raw[4] != Version {
+		return nil, nil, ErrUnsupportedVersion
+	}
+
+	out := &header{
+		level:     strobe.SecurityLevel(raw[5]) * 8,
+		chunkSize: int(binary.LittleEndian.Uint32(raw[6:10])),
+	}
+	if out.chunkSize <= 0 || out.chunkSize > MaxChunkSize {
+		return nil, nil, ErrInvalidHeader
+	}
+
+	rest := make([]byte, int(raw[10])+SaltLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, nil, ErrInvalidHeader
+	}
+	out.proto, out.salt = string(rest[:len(rest)-SaltLen]), rest[len(rest)-SaltLen:]
+
+	return append(raw, rest...), out, nil
+}
@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Reader decrypts a stream produced by Writer. Data is returned only after the MAC of its chunk
+// is verified, and io.EOF is returned only after the final chunk.
+type Reader struct {
+	r         io.Reader
+	s         *strobe.Strobe
+	chunkSize int
+
+	plaintext []byte
+	err       error
+}
+
+// Read reads the decrypted data.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.err = r.readChunk()
+	}
+
+	n := copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+
+	return n, nil
+}
+
+func (r *Reader) readChunk() error {
+	hdr := make([]byte, chunkHeaderLen)
+	if _, err := io.ReadFull(r.r, hdr); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	} else if err != nil {
+		return err
+	}
+
+	final := hdr[0] == flagFinal
+	n := int(binary.LittleEndian.Uint32(hdr[1:]))
+	if (hdr[0] != 0 && !final) || n > r.chunkSize || (!final && n != r.chunkSize) {
+		return ErrInvalidChunk
+	}
+
+	if err := r.s.RecvCLR(hdr, &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, n+TagLen)
+	if _, err := io.ReadFull(r.r, chunk); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	} else if err != nil {
+		return err
+	}
+
+	opts := &strobe.Options{}
+
+	plaintext, err := r.s.RecvENC(chunk[:n], opts)
+	if err != nil {
+		return err
+	}
+
+	if err := r.s.RecvMAC(chunk[n:], opts); err != nil {
+		return err
+	}
+
+	r.plaintext = plaintext
+	if !final {
+		return nil
+	}
+
+	// a single Read may return no data without an error, so read until EOF
+	var trailing [1]byte
+	if _, err := io.ReadFull(r.r, trailing[:]); err == nil {
+		return ErrTrailingData
+	} else if err != io.EOF {
+		return err
+	}
+
+	return io.EOF
+}
+
+// NewReader reads and verifies the stream header from r, and returns a Reader decrypting the
+// stream with the key. Only the Proto field of config is used, which must match the header.
+func NewReader(r io.Reader, key []byte, config *Config) (*Reader, error) {
+	if len(key) < MinKeyLen {
+		return nil, ErrInvalidKey
+	}
+
+	proto := DefaultProto
+	if config != nil && config.Proto != "" {
+		proto = config.Proto
+	}
+
+	raw, h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	} else if h.proto != proto {
+		return nil, ErrInvalidHeader
+	}
+
+	s, err := h.newStrobe(raw, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var mac [TagLen]byte
+	if _, err := io.ReadFull(r, mac[:]); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	if err := s.RecvMAC(mac[:], &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	return &Reader{r: r, s: s, chunkSize: h.chunkSize}, nil
+}
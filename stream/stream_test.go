@@ -0,0 +1,187 @@
+package stream_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/stream"
+)
+
+const chunkSize = 16
+
+var key = []byte("a super secret key of 32 bytes!!")
+
+func TestStream(t *testing.T) {
+	for _, n := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, 1000} {
+		plaintext := mustRandBytes(t, n)
+		ciphertext := mustEncrypt(t, plaintext)
+
+		got, err := decrypt(ciphertext, key)
+		if err != nil {
+			t.Fatalf("%d bytes: fail to decrypt: %v", n, err)
+		} else if !bytes.Equal(plaintext, got) {
+			t.Fatalf("%d bytes: invalid plaintext: expect %x, got %x", n, plaintext, got)
+		}
+	}
+}
+
+func TestStream_Tampered(t *testing.T) {
+	plaintext := mustRandBytes(t, 3*chunkSize+5)
+	ciphertext := mustEncrypt(t, plaintext)
+
+	headerLen := len(ciphertext) - 4*(5+stream.TagLen) - len(plaintext)
+	chunkLen := 5 + chunkSize + stream.TagLen
+	chunk := func(i int) []byte {
+		return ciphertext[headerLen+i*chunkLen : headerLen+(i+1)*chunkLen]
+	}
+
+	concat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	testVector := []struct {
+		name       string
+		ciphertext []byte
+		key        []byte
+		expect     error
+	}{
+		{"wrong key", ciphertext, []byte("another secret key of 32 bytes!!"), strobe.ErrAuthenticationFailed},
+		{"flipped byte", flip(ciphertext, headerLen+10), key, strobe.ErrAuthenticationFailed},
+		{"tampered salt", flip(ciphertext, headerLen-stream.TagLen-1), key, strobe.ErrAuthenticationFailed},
+		{"truncated header", ciphertext[:10], key, stream.ErrInvalidHeader},
+		{"dropped final chunk", ciphertext[:headerLen+3*chunkLen], key, stream.ErrTruncated},
+		{"cut final chunk", ciphertext[:len(ciphertext)-1], key, stream.ErrTruncated},
+		{
+			"swapped chunks",
+			concat(ciphertext[:headerLen], chunk(1), chunk(0), ciphertext[headerLen+2*chunkLen:]),
+			key,
+			strobe.ErrAuthenticationFailed,
+		},
+		{"trailing data", concat(ciphertext, []byte{0}), key, stream.ErrTrailingData},
+	}
+
+	for _, c := range testVector {
+		if _, err := decrypt(c.ciphertext, c.key); err != c.expect {
+			t.Fatalf("%s: invalid error: expect %v, got %v", c.name, c.expect, err)
+		}
+	}
+}
+
+func TestNewReader_Proto(t *testing.T) {
+	ciphertext := mustEncrypt(t, []byte("hello"))
+
+	config := &stream.Config{Proto: "another proto"}
+	if _, err := stream.NewReader(bytes.NewReader(ciphertext), key, config); err != stream.ErrInvalidHeader {
+		t.Fatalf("invalid error: expect %v, got %v", stream.ErrInvalidHeader, err)
+	}
+}
+
+func TestNewReader_TrailingData(t *testing.T) {
+	ciphertext := mustEncrypt(t, []byte("hello"))
+
+	// the trailing byte comes after a read returning neither data nor error
+	r, err := stream.NewReader(&stallingReader{r: bytes.NewReader(append(ciphertext, 0))}, key, nil)
+	if err != nil {
+		t.Fatalf("fail to new reader: %v", err)
+	}
+
+	if _, err := io.ReadAll(r); err != stream.ErrTrailingData {
+		t.Fatalf("invalid error: expect %v, got %v", stream.ErrTrailingData, err)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	ciphertext := mustEncrypt(t, []byte("hello"))
+
+	if _, err := stream.NewWriter(io.Discard, key[1:], nil); err != stream.ErrInvalidKey {
+		t.Fatalf("invalid error of NewWriter: expect %v, got %v", stream.ErrInvalidKey, err)
+	}
+
+	if _, err := decrypt(ciphertext, key[1:]); err != stream.ErrInvalidKey {
+		t.Fatalf("invalid error of NewReader: expect %v, got %v", stream.ErrInvalidKey, err)
+	}
+}
+
+func TestWriter_Close(t *testing.T) {
+	w, err := stream.NewWriter(io.Discard, key, nil)
+	if err != nil {
+		t.Fatalf("fail to new writer: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	if _, err := w.Write([]byte("hello")); err != stream.ErrClosed {
+		t.Fatalf("invalid error: expect %v, got %v", stream.ErrClosed, err)
+	}
+}
+
+func decrypt(ciphertext, key []byte) ([]byte, error) {
+	r, err := stream.NewReader(bytes.NewReader(ciphertext), key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func flip(b []byte, i int) []byte {
+	out := append([]byte{}, b...)
+	out[i] ^= 1
+	return out
+}
+
+func mustEncrypt(t *testing.T, plaintext []byte) []byte {
+	var out bytes.Buffer
+
+	w, err := stream.NewWriter(&out, key, &stream.Config{ChunkSize: chunkSize, Level: strobe.Bit256})
+	if err != nil {
+		t.Fatalf("fail to new writer: %v", err)
+	}
+
+	// write in pieces unaligned to chunks
+	for p := plaintext; len(p) > 0; {
+		n := 7
+		if n > len(p) {
+			n = len(p)
+		}
+
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatalf("fail to write: %v", err)
+		}
+		p = p[n:]
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("fail to close: %v", err)
+	}
+
+	return out.Bytes()
+}
+
+func mustRandBytes(t *testing.T, n int) []byte {
+	out := make([]byte, n)
+	if _, err := rand.Read(out); err != nil {
+		t.Fatalf("fail to read random bytes: %v", err)
+	}
+
+	return out
+}
+
+// stallingReader returns no data and no error on every other Read.
+type stallingReader struct {
+	r       io.Reader
+	stalled bool
+}
+
+func (r *stallingReader) Read(p []byte) (int, error) {
+	if r.stalled = !r.stalled; r.stalled {
+		return 0, nil
+	}
+
+	return r.r.Read(p)
+}
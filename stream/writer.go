@@ -0,0 +1,154 @@
+// Package stream implements a chunked streaming encryption format over STROBE, so that data of
+// arbitrary size can be encrypted and decrypted in constant memory.
+//
+// A stream starts with a header carrying the version, security level, chunk size, proto and a
+// random salt, which is absorbed before the key and authenticated by a MAC. The plaintext is then
+// split into chunks, each framed as a meta-CLR of its flags and length, followed by the SendENC of
+// the chunk and a SendMAC tag. Since all chunks run through a single transcript, chunks can't be
+// reordered, and the flag marking the final chunk prevents truncation.
+package stream
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/sammyne/strobe"
+)
+
+// Config specifies how a stream is produced.
+type Config struct {
+	// Proto is the STROBE proto, which defaults to DefaultProto if empty.
+	Proto string
+	// Level is the security level, which defaults to strobe.Bit128 if zero.
+	Level strobe.SecurityLevel
+	// ChunkSize is the size of plaintext chunks, which defaults to DefaultChunkSize if zero.
+	ChunkSize int
+	// Rand is the source of randomness for the salt, which defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Writer encrypts data written to it into the underlying writer.
+type Writer struct {
+	w   io.Writer
+	s   *strobe.Strobe
+	buf []byte
+	err error
+}
+
+// Close encrypts the buffered data as the final chunk. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.err = w.writeChunk(true); w.err == nil {
+		w.err = ErrClosed
+		return nil
+	}
+
+	return w.err
+}
+
+// Write buffers the data, and encrypts it into the underlying writer chunk by chunk.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var n int
+	for len(p) > 0 {
+		// a full chunk is written only if more data follows, since the last chunk must be final
+		if len(w.buf) == cap(w.buf) {
+			if w.err = w.writeChunk(false); w.err != nil {
+				return n, w.err
+			}
+		}
+
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		n, p = n+m, p[m:]
+	}
+
+	return n, nil
+}
+
+func (w *Writer) writeChunk(final bool) error {
+	out := make([]byte, chunkHeaderLen+len(w.buf)+TagLen)
+	if final {
+		out[0] = flagFinal
+	}
+	binary.LittleEndian.PutUint32(out[1:chunkHeaderLen], uint32(len(w.buf)))
+
+	if err := w.s.SendCLR(out[:chunkHeaderLen], &strobe.Options{Meta: true}); err != nil {
+		return err
+	}
+
+	opts := &strobe.Options{}
+
+	n := chunkHeaderLen + len(w.buf)
+	copy(out[chunkHeaderLen:], w.buf)
+	if _, err := w.s.SendENC(out[chunkHeaderLen:n], opts); err != nil {
+		return err
+	}
+
+	if err := w.s.SendMAC(out[n:], opts); err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+
+	_, err := w.w.Write(out)
+	return err
+}
+
+// NewWriter writes the stream header into w, and returns a Writer encrypting data under the key.
+// A nil config means the default one. Close must be called to finish the stream.
+func NewWriter(w io.Writer, key []byte, config *Config) (*Writer, error) {
+	if len(key) < MinKeyLen {
+		return nil, ErrInvalidKey
+	}
+
+	var c Config
+	if config != nil {
+		c = *config
+	}
+	if c.Proto == "" {
+		c.Proto = DefaultProto
+	}
+	if c.Level == 0 {
+		c.Level = strobe.Bit128
+	}
+	if c.ChunkSize == 0 {
+		c.ChunkSize = DefaultChunkSize
+	}
+	if c.Rand == nil {
+		c.Rand = rand.Reader
+	}
+
+	if len(c.Proto) > 255 || c.ChunkSize < 0 || c.ChunkSize > MaxChunkSize {
+		return nil, ErrInvalidConfig
+	}
+
+	h := &header{level: c.Level, chunkSize: c.ChunkSize, proto: c.Proto, salt: make([]byte, SaltLen)}
+	if _, err := io.ReadFull(c.Rand, h.salt); err != nil {
+		return nil, err
+	}
+
+	raw := h.marshal()
+	s, err := h.newStrobe(raw, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var mac [TagLen]byte
+	if err := s.SendMAC(mac[:], &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(append(raw, mac[:]...)); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, s: s, buf: make([]byte, 0, c.ChunkSize)}, nil
+}
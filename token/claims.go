@@ -0,0 +1,50 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Claims are the standard claims of tokens, whose times are in seconds since the Unix epoch. It
+// can be embedded in application-specific claims, which are validated by the Verifier as well.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+}
+
+// NewClaims returns the claims issued at now and expiring after ttl.
+func NewClaims(now time.Time, ttl time.Duration) Claims {
+	return Claims{IssuedAt: now.Unix(), NotBefore: now.Unix(), ExpiresAt: now.Add(ttl).Unix()}
+}
+
+// validate checks the claims against the time now tolerating the clock skew of leeway, and the
+// expected audience and issuer unless empty.
+func (c *Claims) validate(now time.Time, leeway time.Duration, audience, issuer string) error {
+	if c.ExpiresAt == 0 {
+		return ErrMissingExpiry
+	}
+
+	t := now.Unix()
+	skew := int64(leeway / time.Second)
+
+	if t > c.ExpiresAt+skew {
+		return ErrExpired
+	} else if c.NotBefore != 0 && t+skew < c.NotBefore {
+		return ErrNotYetValid
+	} else if c.IssuedAt != 0 && t+skew < c.IssuedAt {
+		return ErrIssuedInFuture
+	}
+
+	if audience != "" && c.Audience != audience {
+		return fmt.Errorf("%w: expect %q, got %q", ErrInvalidAudience, audience, c.Audience)
+	} else if issuer != "" && c.Issuer != issuer {
+		return fmt.Errorf("%w: expect %q, got %q", ErrInvalidIssuer, issuer, c.Issuer)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package token
+
+// Proto is the STROBE proto of tokens.
+const Proto = "github.com/sammyne/strobe/token/v1"
+
+const (
+	// Version is the version of the token format.
+	Version = "v1"
+	// Purpose is the purpose of tokens, which are encrypted with a shared key.
+	Purpose = "local"
+)
+
+// Header prefixes all tokens of the format.
+const Header = Version + "." + Purpose + "."
+
+const (
+	// KeySize is the size of keys in bytes.
+	KeySize = 32
+	// MACSize is the size of the MAC in bytes.
+	MACSize = 32
+	// NonceSize is the size of the random nonce of each token in bytes.
+	NonceSize = 32
+)
+
+// Labels framing the operations of the transcript.
+const (
+	labelClaims = "claims"
+	labelFooter = "footer"
+	labelHeader = "header"
+	labelKey    = "key"
+	labelMAC    = "mac"
+	labelNonce  = "nonce"
+)
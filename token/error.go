@@ -0,0 +1,29 @@
+package token
+
+import "errors"
+
+var (
+	// ErrExpired is the error returned when the token has expired.
+	ErrExpired = errors.New("token expired")
+	// ErrInvalidAudience is the error returned when the audience of the token isn't the expected
+	// one.
+	ErrInvalidAudience = errors.New("invalid audience")
+	// ErrInvalidIssuer is the error returned when the issuer of the token isn't the expected one.
+	ErrInvalidIssuer = errors.New("invalid issuer")
+	// ErrInvalidKeySize is the error returned when a key isn't KeySize bytes.
+	ErrInvalidKeySize = errors.New("invalid key size")
+	// ErrIssuedInFuture is the error returned when the token claims to be issued in the future.
+	ErrIssuedInFuture = errors.New("token issued in the future")
+	// ErrMalformedToken is the error returned when the token can't be decoded.
+	ErrMalformedToken = errors.New("malformed token")
+	// ErrMissingExpiry is the error returned when the token has no expiry.
+	ErrMissingExpiry = errors.New("missing expiry")
+	// ErrNotYetValid is the error returned when the token isn't valid before its nbf claim.
+	ErrNotYetValid = errors.New("token not yet valid")
+	// ErrUnknownKey is the error returned when the key ID in the footer matches no key of the
+	// verifier.
+	ErrUnknownKey = errors.New("unknown key")
+	// ErrUnsupportedVersion is the error returned when the token isn't prefixed by Header, i.e. of
+	// another version or purpose.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+)
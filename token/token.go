@@ -0,0 +1,286 @@
+// Package token implements an authenticated encrypted token format in the spirit of PASETO
+// local tokens, built solely on STROBE, for session tokens and the like.
+//
+// A token is laid out as
+//
+//	v1.local.<base64url(nonce || ciphertext || mac)>[.<base64url(footer)>]
+//
+// where the ciphertext is the JSON claims encrypted by SendENC, and the MAC is the SendMAC output
+// of a transcript keyed with the key, which also absorbs the header, the footer and a random nonce.
+// The footer is authenticated but not encrypted, and carries the ID of the key for rotation.
+//
+// The Verifier only accepts tokens prefixed by Header, decrypts the claims once the MAC checks
+// out, and then validates the standard claims, i.e. exp, nbf, iat, aud and iss.
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sammyne/strobe"
+)
+
+// Footer is the authenticated and unencrypted footer of tokens.
+type Footer struct {
+	// KeyID is the ID of the key the token is encrypted with.
+	KeyID string `json:"kid,omitempty"`
+}
+
+// Issuer issues tokens with a key, which is safe for concurrent use.
+type Issuer struct {
+	keyID string
+	key   []byte
+	rand  io.Reader
+}
+
+// NewIssuer returns the issuer encrypting tokens with the key of KeySize bytes, whose ID is put
+// in the footer unless empty.
+func NewIssuer(keyID string, key []byte) (*Issuer, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKeySize
+	}
+
+	return &Issuer{keyID: keyID, key: append([]byte{}, key...), rand: rand.Reader}, nil
+}
+
+// Issue encodes claims as JSON, and encrypts it into a token. The claims should embed or be a
+// Claims with an expiry, since tokens without one are rejected by verifiers.
+func (i *Issuer) Issue(claims interface{}) (string, error) {
+	plaintext, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	var footer []byte
+	if i.keyID != "" {
+		if footer, err = json.Marshal(Footer{KeyID: i.keyID}); err != nil {
+			return "", err
+		}
+	}
+
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(i.rand, nonce); err != nil {
+		return "", err
+	}
+
+	s, err := transcript(i.key, footer, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.AD([]byte(labelClaims), &strobe.Options{Meta: true}); err != nil {
+		return "", err
+	}
+	ciphertext, err := s.SendENC(plaintext, &strobe.Options{})
+	if err != nil {
+		return "", err
+	}
+
+	mac := make([]byte, MACSize)
+	if err := s.AD([]byte(labelMAC), &strobe.Options{Meta: true}); err != nil {
+		return "", err
+	}
+	if err := s.SendMAC(mac, &strobe.Options{}); err != nil {
+		return "", err
+	}
+
+	payload := append(append(nonce, ciphertext...), mac...)
+
+	out := Header + base64.RawURLEncoding.EncodeToString(payload)
+	if footer != nil {
+		out += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+
+	return out, nil
+}
+
+// VerifierConfig specifies the tokens accepted by a verifier.
+type VerifierConfig struct {
+	// Keys maps key IDs to keys of KeySize bytes, where the empty ID is for tokens without footer.
+	Keys map[string][]byte
+	// Audience is the expected aud claim, which isn't checked if empty.
+	Audience string
+	// Issuer is the expected iss claim, which isn't checked if empty.
+	Issuer string
+	// Leeway is the tolerated clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+	// Now returns the current time, which defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verifier decrypts and validates tokens, which is safe for concurrent use.
+type Verifier struct {
+	keys     map[string][]byte
+	audience string
+	issuer   string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier returns the verifier specified by config.
+func NewVerifier(config *VerifierConfig) (*Verifier, error) {
+	keys := make(map[string][]byte, len(config.Keys))
+	for id, v := range config.Keys {
+		if len(v) != KeySize {
+			return nil, ErrInvalidKeySize
+		}
+		keys[id] = append([]byte{}, v...)
+	}
+
+	out := &Verifier{
+		keys:     keys,
+		audience: config.Audience,
+		issuer:   config.Issuer,
+		leeway:   config.Leeway,
+		now:      config.Now,
+	}
+	if out.now == nil {
+		out.now = time.Now
+	}
+
+	return out, nil
+}
+
+// Verify decrypts the token and validates its standard claims, which are returned. If claims
+// isn't nil, the JSON claims are also decoded into it.
+func (v *Verifier) Verify(token string, claims interface{}) (*Claims, error) {
+	payload, footer, err := decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var f Footer
+	if footer != nil {
+		if err := json.Unmarshal(footer, &f); err != nil {
+			return nil, ErrMalformedToken
+		}
+	}
+
+	key, ok := v.keys[f.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if len(payload) < NonceSize+MACSize {
+		return nil, ErrMalformedToken
+	}
+	nonce, ciphertext := payload[:NonceSize], payload[NonceSize:len(payload)-MACSize]
+	mac := payload[len(payload)-MACSize:]
+
+	s, err := transcript(key, footer, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelClaims), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	plaintext, err := s.RecvENC(ciphertext, &strobe.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelMAC), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.RecvMAC(mac, &strobe.Options{}); err != nil {
+		return nil, err
+	}
+
+	var out Claims
+	if err := json.Unmarshal(plaintext, &out); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := out.validate(v.now(), v.leeway, v.audience, v.issuer); err != nil {
+		return nil, err
+	}
+
+	if claims != nil {
+		if err := json.Unmarshal(plaintext, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return &out, nil
+}
+
+// ParseFooter returns the footer of the token without verifying it, e.g. for picking a key. It's
+// empty if the token has no footer.
+func ParseFooter(token string) (*Footer, error) {
+	_, footer, err := decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var out Footer
+	if footer != nil {
+		if err := json.Unmarshal(footer, &out); err != nil {
+			return nil, ErrMalformedToken
+		}
+	}
+
+	return &out, nil
+}
+
+// decode splits the token into the decoded payload and footer, the latter of which is nil if
+// absent. The payload is a fresh buffer, since ENC and MAC work in place.
+func decode(token string) (payload, footer []byte, err error) {
+	if !strings.HasPrefix(token, Header) {
+		return nil, nil, ErrUnsupportedVersion
+	}
+
+	p, f, hasFooter := strings.Cut(token[len(Header):], ".")
+	if payload, err = base64.RawURLEncoding.DecodeString(p); err != nil {
+		return nil, nil, ErrMalformedToken
+	}
+
+	if !hasFooter {
+		return payload, nil, nil
+	}
+
+	if footer, err = base64.RawURLEncoding.DecodeString(f); err != nil || len(footer) == 0 {
+		return nil, nil, ErrMalformedToken
+	}
+
+	return payload, footer, nil
+}
+
+// transcript returns the STROBE instance keyed with the key, after absorbing the header, footer
+// and nonce, which is ready to encrypt or decrypt the claims.
+func transcript(key, footer, nonce []byte) (*strobe.Strobe, error) {
+	s, err := strobe.New(Proto, strobe.Bit128)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AD([]byte(labelKey), &strobe.Options{Meta: true}); err != nil {
+		return nil, err
+	}
+	if err := s.KEY(append([]byte{}, key...), false); err != nil {
+		return nil, err
+	}
+
+	steps := []struct {
+		label string
+		data  []byte
+	}{
+		{labelHeader, []byte(Header)},
+		{labelFooter, footer},
+		{labelNonce, nonce},
+	}
+	for _, v := range steps {
+		if err := s.AD([]byte(v.label), &strobe.Options{Meta: true}); err != nil {
+			return nil, err
+		}
+		if err := s.AD(v.data, &strobe.Options{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,220 @@
+package token_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sammyne/strobe"
+	"github.com/sammyne/strobe/token"
+)
+
+type sessionClaims struct {
+	token.Claims
+	Role string `json:"role"`
+}
+
+var (
+	key1 = bytes.Repeat([]byte{0x01}, token.KeySize)
+	key2 = bytes.Repeat([]byte{0x02}, token.KeySize)
+	now  = time.Unix(1700000000, 0)
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	claims := sessionClaims{Claims: token.NewClaims(now, time.Hour), Role: "admin"}
+	claims.Issuer, claims.Audience, claims.Subject = "auth", "api", "alice"
+
+	tok := mustIssue(t, "k1", key1, claims)
+	if !strings.HasPrefix(tok, token.Header) {
+		t.Fatalf("invalid header: expect prefix %q, got %q", token.Header, tok)
+	}
+
+	if footer, err := token.ParseFooter(tok); err != nil {
+		t.Fatalf("fail to parse footer: %v", err)
+	} else if footer.KeyID != "k1" {
+		t.Fatalf("invalid key ID: expect %q, got %q", "k1", footer.KeyID)
+	}
+
+	v := mustNewVerifier(t, &token.VerifierConfig{
+		Keys:     map[string][]byte{"k1": key1, "k2": key2},
+		Audience: "api",
+		Issuer:   "auth",
+		Now:      func() time.Time { return now },
+	})
+
+	var got sessionClaims
+	std, err := v.Verify(tok, &got)
+	if err != nil {
+		t.Fatalf("fail to verify: %v", err)
+	} else if got != claims {
+		t.Fatalf("invalid claims: expect %+v, got %+v", claims, got)
+	} else if *std != claims.Claims {
+		t.Fatalf("invalid standard claims: expect %+v, got %+v", claims.Claims, *std)
+	}
+
+	// tokens of both keys are accepted during rotation
+	if _, err := v.Verify(mustIssue(t, "k2", key2, claims), nil); err != nil {
+		t.Fatalf("fail to verify token of rotated key: %v", err)
+	}
+}
+
+func TestVerifier_Verify_Claims(t *testing.T) {
+	v := mustNewVerifier(t, &token.VerifierConfig{
+		Keys:     map[string][]byte{"": key1},
+		Audience: "api",
+		Issuer:   "auth",
+		Leeway:   time.Minute,
+		Now:      func() time.Time { return now },
+	})
+
+	valid := token.Claims{Issuer: "auth", Audience: "api", ExpiresAt: now.Unix() + 1}
+	with := func(f func(c *token.Claims)) token.Claims {
+		out := valid
+		f(&out)
+		return out
+	}
+
+	testVector := []struct {
+		claims token.Claims
+		expect error
+	}{
+		{valid, nil},
+		{with(func(c *token.Claims) { c.ExpiresAt = now.Unix() - 60 }), nil},
+		{with(func(c *token.Claims) { c.ExpiresAt = now.Unix() - 61 }), token.ErrExpired},
+		{with(func(c *token.Claims) { c.ExpiresAt = 0 }), token.ErrMissingExpiry},
+		{with(func(c *token.Claims) { c.NotBefore = now.Unix() + 60 }), nil},
+		{with(func(c *token.Claims) { c.NotBefore = now.Unix() + 61 }), token.ErrNotYetValid},
+		{with(func(c *token.Claims) { c.IssuedAt = now.Unix() + 61 }), token.ErrIssuedInFuture},
+		{with(func(c *token.Claims) { c.Audience = "web" }), token.ErrInvalidAudience},
+		{with(func(c *token.Claims) { c.Audience = "" }), token.ErrInvalidAudience},
+		{with(func(c *token.Claims) { c.Issuer = "evil" }), token.ErrInvalidIssuer},
+	}
+
+	for i, c := range testVector {
+		if _, err := v.Verify(mustIssue(t, "", key1, c.claims), nil); !errors.Is(err, c.expect) {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
+
+func TestVerifier_Verify_Invalid(t *testing.T) {
+	claims := token.NewClaims(now, time.Hour)
+	tok := mustIssue(t, "k1", key1, claims)
+	payload, footer, _ := strings.Cut(strings.TrimPrefix(tok, token.Header), ".")
+
+	flip := func(i int) string {
+		b, _ := base64.RawURLEncoding.DecodeString(payload)
+		b[i] ^= 1
+		return token.Header + base64.RawURLEncoding.EncodeToString(b) + "." + footer
+	}
+	withFooter := func(f string) string {
+		return token.Header + payload + "." + base64.RawURLEncoding.EncodeToString([]byte(f))
+	}
+
+	v := mustNewVerifier(t, &token.VerifierConfig{
+		Keys: map[string][]byte{"k1": key1, "k2": key2},
+		Now:  func() time.Time { return now },
+	})
+
+	testVector := []struct {
+		token  string
+		expect error
+	}{
+		{"v2.local." + payload + "." + footer, token.ErrUnsupportedVersion},
+		{"v1.public." + payload + "." + footer, token.ErrUnsupportedVersion},
+		{strings.TrimPrefix(tok, "v1."), token.ErrUnsupportedVersion},
+		{token.Header + "!" + payload + "." + footer, token.ErrMalformedToken},
+		{token.Header + payload + ".", token.ErrMalformedToken},
+		{token.Header + payload[:40] + "." + footer, token.ErrMalformedToken},
+		{withFooter("not json"), token.ErrMalformedToken},
+		{withFooter(`{"kid":"k3"}`), token.ErrUnknownKey},
+		{token.Header + payload, token.ErrUnknownKey},
+		// the footer is authenticated
+		{withFooter(`{"kid":"k2"}`), strobe.ErrAuthenticationFailed},
+		{withFooter(`{"kid":"k1","x":1}`), strobe.ErrAuthenticationFailed},
+		{flip(0), strobe.ErrAuthenticationFailed},
+		{flip(token.NonceSize), strobe.ErrAuthenticationFailed},
+		{flip(token.NonceSize + token.MACSize), strobe.ErrAuthenticationFailed},
+	}
+
+	for i, c := range testVector {
+		if _, err := v.Verify(c.token, nil); err != c.expect {
+			t.Fatalf("#%d invalid error: expect %v, got %v", i, c.expect, err)
+		}
+	}
+}
+
+func TestNew_InvalidKeySize(t *testing.T) {
+	if _, err := token.NewIssuer("", key1[1:]); err != token.ErrInvalidKeySize {
+		t.Fatalf("invalid error for issuer: expect %v, got %v", token.ErrInvalidKeySize, err)
+	}
+
+	config := &token.VerifierConfig{Keys: map[string][]byte{"k1": key1, "k2": key2[1:]}}
+	if _, err := token.NewVerifier(config); err != token.ErrInvalidKeySize {
+		t.Fatalf("invalid error for verifier: expect %v, got %v", token.ErrInvalidKeySize, err)
+	}
+}
+
+// TestVerifier_Verify_Vectors checks tokens produced by this implementation, so as to pin down the
+// format.
+func TestVerifier_Verify_Vectors(t *testing.T) {
+	type TestCase struct {
+		KeyID  string
+		Key    []byte
+		Claims map[string]interface{}
+		Token  string
+	}
+
+	raw, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testVector []TestCase
+	if err := json.Unmarshal(raw, &testVector); err != nil {
+		t.Fatalf("fail to parse test vector: %v", err)
+	}
+
+	for i, c := range testVector {
+		v := mustNewVerifier(t, &token.VerifierConfig{
+			Keys: map[string][]byte{c.KeyID: c.Key},
+			Now:  func() time.Time { return now },
+		})
+
+		var got map[string]interface{}
+		if _, err := v.Verify(c.Token, &got); err != nil {
+			t.Fatalf("#%d fail to verify: %v", i, err)
+		} else if !reflect.DeepEqual(c.Claims, got) {
+			t.Fatalf("#%d invalid claims: expect %v, got %v", i, c.Claims, got)
+		}
+	}
+}
+
+func mustIssue(t *testing.T, keyID string, key []byte, claims interface{}) string {
+	issuer, err := token.NewIssuer(keyID, key)
+	if err != nil {
+		t.Fatalf("fail to new issuer: %v", err)
+	}
+
+	out, err := issuer.Issue(claims)
+	if err != nil {
+		t.Fatalf("fail to issue: %v", err)
+	}
+
+	return out
+}
+
+func mustNewVerifier(t *testing.T, config *token.VerifierConfig) *token.Verifier {
+	out, err := token.NewVerifier(config)
+	if err != nil {
+		t.Fatalf("fail to new verifier: %v", err)
+	}
+
+	return out
+}